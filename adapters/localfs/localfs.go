@@ -67,6 +67,18 @@ func (fs FileSystem) Remove(name string) error {
 	return os.Remove(path)
 }
 
+func (fs FileSystem) Rename(oldpath, newpath string) error {
+	oldPath, err := fs.path(oldpath, "rename")
+	if err != nil {
+		return err
+	}
+	newPath, err := fs.path(newpath, "rename")
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
 func (fs FileSystem) Stat(name string) (fs.FileInfo, error) {
 	path, err := fs.path(name, "stat")
 	if err != nil {
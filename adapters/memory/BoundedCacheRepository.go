@@ -0,0 +1,613 @@
+package memory
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.llib.dev/frameless/pkg/cache"
+	"go.llib.dev/frameless/ports/crud"
+	"go.llib.dev/frameless/ports/crud/extid"
+	"go.llib.dev/frameless/ports/iterators"
+)
+
+func NewBoundedCacheRepository[Entity, ID any](m *Memory, maxEntries int) *BoundedCacheRepository[Entity, ID] {
+	return &BoundedCacheRepository[Entity, ID]{Memory: m, MaxEntries: maxEntries}
+}
+
+// BoundedCacheRepository is a cache.Repository with a limited size.
+// When the cached entities and query hits grow above the limits,
+// the least recently or the least frequently used entries are evicted, depending on the Policy.
+//
+// Evicting an entity also evicts the query hits that reference it,
+// and a query hit is not kept when its entities don't fit into the cache,
+// thus a cached query never yields partial results, but it is executed again against the cache Source.
+type BoundedCacheRepository[Entity, ID any] struct {
+	Memory *Memory
+	// MaxEntries is the maximum number of the cached entities and query hits together.
+	// Zero means that the number of the entries is not limited.
+	MaxEntries int
+	// MaxBytes is the maximum approximate size of the cached entities and query hits together.
+	// Zero means that the size is not limited.
+	MaxBytes int
+	// Policy is the eviction policy that selects which entries are evicted first.
+	//
+	// Default: LRU
+	Policy EvictionPolicy
+	// SizeOf estimates the size of a cached entity or query hit in bytes.
+	//
+	// Default: an approximation based on the in-memory representation of the value.
+	SizeOf func(v any) int
+
+	mutex   sync.Mutex
+	entries map[boundedCacheKey]*boundedCacheEntry
+	queue   boundedCacheQueue
+	// refs holds for each entity key the keys of the query hits that reference it,
+	// and hitRefs holds for each query hit key the entity keys it references.
+	refs    map[string]map[string]struct{}
+	hitRefs map[string][]string
+	seq     uint64
+	bytes   int
+	stats   BoundedCacheStats
+}
+
+// EvictionPolicy tells which entries should be evicted first from a BoundedCacheRepository.
+type EvictionPolicy int
+
+const (
+	// LRU evicts the least recently used entries first.
+	LRU EvictionPolicy = iota
+	// LFU evicts the least frequently used entries first,
+	// and among the equally used entries, the least recently used one.
+	LFU
+)
+
+// BoundedCacheStats describes the current state and the evictions of a BoundedCacheRepository.
+type BoundedCacheStats struct {
+	// Entries is the number of the tracked entities and query hits.
+	Entries int
+	// Bytes is the approximate size of the tracked entities and query hits.
+	Bytes int
+	// EvictedEntities is the number of the entities evicted so far.
+	EvictedEntities int
+	// EvictedHits is the number of the query hits evicted so far,
+	// including the ones evicted together with an entity they referenced.
+	EvictedHits int
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) Stats() BoundedCacheStats {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	stats := cr.stats
+	stats.Entries = len(cr.entries)
+	stats.Bytes = cr.bytes
+	return stats
+}
+
+// EvictionCount implements cache.EvictionCounter.
+func (cr *BoundedCacheRepository[Entity, ID]) EvictionCount() int {
+	stats := cr.Stats()
+	return stats.EvictedEntities + stats.EvictedHits
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) Entities() cache.EntityRepository[Entity, ID] {
+	return &boundedCacheEntityRepository[Entity, ID]{
+		Repository: cr.entities(),
+		Bounded:    cr,
+	}
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) Hits() cache.HitRepository[ID] {
+	return &boundedCacheHitRepository[Entity, ID]{
+		Repository: cr.hits(),
+		Bounded:    cr,
+	}
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) BeginTx(ctx context.Context) (context.Context, error) {
+	return cr.Memory.BeginTx(ctx)
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) CommitTx(ctx context.Context) error {
+	return cr.Memory.CommitTx(ctx)
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) RollbackTx(ctx context.Context) error {
+	return cr.Memory.RollbackTx(ctx)
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) entities() *Repository[Entity, ID] {
+	return &Repository[Entity, ID]{
+		Memory:    cr.Memory,
+		Namespace: fmt.Sprintf("memory.BoundedCacheRepository.Entities[%T, %T]", *new(Entity), *new(ID)),
+	}
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) hits() *Repository[cache.Hit[ID], cache.HitID] {
+	return &Repository[cache.Hit[ID], cache.HitID]{
+		Memory:    cr.Memory,
+		Namespace: fmt.Sprintf("memory.BoundedCacheRepository.Hits[%T]", *new(ID)),
+	}
+}
+
+type boundedCacheKind int
+
+const (
+	boundedCacheEntity boundedCacheKind = iota
+	boundedCacheHit
+)
+
+type boundedCacheKey struct {
+	Kind boundedCacheKind
+	Key  string
+}
+
+type boundedCacheEntry struct {
+	boundedCacheKey
+	Size   int
+	Uses   int
+	UsedAt uint64
+	// Delete removes the entry from the underlying repository.
+	Delete func(context.Context) error
+
+	index int
+}
+
+// track registers the use of an entry, and evicts entries while the cache is over its limits.
+func (cr *BoundedCacheRepository[Entity, ID]) track(ctx context.Context, key boundedCacheKey, v any, del func(context.Context) error) error {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	cr.init()
+	return cr.add(ctx, key, v, del)
+}
+
+// trackHit registers the use of a query hit together with the entities it references.
+// When one of the referenced entities is already evicted,
+// for example because the query returned more entities than the cache can hold,
+// then the hit is evicted right away, as it would yield partial results.
+func (cr *BoundedCacheRepository[Entity, ID]) trackHit(ctx context.Context, key boundedCacheKey, entKeys []string, v any, del func(context.Context) error) error {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	cr.init()
+	for _, entKey := range entKeys {
+		if _, ok := cr.entries[boundedCacheKey{Kind: boundedCacheEntity, Key: entKey}]; ok {
+			continue
+		}
+		e, ok := cr.remove(key)
+		if !ok {
+			e = &boundedCacheEntry{boundedCacheKey: key, Delete: del}
+		}
+		return cr.delete(ctx, e)
+	}
+	cr.reference(key.Key, entKeys)
+	return cr.add(ctx, key, v, del)
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) add(ctx context.Context, key boundedCacheKey, v any, del func(context.Context) error) error {
+	size := cr.sizeOf(v)
+	e, ok := cr.entries[key]
+	if ok {
+		cr.bytes += size - e.Size
+		e.Size = size
+		cr.use(e)
+	} else {
+		e = &boundedCacheEntry{boundedCacheKey: key, Size: size, Delete: del}
+		cr.entries[key] = e
+		cr.bytes += size
+		cr.use(e)
+		heap.Push(&cr.queue, e)
+	}
+	return cr.evict(ctx, e)
+}
+
+// touch registers the read of an entry.
+// Entries which are present in the underlying repository but unknown,
+// like the ones restored by a rolled back transaction, become tracked again.
+func (cr *BoundedCacheRepository[Entity, ID]) touch(ctx context.Context, key boundedCacheKey, v any, del func(context.Context) error) error {
+	cr.mutex.Lock()
+	cr.init()
+	e, ok := cr.entries[key]
+	if ok {
+		cr.use(e)
+	}
+	cr.mutex.Unlock()
+	if ok {
+		return nil
+	}
+	return cr.track(ctx, key, v, del)
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) use(e *boundedCacheEntry) {
+	cr.seq++
+	e.Uses++
+	e.UsedAt = cr.seq
+	if 0 <= e.index && e.index < cr.queue.Len() && cr.queue.Entries[e.index] == e {
+		heap.Fix(&cr.queue, e.index)
+	}
+}
+
+// forget stops the tracking of an entry which was removed from the underlying repository.
+func (cr *BoundedCacheRepository[Entity, ID]) forget(key boundedCacheKey) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	cr.init()
+	cr.remove(key)
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) forgetAll(kind boundedCacheKind) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	cr.init()
+	for key := range cr.entries {
+		if key.Kind == kind {
+			cr.remove(key)
+		}
+	}
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) remove(key boundedCacheKey) (*boundedCacheEntry, bool) {
+	e, ok := cr.entries[key]
+	if !ok {
+		return nil, false
+	}
+	delete(cr.entries, key)
+	heap.Remove(&cr.queue, e.index)
+	cr.bytes -= e.Size
+	if key.Kind == boundedCacheHit {
+		cr.unreference(key.Key)
+	}
+	return e, true
+}
+
+// reference replaces the entity references of a query hit.
+func (cr *BoundedCacheRepository[Entity, ID]) reference(hitKey string, entKeys []string) {
+	cr.unreference(hitKey)
+	for _, entKey := range entKeys {
+		if _, ok := cr.refs[entKey]; !ok {
+			cr.refs[entKey] = make(map[string]struct{})
+		}
+		cr.refs[entKey][hitKey] = struct{}{}
+	}
+	cr.hitRefs[hitKey] = entKeys
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) unreference(hitKey string) {
+	for _, entKey := range cr.hitRefs[hitKey] {
+		delete(cr.refs[entKey], hitKey)
+		if len(cr.refs[entKey]) == 0 {
+			delete(cr.refs, entKey)
+		}
+	}
+	delete(cr.hitRefs, hitKey)
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) isTracked(key boundedCacheKey) bool {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	_, ok := cr.entries[key]
+	return ok
+}
+
+// evict removes entries while the cache is over its limits.
+// The recently tracked entry is only evicted when it can't fit into the cache alone,
+// otherwise with LFU, a new entry would be always the first one to evict.
+func (cr *BoundedCacheRepository[Entity, ID]) evict(ctx context.Context, recent *boundedCacheEntry) error {
+	for cr.isOverLimit() && 0 < cr.queue.Len() {
+		victim := cr.queue.Entries[0]
+		if victim == recent && 1 < cr.queue.Len() { // the next candidate is one of the root's children
+			victim = cr.queue.Entries[1]
+			if 2 < cr.queue.Len() && cr.queue.Less(2, 1) {
+				victim = cr.queue.Entries[2]
+			}
+		}
+		var hitKeys []string
+		if victim.Kind == boundedCacheEntity {
+			for hitKey := range cr.refs[victim.Key] {
+				hitKeys = append(hitKeys, hitKey)
+			}
+		}
+		cr.remove(victim.boundedCacheKey)
+		if err := cr.delete(ctx, victim); err != nil {
+			return err
+		}
+		for _, hitKey := range hitKeys {
+			hit, ok := cr.remove(boundedCacheKey{Kind: boundedCacheHit, Key: hitKey})
+			if !ok {
+				continue
+			}
+			if err := cr.delete(ctx, hit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) delete(ctx context.Context, e *boundedCacheEntry) error {
+	switch e.Kind {
+	case boundedCacheEntity:
+		cr.stats.EvictedEntities++
+	case boundedCacheHit:
+		cr.stats.EvictedHits++
+	}
+	if err := e.Delete(ctx); err != nil && !errors.Is(err, crud.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) isOverLimit() bool {
+	if 0 < cr.MaxEntries && cr.MaxEntries < len(cr.entries) {
+		return true
+	}
+	if 0 < cr.MaxBytes && cr.MaxBytes < cr.bytes {
+		return true
+	}
+	return false
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) init() {
+	if cr.entries != nil {
+		return
+	}
+	cr.entries = make(map[boundedCacheKey]*boundedCacheEntry)
+	cr.refs = make(map[string]map[string]struct{})
+	cr.hitRefs = make(map[string][]string)
+	cr.queue = boundedCacheQueue{Policy: cr.Policy}
+}
+
+func (cr *BoundedCacheRepository[Entity, ID]) sizeOf(v any) int {
+	if cr.SizeOf != nil {
+		return cr.SizeOf(v)
+	}
+	return approxSizeOf(reflect.ValueOf(v), 0)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type boundedCacheEntityRepository[Entity, ID any] struct {
+	*Repository[Entity, ID]
+	Bounded *BoundedCacheRepository[Entity, ID]
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) key(id ID) boundedCacheKey {
+	return boundedCacheKey{Kind: boundedCacheEntity, Key: r.IDToMemoryKey(id)}
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) track(ctx context.Context, ent Entity) error {
+	id, _ := extid.Lookup[ID](ent)
+	return r.Bounded.track(ctx, r.key(id), ent, r.deleteFunc(id))
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) touch(ctx context.Context, ent Entity) error {
+	id, _ := extid.Lookup[ID](ent)
+	return r.Bounded.touch(ctx, r.key(id), ent, r.deleteFunc(id))
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) deleteFunc(id ID) func(context.Context) error {
+	return func(ctx context.Context) error { return r.Repository.DeleteByID(ctx, id) }
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) Create(ctx context.Context, ptr *Entity) error {
+	if err := r.Repository.Create(ctx, ptr); err != nil {
+		return err
+	}
+	return r.track(ctx, *ptr)
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) Update(ctx context.Context, ptr *Entity) error {
+	if err := r.Repository.Update(ctx, ptr); err != nil {
+		return err
+	}
+	return r.track(ctx, *ptr)
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) Upsert(ctx context.Context, ptrs ...*Entity) error {
+	if err := r.Repository.Upsert(ctx, ptrs...); err != nil {
+		return err
+	}
+	for _, ptr := range ptrs {
+		if err := r.track(ctx, *ptr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) FindByID(ctx context.Context, id ID) (Entity, bool, error) {
+	ent, found, err := r.Repository.FindByID(ctx, id)
+	if err != nil || !found {
+		return ent, found, err
+	}
+	return ent, true, r.touch(ctx, ent)
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) FindByIDs(ctx context.Context, ids ...ID) iterators.Iterator[Entity] {
+	return iterators.Map(r.Repository.FindByIDs(ctx, ids...), func(ent Entity) (Entity, error) {
+		return ent, r.touch(ctx, ent)
+	})
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) DeleteByID(ctx context.Context, id ID) error {
+	if err := r.Repository.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+	r.Bounded.forget(r.key(id))
+	return nil
+}
+
+func (r *boundedCacheEntityRepository[Entity, ID]) DeleteAll(ctx context.Context) error {
+	if err := r.Repository.DeleteAll(ctx); err != nil {
+		return err
+	}
+	r.Bounded.forgetAll(boundedCacheEntity)
+	return nil
+}
+
+type boundedCacheHitRepository[Entity, ID any] struct {
+	*Repository[cache.Hit[ID], cache.HitID]
+	Bounded *BoundedCacheRepository[Entity, ID]
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) key(id cache.HitID) boundedCacheKey {
+	return boundedCacheKey{Kind: boundedCacheHit, Key: id}
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) track(ctx context.Context, hit cache.Hit[ID]) error {
+	var (
+		entities = r.Bounded.entities()
+		entKeys  = make([]string, 0, len(hit.EntityIDs))
+	)
+	for _, id := range hit.EntityIDs {
+		entKeys = append(entKeys, entities.IDToMemoryKey(id))
+	}
+	return r.Bounded.trackHit(ctx, r.key(hit.QueryID), entKeys, hit, r.deleteFunc(hit.QueryID))
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) deleteFunc(id cache.HitID) func(context.Context) error {
+	return func(ctx context.Context) error { return r.Repository.DeleteByID(ctx, id) }
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) Create(ctx context.Context, ptr *cache.Hit[ID]) error {
+	if err := r.Repository.Create(ctx, ptr); err != nil {
+		return err
+	}
+	return r.track(ctx, *ptr)
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) Update(ctx context.Context, ptr *cache.Hit[ID]) error {
+	if err := r.Repository.Update(ctx, ptr); err != nil {
+		return err
+	}
+	return r.track(ctx, *ptr)
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) FindByID(ctx context.Context, id cache.HitID) (cache.Hit[ID], bool, error) {
+	hit, found, err := r.Repository.FindByID(ctx, id)
+	if err != nil || !found {
+		return hit, found, err
+	}
+	if !r.Bounded.isTracked(r.key(id)) {
+		if err := r.track(ctx, hit); err != nil {
+			return hit, false, err
+		}
+		if !r.Bounded.isTracked(r.key(id)) { // evicted, as its entities are no longer cached
+			return cache.Hit[ID]{}, false, nil
+		}
+		return hit, true, nil
+	}
+	return hit, true, r.Bounded.touch(ctx, r.key(id), hit, r.deleteFunc(id))
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) DeleteByID(ctx context.Context, id cache.HitID) error {
+	if err := r.Repository.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+	r.Bounded.forget(r.key(id))
+	return nil
+}
+
+func (r *boundedCacheHitRepository[Entity, ID]) DeleteAll(ctx context.Context) error {
+	if err := r.Repository.DeleteAll(ctx); err != nil {
+		return err
+	}
+	r.Bounded.forgetAll(boundedCacheHit)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// boundedCacheQueue is a heap of the entries, where the first element is the next to evict.
+type boundedCacheQueue struct {
+	Policy  EvictionPolicy
+	Entries []*boundedCacheEntry
+}
+
+func (q boundedCacheQueue) Len() int { return len(q.Entries) }
+
+func (q boundedCacheQueue) Less(i, j int) bool {
+	a, b := q.Entries[i], q.Entries[j]
+	if q.Policy == LFU && a.Uses != b.Uses {
+		return a.Uses < b.Uses
+	}
+	return a.UsedAt < b.UsedAt
+}
+
+func (q boundedCacheQueue) Swap(i, j int) {
+	q.Entries[i], q.Entries[j] = q.Entries[j], q.Entries[i]
+	q.Entries[i].index = i
+	q.Entries[j].index = j
+}
+
+func (q *boundedCacheQueue) Push(x any) {
+	e := x.(*boundedCacheEntry)
+	e.index = len(q.Entries)
+	q.Entries = append(q.Entries, e)
+}
+
+func (q *boundedCacheQueue) Pop() any {
+	last := len(q.Entries) - 1
+	e := q.Entries[last]
+	q.Entries[last] = nil
+	q.Entries = q.Entries[:last]
+	e.index = -1
+	return e
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var typeTime = reflect.TypeOf(time.Time{})
+
+// approxSizeOf estimates the memory footprint of a value.
+// It is not meant to be accurate, only to be proportional to the real size.
+func approxSizeOf(v reflect.Value, depth int) int {
+	const maxDepth = 16
+	if !v.IsValid() {
+		return 0
+	}
+	size := int(v.Type().Size())
+	if maxDepth < depth {
+		return size
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return size + v.Len()
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			size += approxSizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Array:
+		size = 0
+		for i := 0; i < v.Len(); i++ {
+			size += approxSizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			size += approxSizeOf(iter.Key(), depth+1) + approxSizeOf(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return size
+		}
+		return size + approxSizeOf(v.Elem(), depth+1)
+	case reflect.Struct:
+		if v.Type() == typeTime { // the location is shared between the time values
+			return size
+		}
+		size = 0
+		for i := 0; i < v.NumField(); i++ {
+			size += approxSizeOf(v.Field(i), depth+1)
+		}
+		return size
+	default:
+		return size
+	}
+}
@@ -0,0 +1,204 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/pkg/cache"
+	"go.llib.dev/frameless/pkg/cache/cachecontracts"
+	"go.llib.dev/frameless/ports/crud/crudtest"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/spechelper/testent"
+	"go.llib.dev/testcase"
+)
+
+var _ cache.Repository[testent.Foo, testent.FooID] = &memory.BoundedCacheRepository[testent.Foo, testent.FooID]{}
+
+func TestBoundedCacheRepository(t *testing.T) {
+	cachecontracts.Repository[testent.Foo, testent.FooID](func(tb testing.TB) cachecontracts.RepositorySubject[testent.Foo, testent.FooID] {
+		return cachecontracts.RepositorySubject[testent.Foo, testent.FooID]{
+			Repository:  memory.NewBoundedCacheRepository[testent.Foo, testent.FooID](memory.NewMemory(), 1024),
+			MakeContext: context.Background,
+			MakeEntity:  testent.MakeFooFunc(tb),
+		}
+	}).Test(t)
+}
+
+func TestBoundedCacheRepository_cache(t *testing.T) {
+	cachecontracts.Cache[testent.Foo, testent.FooID](func(tb testing.TB) cachecontracts.CacheSubject[testent.Foo, testent.FooID] {
+		m := memory.NewMemory()
+		source := memory.NewRepository[testent.Foo, testent.FooID](m)
+		cacheRepository := memory.NewBoundedCacheRepository[testent.Foo, testent.FooID](m, 1024)
+		return cachecontracts.CacheSubject[testent.Foo, testent.FooID]{
+			Cache:       cache.New[testent.Foo, testent.FooID](source, cacheRepository),
+			Source:      source,
+			Repository:  cacheRepository,
+			MakeContext: context.Background,
+			MakeEntity:  testent.MakeFooFunc(tb),
+		}
+	}).Test(t)
+}
+
+func TestBoundedCacheRepository_eviction(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		ctx      = testcase.LetValue(s, context.Background())
+		m        = testcase.Let(s, func(t *testcase.T) *memory.Memory { return memory.NewMemory() })
+		capacity = testcase.LetValue(s, 3)
+		repo     = testcase.Let(s, func(t *testcase.T) *memory.BoundedCacheRepository[testent.Foo, testent.FooID] {
+			return memory.NewBoundedCacheRepository[testent.Foo, testent.FooID](m.Get(t), capacity.Get(t))
+		})
+	)
+
+	makeFoos := func(t *testcase.T, repo cache.EntityRepository[testent.Foo, testent.FooID], n int) []testent.Foo {
+		var foos []testent.Foo
+		for i := 0; i < n; i++ {
+			foo := testent.MakeFoo(t)
+			crudtest.Create[testent.Foo, testent.FooID](t, repo, ctx.Get(t), &foo)
+			foos = append(foos, foo)
+		}
+		return foos
+	}
+	isCached := func(t *testcase.T, repo cache.EntityRepository[testent.Foo, testent.FooID], id testent.FooID) bool {
+		_, found, err := repo.FindByID(ctx.Get(t), id)
+		t.Must.NoError(err)
+		return found
+	}
+	isHitCached := func(t *testcase.T, id cache.HitID) bool {
+		_, found, err := repo.Get(t).Hits().FindByID(ctx.Get(t), id)
+		t.Must.NoError(err)
+		return found
+	}
+
+	s.Test("LRU evicts the least recently used entity", func(t *testcase.T) {
+		repo := repo.Get(t)
+		foos := makeFoos(t, repo.Entities(), 3)
+		t.Must.True(isCached(t, repo.Entities(), foos[0].ID)) // use the oldest
+		foo4 := makeFoos(t, repo.Entities(), 1)[0]
+
+		t.Must.False(isCached(t, repo.Entities(), foos[1].ID))
+		t.Must.True(isCached(t, repo.Entities(), foos[0].ID))
+		t.Must.True(isCached(t, repo.Entities(), foos[2].ID))
+		t.Must.True(isCached(t, repo.Entities(), foo4.ID))
+		t.Must.Equal(1, repo.Stats().EvictedEntities)
+		t.Must.Equal(3, repo.Stats().Entries)
+	})
+
+	s.When("the policy is LFU", func(s *testcase.Spec) {
+		repo.Let(s, func(t *testcase.T) *memory.BoundedCacheRepository[testent.Foo, testent.FooID] {
+			r := repo.Super(t)
+			r.Policy = memory.LFU
+			return r
+		})
+
+		s.Then("the least frequently used entity is evicted", func(t *testcase.T) {
+			repo := repo.Get(t)
+			foos := makeFoos(t, repo.Entities(), 3)
+			for i := 0; i < 3; i++ {
+				isCached(t, repo.Entities(), foos[0].ID)
+				isCached(t, repo.Entities(), foos[1].ID)
+			}
+			isCached(t, repo.Entities(), foos[2].ID) // most recent, but the least frequently used
+			makeFoos(t, repo.Entities(), 1)
+
+			t.Must.True(isCached(t, repo.Entities(), foos[0].ID))
+			t.Must.True(isCached(t, repo.Entities(), foos[1].ID))
+			t.Must.False(isCached(t, repo.Entities(), foos[2].ID))
+		})
+	})
+
+	s.Context("query hits", func(s *testcase.Spec) {
+		s.Test("evicting an entity evicts the query hits referencing it", func(t *testcase.T) {
+			capacity.Set(t, 4)
+			repo := repo.Get(t)
+			foos := makeFoos(t, repo.Entities(), 2)
+			hit := cache.Hit[testent.FooID]{QueryID: "q", EntityIDs: []testent.FooID{foos[0].ID, foos[1].ID}}
+			crudtest.Create[cache.Hit[testent.FooID], cache.HitID](t, repo.Hits(), ctx.Get(t), &hit)
+			isCached(t, repo.Entities(), foos[1].ID)
+			t.Must.True(isHitCached(t, hit.QueryID))
+
+			makeFoos(t, repo.Entities(), 2) // foos[0] is the least recently used
+
+			t.Must.False(isHitCached(t, hit.QueryID), "hit was expected to be evicted together with its entity")
+			t.Must.True(isCached(t, repo.Entities(), foos[1].ID))
+			stats := repo.Stats()
+			t.Must.Equal(1, stats.EvictedEntities)
+			t.Must.Equal(1, stats.EvictedHits)
+			t.Must.Equal(3, stats.Entries)
+		})
+
+		s.Test("a query hit with more entities than the cache can hold is not stored", func(t *testcase.T) {
+			repo := repo.Get(t)
+			foos := makeFoos(t, repo.Entities(), 4)
+			hit := cache.Hit[testent.FooID]{QueryID: "q"}
+			for _, foo := range foos {
+				hit.EntityIDs = append(hit.EntityIDs, foo.ID)
+			}
+			t.Must.NoError(repo.Hits().Create(ctx.Get(t), &hit))
+
+			t.Must.False(isHitCached(t, hit.QueryID), "hit was expected to be evicted, as it references an evicted entity")
+			t.Must.Equal(1, repo.Stats().EvictedHits)
+		})
+
+		s.Test("a query hit which evicts its own entity is evicted together with it", func(t *testcase.T) {
+			repo := repo.Get(t)
+			foos := makeFoos(t, repo.Entities(), 3)
+			hit := cache.Hit[testent.FooID]{QueryID: "q", EntityIDs: []testent.FooID{foos[0].ID, foos[1].ID, foos[2].ID}}
+			t.Must.NoError(repo.Hits().Create(ctx.Get(t), &hit))
+
+			t.Must.False(isHitCached(t, hit.QueryID), "hit was expected to be evicted together with its entity")
+			t.Must.False(isCached(t, repo.Entities(), foos[0].ID))
+			t.Must.Equal(1, repo.Stats().EvictedHits)
+		})
+	})
+
+	s.Test("it doesn't share its namespace with the CacheRepository", func(t *testcase.T) {
+		other := memory.NewCacheRepository[testent.Foo, testent.FooID](m.Get(t))
+		foo := makeFoos(t, other.Entities(), 1)[0]
+		t.Must.False(isCached(t, repo.Get(t).Entities(), foo.ID))
+		makeFoos(t, repo.Get(t).Entities(), 4)
+		t.Must.True(isCached(t, other.Entities(), foo.ID))
+	})
+
+	s.Test("cache falls back to the source when a cached query lost its entities", func(t *testcase.T) {
+		source := memory.NewRepository[testent.Foo, testent.FooID](m.Get(t))
+		c := cache.New[testent.Foo, testent.FooID](source, repo.Get(t))
+		foos := makeFoos(t, source, 5)
+
+		for i := 0; i < 2; i++ {
+			vs, err := iterators.Collect(c.FindAll(ctx.Get(t)))
+			t.Must.NoError(err)
+			t.Must.ContainExactly(foos, vs)
+			for _, foo := range foos {
+				crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx.Get(t), foo.ID)
+			}
+		}
+		t.Must.True(repo.Get(t).Stats().Entries <= 3)
+	})
+
+	s.When("the size is bounded by bytes instead of entries", func(s *testcase.Spec) {
+		capacity.LetValue(s, 0)
+
+		s.Test("entities are evicted beyond the approximate bytes", func(t *testcase.T) {
+			repo := repo.Get(t)
+			repo.MaxBytes = 250
+			repo.SizeOf = func(v any) int { return 100 }
+			foos := makeFoos(t, repo.Entities(), 3)
+			t.Must.False(isCached(t, repo.Entities(), foos[0].ID))
+			t.Must.Equal(200, repo.Stats().Bytes)
+		})
+
+		s.Test("the default size approximation grows with the content", func(t *testcase.T) {
+			repo := repo.Get(t)
+			small := testent.Foo{ID: "1"}
+			crudtest.Create[testent.Foo, testent.FooID](t, repo.Entities(), ctx.Get(t), &small)
+			smallSize := repo.Stats().Bytes
+			t.Must.True(0 < smallSize)
+			large := testent.Foo{ID: "2", Foo: string(make([]byte, 1024))}
+			crudtest.Create[testent.Foo, testent.FooID](t, repo.Entities(), ctx.Get(t), &large)
+			t.Must.True(1024 <= repo.Stats().Bytes-smallSize, "the large entity should account for at least its content")
+		})
+	})
+}
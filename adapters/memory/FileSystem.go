@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -182,6 +183,50 @@ func (mfs *FileSystem) Remove(name string) error {
 	return nil
 }
 
+func (mfs *FileSystem) Rename(oldpath, newpath string) error {
+	defer mfs.wlock()()
+	var (
+		oldPath = mfs.path(oldpath)
+		newPath = mfs.path(newpath)
+	)
+	f, ok := mfs.entries[oldPath]
+	if !ok {
+		return &os.LinkError{
+			Op:  "rename",
+			Old: oldPath,
+			New: newPath,
+			Err: os.ErrNotExist,
+		}
+	}
+	if oldPath == newPath {
+		return nil
+	}
+	if existing, ok := mfs.entries[newPath]; ok && (existing.isDir || f.isDir) {
+		err := syscall.EEXIST
+		if existing.isDir {
+			err = syscall.EISDIR
+		}
+		return &os.LinkError{
+			Op:  "rename",
+			Old: oldPath,
+			New: newPath,
+			Err: err,
+		}
+	}
+	var moved []*fileSystemEntry // a directory is moved together with its content
+	for path, entry := range mfs.entries {
+		if path == oldPath || strings.HasPrefix(path, oldPath+string(filepath.Separator)) {
+			delete(mfs.entries, path)
+			moved = append(moved, entry)
+		}
+	}
+	for _, entry := range moved {
+		entry.path = newPath + strings.TrimPrefix(entry.path, oldPath)
+		mfs.entries[entry.path] = entry
+	}
+	return nil
+}
+
 type fileSystemEntry struct {
 	path     string
 	mode     fs.FileMode
@@ -302,10 +302,15 @@ func memoryAll[Entity any](m *Memory, ctx context.Context, namespace string) ite
 }
 
 func (m *Memory) All(T any, ctx context.Context, namespace string) (sliceOfT interface{}) {
+	return m.toTSlice(T, m.allWith(ctx, namespace))
+}
+
+// allWith returns the values of the namespace by their keys, as they are visible in the transaction of the context.
+func (m *Memory) allWith(ctx context.Context, namespace string) map[string]interface{} {
 	if tx, ok := m.LookupTx(ctx); ok && !tx.done {
-		return m.toTSlice(T, tx.all(namespace))
+		return tx.all(namespace)
 	}
-	return m.toTSlice(T, m.all(namespace))
+	return m.all(namespace)
 }
 
 func (m *Memory) toTSlice(T any, vs map[string]interface{}) interface{} {
@@ -474,10 +479,13 @@ func (tx *MemoryTx) commit() error {
 }
 
 func (tx *MemoryTx) rollback() error {
+	tx.m.Lock()
 	if tx.done {
+		tx.m.Unlock()
 		return errTxDone
 	}
 	tx.done = true
+	tx.m.Unlock()
 	super, ok := tx.super.(*MemoryTx)
 	if !ok {
 		return nil
@@ -488,6 +496,23 @@ func (tx *MemoryTx) rollback() error {
 	return super.rollback()
 }
 
+func (tx *MemoryTx) isDone() bool {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+	return tx.done
+}
+
+// root returns the outermost transaction, which commits the changes into the Memory.
+func (tx *MemoryTx) root() *MemoryTx {
+	for {
+		super, ok := tx.super.(*MemoryTx)
+		if !ok {
+			return tx
+		}
+		tx = super
+	}
+}
+
 func (tx *MemoryTx) getChanges(name string) memoryTxChanges {
 	if tx.changes == nil {
 		tx.changes = make(map[string]memoryTxChanges)
@@ -9,6 +9,7 @@ import (
 	"go.llib.dev/testcase/clock"
 	"go.llib.dev/testcase/random"
 	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -25,7 +26,9 @@ type Queue[Data any] struct {
 	Blocking bool
 
 	// SortLessFunc will define how to sort data, when we look for what message to handle next.
-	// if not supplied FIFO is the default ordering.
+	// Messages are always ordered by their priority first (see pubsub.ContextWithPriority),
+	// and SortLessFunc orders the messages within the same priority.
+	// If not supplied, FIFO is the default ordering within the same priority.
 	SortLessFunc func(i Data, j Data) bool
 }
 
@@ -33,8 +36,10 @@ const typeNameQueue = "Queue"
 
 func (ps *Queue[Data]) Publish(ctx context.Context, vs ...Data) (rErr error) {
 	var (
-		keys      []string
-		namespace = getNamespaceFor[Data](typeNameQueue, &ps.Namespace)
+		keys        []string
+		namespace   = getNamespaceFor[Data](typeNameQueue, &ps.Namespace)
+		headers, _  = pubsub.LookupHeaders(ctx)
+		priority, _ = pubsub.LookupPriority(ctx)
 	)
 	if err := func(ctx context.Context) error {
 		ctx, err := ps.Memory.BeginTx(ctx)
@@ -46,9 +51,16 @@ func (ps *Queue[Data]) Publish(ctx context.Context, vs ...Data) (rErr error) {
 		for _, v := range vs {
 			key := ps.makeKey()
 			keys = append(keys, key)
+			hs := headers.Clone()
+			if hs == nil {
+				hs = pubsub.Headers{}
+			}
+			hs[pubsub.HeaderMessageID] = key
 			ps.Memory.Set(ctx, namespace, key, &pubsubRecord[Data]{
 				key:       key,
 				value:     v,
+				headers:   hs,
+				priority:  priority,
 				createdAt: time.Now().UTC(),
 			})
 		}
@@ -92,6 +104,8 @@ func (ps *Queue[Data]) Purge(ctx context.Context) error {
 type pubsubRecord[Data any] struct {
 	key       string
 	value     Data
+	headers   pubsub.Headers
+	priority  int
 	createdAt time.Time
 	taken     int32
 }
@@ -188,6 +202,9 @@ fetch:
 
 func (pss *pubsubSubscription[Data]) sort(recs []*pubsubRecord[Data]) {
 	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].priority != recs[j].priority {
+			return recs[i].priority > recs[j].priority
+		}
 		if pss.q.SortLessFunc != nil {
 			return pss.q.SortLessFunc(recs[i].value, recs[j].value)
 		}
@@ -240,6 +257,13 @@ func (pm *pubsubMessage[Data]) Data() Data {
 	return pm.record.value
 }
 
+func (pm *pubsubMessage[Data]) Headers() pubsub.Headers {
+	if pm.record == nil {
+		return nil
+	}
+	return pm.record.headers.Clone()
+}
+
 //--------------------------------------------------------------------------------------------------------------------//
 
 // FanOutExchange delivers messages to all the queues that are bound to it.
@@ -299,3 +323,159 @@ func (e *FanOutExchange[Data]) getNamespace() string {
 }
 
 //--------------------------------------------------------------------------------------------------------------------//
+
+// TopicExchange delivers messages to the queues which binding pattern matches the message's routing key.
+// The binding pattern is made of dot separated words, where "*" substitutes exactly one word,
+// and "#" substitutes zero or more words.
+type TopicExchange[Data any] struct {
+	Memory *Memory
+	// Namespace allows you to isolate two different TopicExchange while using the same *Memory
+	Namespace string
+	// Bindings contain every Queue that suppose to be bound to the Topic Exchange, along with their binding pattern.
+	Bindings []TopicBinding[Data]
+}
+
+// TopicBinding binds a Queue to a TopicExchange with a routing key pattern.
+type TopicBinding[Data any] struct {
+	Pattern string
+	Queue   *Queue[Data]
+}
+
+// Publish will publish all data to the TopicExchange.Bindings' Queue which pattern matches the routing key.
+// A Queue bound with multiple matching patterns receives the data only once.
+// It will either all succeed or all fail together.
+func (e *TopicExchange[Data]) Publish(ctx context.Context, routingKey string, data ...Data) (rErr error) {
+	ctx, err := e.Memory.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer comproto.FinishOnePhaseCommit(&rErr, e.Memory, ctx)
+
+	seen := make(map[*Queue[Data]]struct{})
+	for _, b := range e.Bindings {
+		if _, ok := seen[b.Queue]; ok {
+			continue
+		}
+		if !pubsub.MatchRoutingKey(b.Pattern, routingKey) {
+			continue
+		}
+		seen[b.Queue] = struct{}{}
+		if err := b.Queue.Publish(ctx, data...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge will flush all data from the exchange's queues
+func (e *TopicExchange[Data]) Purge(ctx context.Context) (rErr error) {
+	ctx, err := e.Memory.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer comproto.FinishOnePhaseCommit(&rErr, e.Memory, ctx)
+	for _, b := range e.Bindings {
+		if err := b.Queue.Purge(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MakeQueue creates a unique queue which is bound to the Topic exchange with the given routing key pattern.
+func (e *TopicExchange[Data]) MakeQueue(pattern string) *Queue[Data] {
+	q := &Queue[Data]{
+		Memory:    e.Memory,
+		Namespace: fmt.Sprintf("%s/queues/%s", e.getNamespace(), rnd.UUID()),
+	}
+	e.Bindings = append(e.Bindings, TopicBinding[Data]{
+		Pattern: pattern,
+		Queue:   q,
+	})
+	return q
+}
+
+func (e *TopicExchange[Data]) getNamespace() string {
+	return getNamespaceFor[Data]("TopicExchange", &e.Namespace)
+}
+
+//--------------------------------------------------------------------------------------------------------------------//
+
+// DeduplicationRepository records the processed message IDs for the pubsub.Deduplicator.
+// It respects the transactions of its Memory.
+// A mark made in an ongoing transaction already counts for the concurrent transactions,
+// thus only one of them can process the same message.
+type DeduplicationRepository struct {
+	Memory *Memory
+	// Namespace allows you to isolate two different DeduplicationRepository while using the same *Memory
+	Namespace string
+
+	m sync.Mutex
+	// claims holds the outermost transaction of the marks which are not yet committed.
+	claims map[string]*MemoryTx
+}
+
+const typeNameDeduplicationRepository = "DeduplicationRepository"
+
+func (r *DeduplicationRepository) MarkProcessed(ctx context.Context, messageID string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	now := clock.TimeNow()
+	if v, ok := r.Memory.Get(ctx, r.getNamespace(), messageID); ok {
+		if expiresAt, ok := v.(time.Time); ok && now.Before(expiresAt) {
+			return false, nil
+		}
+	}
+	if tx, ok := r.Memory.LookupTx(ctx); ok {
+		root := tx.root()
+		if claim, ok := r.claims[messageID]; ok && claim != root && !claim.isDone() {
+			return false, nil // marked by a concurrent transaction
+		}
+		if r.claims == nil {
+			r.claims = make(map[string]*MemoryTx)
+		}
+		r.claims[messageID] = root
+	}
+	r.Memory.Set(ctx, r.getNamespace(), messageID, now.Add(ttl))
+	return true, nil
+}
+
+func (r *DeduplicationRepository) UnmarkProcessed(ctx context.Context, messageID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.Memory.Del(ctx, r.getNamespace(), messageID)
+	return nil
+}
+
+// DeleteExpired removes the processed marks which TTL already expired.
+func (r *DeduplicationRepository) DeleteExpired(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	for messageID, claim := range r.claims {
+		if claim.isDone() {
+			delete(r.claims, messageID)
+		}
+	}
+	now := clock.TimeNow()
+	for messageID, v := range r.Memory.allWith(ctx, r.getNamespace()) {
+		if expiresAt, ok := v.(time.Time); ok && !now.Before(expiresAt) {
+			r.Memory.Del(ctx, r.getNamespace(), messageID)
+		}
+	}
+	return nil
+}
+
+func (r *DeduplicationRepository) getNamespace() string {
+	return getNamespaceFor[string](typeNameDeduplicationRepository, &r.Namespace)
+}
+
+//--------------------------------------------------------------------------------------------------------------------//
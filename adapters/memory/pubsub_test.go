@@ -6,8 +6,11 @@ import (
 	"go.llib.dev/frameless/ports/pubsub"
 	"go.llib.dev/frameless/ports/pubsub/pubsubcontracts"
 	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/clock/timecop"
 	"sort"
 	"testing"
+	"time"
 
 	. "go.llib.dev/frameless/spechelper/testent"
 )
@@ -72,6 +75,26 @@ func TestQueue(t *testing.T) {
 				MakeData:    makeTestEntityFunc(tb),
 			}
 		}),
+		pubsubcontracts.Headers[TestEntity](func(tb testing.TB) pubsubcontracts.HeadersSubject[TestEntity] {
+			q := &memory.Queue[TestEntity]{
+				Memory: memory.NewMemory(),
+			}
+			return pubsubcontracts.HeadersSubject[TestEntity]{
+				PubSub:      pubsubcontracts.PubSub[TestEntity]{Publisher: q, Subscriber: q},
+				MakeContext: context.Background,
+				MakeData:    makeTestEntityFunc(tb),
+			}
+		}),
+		pubsubcontracts.Priority[TestEntity](func(tb testing.TB) pubsubcontracts.PrioritySubject[TestEntity] {
+			q := &memory.Queue[TestEntity]{
+				Memory: memory.NewMemory(),
+			}
+			return pubsubcontracts.PrioritySubject[TestEntity]{
+				PubSub:      pubsubcontracts.PubSub[TestEntity]{Publisher: q, Subscriber: q},
+				MakeContext: context.Background,
+				MakeData:    makeTestEntityFunc(tb),
+			}
+		}),
 		pubsubcontracts.Ordering[TestEntity](func(tb testing.TB) pubsubcontracts.OrderingSubject[TestEntity] {
 			t := testcase.ToT(&tb)
 			q := &memory.Queue[TestEntity]{
@@ -98,6 +121,46 @@ func TestQueue(t *testing.T) {
 	)
 }
 
+func TestQueue_SortLessFunc(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		ctx   = testcase.LetValue(s, context.Background())
+		queue = testcase.Let(s, func(t *testcase.T) *memory.Queue[TestEntity] {
+			return &memory.Queue[TestEntity]{
+				Memory: memory.NewMemory(),
+				SortLessFunc: func(i TestEntity, j TestEntity) bool {
+					return i.Data < j.Data
+				},
+			}
+		})
+	)
+	receive := func(t *testcase.T, n int) []string {
+		sub := queue.Get(t).Subscribe(ctx.Get(t))
+		defer sub.Close()
+		var got []string
+		for i := 0; i < n; i++ {
+			t.Must.True(sub.Next())
+			got = append(got, sub.Value().Data().Data)
+			t.Must.NoError(sub.Value().ACK())
+		}
+		return got
+	}
+
+	s.Test("the priority of the messages takes precedence, and SortLessFunc orders the messages of the same priority", func(t *testcase.T) {
+		publish := func(priority int, data string) {
+			t.Must.NoError(queue.Get(t).Publish(pubsub.ContextWithPriority(ctx.Get(t), priority), TestEntity{Data: data}))
+		}
+		publish(1, "d")
+		publish(1, "b")
+		publish(5, "c")
+		publish(5, "a")
+		publish(0, "e")
+
+		t.Must.Equal([]string{"a", "c", "b", "d", "e"}, receive(t, 5))
+	})
+}
+
 var _ pubsub.Publisher[Foo] = &memory.FanOutExchange[Foo]{}
 
 func TestFanOutExchange(t *testing.T) {
@@ -117,3 +180,67 @@ func TestFanOutExchange(t *testing.T) {
 		//pubsubcontracts.OnePhaseCommitProtocol
 	)
 }
+
+var _ pubsub.TopicPublisher[Foo] = &memory.TopicExchange[Foo]{}
+
+func TestTopicExchange(t *testing.T) {
+	testcase.RunSuite(t,
+		pubsubcontracts.Topic[Foo](func(tb testing.TB) pubsubcontracts.TopicSubject[Foo] {
+			mm := memory.NewMemory()
+			exchange := &memory.TopicExchange[Foo]{Memory: mm}
+			return pubsubcontracts.TopicSubject[Foo]{
+				Exchange: exchange,
+				MakeQueue: func(pattern string) pubsub.Subscriber[Foo] {
+					return exchange.MakeQueue(pattern)
+				},
+				Bind: func(queue pubsub.Subscriber[Foo], pattern string) {
+					exchange.Bindings = append(exchange.Bindings, memory.TopicBinding[Foo]{
+						Pattern: pattern,
+						Queue:   queue.(*memory.Queue[Foo]),
+					})
+				},
+				MakeContext: context.Background,
+				MakeData:    MakeFooFunc(tb),
+			}
+		}),
+	)
+}
+
+var _ pubsub.DeduplicationRepository = &memory.DeduplicationRepository{}
+
+func TestDeduplicationRepository(t *testing.T) {
+	testcase.RunSuite(t,
+		pubsubcontracts.Deduplication(func(tb testing.TB) pubsubcontracts.DeduplicationSubject {
+			m := memory.NewMemory()
+			return pubsubcontracts.DeduplicationSubject{
+				Repository:    &memory.DeduplicationRepository{Memory: m},
+				CommitManager: m,
+				MakeContext:   context.Background,
+			}
+		}),
+	)
+}
+
+func TestDeduplicationRepository_DeleteExpired(t *testing.T) {
+	m := memory.NewMemory()
+	repo := &memory.DeduplicationRepository{Memory: m, Namespace: "dedup"}
+
+	tx, err := m.BeginTx(context.Background())
+	assert.NoError(t, err)
+	ok, err := repo.MarkProcessed(tx, "expired", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.MarkProcessed(tx, "active", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	timecop.Travel(t, 2*time.Minute)
+	assert.NoError(t, repo.DeleteExpired(tx))
+	marks := m.All(time.Time{}, tx, "DeduplicationRepository/dedup").([]time.Time)
+	assert.Equal(t, 1, len(marks), "the expired mark within the transaction was expected to be deleted")
+	assert.NoError(t, m.CommitTx(tx))
+
+	ok, err = repo.MarkProcessed(context.Background(), "active", time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.llib.dev/testcase/clock"
+)
+
+// DeduplicationRepository records the processed message IDs for the pubsub.Deduplicator.
+// It respects the transaction in the received context,
+// so the processed mark can be committed together with the message handler's own writes.
+type DeduplicationRepository struct {
+	// Name allows you to isolate the processed message IDs of different consumers.
+	Name       string
+	Connection Connection
+}
+
+const deduplicationTableName = "frameless_pubsub_deduplication"
+
+const queryCreateDeduplicationTable = `
+CREATE TABLE IF NOT EXISTS ` + deduplicationTableName + ` (
+	name       TEXT NOT NULL,
+	message_id TEXT NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	PRIMARY KEY (name, message_id)
+)
+;`
+
+const queryCreateDeduplicationExpiresAtIndex = `
+CREATE INDEX IF NOT EXISTS ` + deduplicationTableName + `_expires_at_idx
+	ON ` + deduplicationTableName + ` (expires_at)
+;`
+
+var deduplicationMigratorConfig = MigratorGroup{
+	ID: deduplicationTableName,
+	Steps: []MigratorStep{
+		MigrationStep{UpQuery: queryCreateDeduplicationTable},
+		MigrationStep{UpQuery: queryCreateDeduplicationExpiresAtIndex},
+	},
+}
+
+func (r DeduplicationRepository) Migrate(ctx context.Context) error {
+	return Migrator{
+		Connection: r.Connection,
+		Group:      deduplicationMigratorConfig,
+	}.Migrate(ctx)
+}
+
+const queryDeduplicationMarkProcessed = `
+INSERT INTO ` + deduplicationTableName + ` AS d (name, message_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (name, message_id) DO UPDATE
+	SET expires_at = EXCLUDED.expires_at
+	WHERE d.expires_at <= $4
+RETURNING message_id
+`
+
+func (r DeduplicationRepository) MarkProcessed(ctx context.Context, messageID string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	now := clock.TimeNow().UTC()
+	var id string
+	err := r.Connection.QueryRowContext(ctx, queryDeduplicationMarkProcessed, r.Name, messageID, now.Add(ttl), now).Scan(&id)
+	if errors.Is(err, errNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+const queryDeduplicationUnmarkProcessed = `
+DELETE FROM ` + deduplicationTableName + `
+WHERE name = $1
+  AND message_id = $2
+`
+
+func (r DeduplicationRepository) UnmarkProcessed(ctx context.Context, messageID string) error {
+	_, err := r.Connection.ExecContext(ctx, queryDeduplicationUnmarkProcessed, r.Name, messageID)
+	return err
+}
+
+const queryDeduplicationDeleteExpired = `
+DELETE FROM ` + deduplicationTableName + `
+WHERE name = $1
+  AND expires_at <= $2
+`
+
+// DeleteExpired removes the processed marks which TTL already expired.
+func (r DeduplicationRepository) DeleteExpired(ctx context.Context) error {
+	_, err := r.Connection.ExecContext(ctx, queryDeduplicationDeleteExpired, r.Name, clock.TimeNow().UTC())
+	return err
+}
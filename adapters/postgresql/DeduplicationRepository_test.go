@@ -0,0 +1,37 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"go.llib.dev/frameless/adapters/postgresql"
+	"go.llib.dev/frameless/ports/migration"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/frameless/ports/pubsub/pubsubcontracts"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/random"
+)
+
+var (
+	_ migration.Migratable           = postgresql.DeduplicationRepository{}
+	_ pubsub.DeduplicationRepository = postgresql.DeduplicationRepository{}
+)
+
+func TestDeduplicationRepository(t *testing.T) {
+	c := GetConnection(t)
+	assert.NoError(t, postgresql.DeduplicationRepository{Connection: c}.Migrate(MakeContext(t)))
+
+	testcase.RunSuite(t,
+		pubsubcontracts.Deduplication(func(tb testing.TB) pubsubcontracts.DeduplicationSubject {
+			return pubsubcontracts.DeduplicationSubject{
+				Repository: postgresql.DeduplicationRepository{
+					Name:       random.New(random.CryptoSeed{}).UUID(),
+					Connection: c,
+				},
+				CommitManager: c,
+				MakeContext:   context.Background,
+			}
+		}),
+	)
+}
@@ -24,6 +24,7 @@ type Queue[Entity, JSONDTO any] struct {
 	Blocking bool
 
 	// LIFO flag will set the queue to use a Last in First out ordering
+	// within the messages of the same priority (see pubsub.ContextWithPriority).
 	LIFO bool
 }
 
@@ -51,14 +52,15 @@ func (q Queue[Entity, JSONDTO]) Publish(ctx context.Context, vs ...Entity) error
 		args  []any
 		ids   []string
 	)
-	query += fmt.Sprintf("INSERT INTO %s (id, queue, data, created_at) Values", queueTableName)
+	priority, _ := pubsub.LookupPriority(ctx)
+	query += fmt.Sprintf("INSERT INTO %s (id, queue, data, headers, priority, created_at) Values", queueTableName)
 	for i, v := range vs {
 		if i == 0 {
 			query += "\n"
 		} else {
 			query += ",\n"
 		}
-		query += fmt.Sprintf("(%s, %s, %s, %s)", phg(), phg(), phg(), phg())
+		query += fmt.Sprintf("(%s, %s, %s, %s, %s, %s)", phg(), phg(), phg(), phg(), phg(), phg())
 		dto, err := q.Mapping.ToDTO(v)
 		if err != nil {
 			return err
@@ -68,8 +70,12 @@ func (q Queue[Entity, JSONDTO]) Publish(ctx context.Context, vs ...Entity) error
 			return err
 		}
 		id := rnd.UUID()
+		headers, err := q.marshalHeaders(ctx, id)
+		if err != nil {
+			return err
+		}
 		ids = append(ids, id)
-		args = append(args, id, q.Name, data, clock.TimeNow().UTC())
+		args = append(args, id, q.Name, data, headers, priority, clock.TimeNow().UTC())
 	}
 
 	_, err := q.Connection.ExecContext(ctx, query, args...)
@@ -91,6 +97,18 @@ func (q Queue[Entity, JSONDTO]) Publish(ctx context.Context, vs ...Entity) error
 	return err
 }
 
+// marshalHeaders serializes the publishing Headers of a message,
+// together with the message's unique pubsub.HeaderMessageID.
+func (q Queue[Entity, JSONDTO]) marshalHeaders(ctx context.Context, messageID string) ([]byte, error) {
+	headers, _ := pubsub.LookupHeaders(ctx)
+	headers = headers.Clone()
+	if headers == nil {
+		headers = pubsub.Headers{}
+	}
+	headers[pubsub.HeaderMessageID] = messageID
+	return json.Marshal(headers)
+}
+
 const queueTableName = "frameless_queue_messages"
 
 const queryCreateQueueTable = `
@@ -102,10 +120,28 @@ CREATE TABLE IF NOT EXISTS ` + queueTableName + ` (
 )
 ;`
 
+const queryAddQueueHeadersColumn = `
+ALTER TABLE ` + queueTableName + `
+	ADD COLUMN IF NOT EXISTS headers JSON NOT NULL DEFAULT '{}'
+;`
+
+const queryAddQueuePriorityColumn = `
+ALTER TABLE ` + queueTableName + `
+	ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0
+;`
+
+const queryCreateQueuePriorityIndex = `
+CREATE INDEX IF NOT EXISTS ` + queueTableName + `_priority_idx
+	ON ` + queueTableName + ` (queue, priority DESC, created_at)
+;`
+
 var queueMigratorConfig = MigratorGroup{
 	ID: queueTableName,
 	Steps: []MigratorStep{
 		MigrationStep{UpQuery: queryCreateQueueTable},
+		MigrationStep{UpQuery: queryAddQueueHeadersColumn},
+		MigrationStep{UpQuery: queryAddQueuePriorityColumn},
+		MigrationStep{UpQuery: queryCreateQueuePriorityIndex},
 	},
 }
 
@@ -155,11 +191,11 @@ DELETE FROM ` + queueTableName + `
       SELECT id
       FROM ` + queueTableName + `
       WHERE queue = $1
-      ORDER BY created_at %s 
+      ORDER BY priority DESC, created_at %s
       FOR UPDATE SKIP LOCKED
       LIMIT 1
     )
-    RETURNING id, data;
+    RETURNING id, data, headers;
 `
 
 func (qs *queueSubscription[Entity, JSONDTO]) Next() bool {
@@ -199,11 +235,12 @@ fetch:
 	}
 
 	var (
-		row  = qs.Queue.Connection.QueryRowContext(tx, fmt.Sprintf(queryQueuePopMessage, ordering), qs.Queue.Name)
-		id   string
-		data []byte
+		row     = qs.Queue.Connection.QueryRowContext(tx, fmt.Sprintf(queryQueuePopMessage, ordering), qs.Queue.Name)
+		id      string
+		data    []byte
+		headers []byte
 	)
-	if err := row.Scan(&id, &data); err != nil {
+	if err := row.Scan(&id, &data, &headers); err != nil {
 		_ = qs.Queue.Connection.RollbackTx(contextkit.Detach(tx))
 		if errors.Is(err, qs.CTX.Err()) {
 			return false
@@ -235,10 +272,18 @@ fetch:
 		return false
 	}
 
+	var hs pubsub.Headers
+	if err := json.Unmarshal(headers, &hs); err != nil {
+		_ = qs.Queue.Connection.RollbackTx(contextkit.Detach(tx))
+		qs.err = err
+		return false
+	}
+
 	qs.value = &queueMessage[Entity, JSONDTO]{
-		q:    qs.Queue,
-		tx:   tx,
-		data: ent,
+		q:       qs.Queue,
+		tx:      tx,
+		data:    ent,
+		headers: hs,
 	}
 	return true
 }
@@ -256,9 +301,10 @@ func (qs *queueSubscription[Entity, JSONDTO]) getEmptyQueueBreakTime() time.Dura
 }
 
 type queueMessage[Entity, JSONDTO any] struct {
-	q    Queue[Entity, JSONDTO]
-	tx   context.Context
-	data Entity
+	q       Queue[Entity, JSONDTO]
+	tx      context.Context
+	data    Entity
+	headers pubsub.Headers
 }
 
 func (qm queueMessage[Entity, JSONDTO]) ACK() error {
@@ -278,3 +324,7 @@ func (qm queueMessage[Entity, JSONDTO]) NACK() error {
 func (qm queueMessage[Entity, JSONDTO]) Data() Entity {
 	return qm.data
 }
+
+func (qm queueMessage[Entity, JSONDTO]) Headers() pubsub.Headers {
+	return qm.headers.Clone()
+}
@@ -3,11 +3,13 @@ package postgresql_test
 import (
 	"context"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"go.llib.dev/frameless/adapters/postgresql"
 	"go.llib.dev/frameless/ports/migration"
+	"go.llib.dev/frameless/ports/pubsub"
 	"go.llib.dev/frameless/ports/pubsub/pubsubcontracts"
 	"go.llib.dev/frameless/ports/pubsub/pubsubtest"
 	"go.llib.dev/frameless/spechelper/testent"
@@ -95,6 +97,36 @@ func TestQueue(t *testing.T) {
 				MakeData:    MakeEntityFunc(tb),
 			}
 		}),
+		pubsubcontracts.Headers[Entity](func(tb testing.TB) pubsubcontracts.HeadersSubject[Entity] {
+			q := postgresql.Queue[Entity, EntityDTO]{
+				Name:       queueName,
+				Connection: c,
+				Mapping:    mapping,
+			}
+			return pubsubcontracts.HeadersSubject[Entity]{
+				PubSub: pubsubcontracts.PubSub[Entity]{
+					Publisher:  q,
+					Subscriber: q,
+				},
+				MakeContext: context.Background,
+				MakeData:    MakeEntityFunc(tb),
+			}
+		}),
+		pubsubcontracts.Priority[Entity](func(tb testing.TB) pubsubcontracts.PrioritySubject[Entity] {
+			q := postgresql.Queue[Entity, EntityDTO]{
+				Name:       queueName,
+				Connection: c,
+				Mapping:    mapping,
+			}
+			return pubsubcontracts.PrioritySubject[Entity]{
+				PubSub: pubsubcontracts.PubSub[Entity]{
+					Publisher:  q,
+					Subscriber: q,
+				},
+				MakeContext: context.Background,
+				MakeData:    MakeEntityFunc(tb),
+			}
+		}),
 		pubsubcontracts.Queue[Entity](func(tb testing.TB) pubsubcontracts.QueueSubject[Entity] {
 			q := postgresql.Queue[Entity, EntityDTO]{
 				Name:       queueName,
@@ -257,6 +289,67 @@ func TestQueue_smoke(t *testing.T) {
 	})
 }
 
+func TestQueue_requestReply(t *testing.T) {
+	var (
+		rnd = random.New(random.CryptoSeed{})
+		cm  = GetConnection(t)
+		ctx = MakeContext(t)
+	)
+	makeQueue := func(name string) postgresql.Queue[testent.Foo, testent.FooDTO] {
+		return postgresql.Queue[testent.Foo, testent.FooDTO]{
+			Name:       name,
+			Connection: cm,
+			Mapping:    testent.FooJSONMapping{},
+		}
+	}
+	var (
+		requests = makeQueue("requests_" + rnd.UUID())
+		replyTo  = "replies_" + rnd.UUID()
+	)
+	assert.NoError(t, requests.Migrate(ctx))
+
+	requester := &pubsub.Requester[testent.Foo, testent.Foo]{
+		Publisher: requests,
+		Replies:   makeQueue(replyTo),
+		ReplyTo:   replyTo,
+	}
+	responder := pubsub.Responder[testent.Foo, testent.Foo]{
+		Requests: requests,
+		Handler: func(ctx context.Context, req testent.Foo) (testent.Foo, error) {
+			req.Bar = "replied"
+			return req, nil
+		},
+		Replies: func(replyTo string) pubsub.Publisher[testent.Foo] {
+			return makeQueue(replyTo)
+		},
+	}
+
+	runCTX, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, requester.Run(runCTX))
+	}()
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, responder.Run(runCTX))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	reqCTX, cancelReq := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelReq()
+
+	req := testent.MakeFoo(t)
+	reply, err := requester.Request(reqCTX, req)
+	assert.NoError(t, err)
+	assert.Equal(t, req.ID, reply.ID)
+	assert.Equal(t, "replied", reply.Bar)
+}
+
 func BenchmarkQueue(b *testing.B) {
 	const queueName = "test_entity"
 	var (
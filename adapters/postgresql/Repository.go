@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -201,6 +202,66 @@ func (r Repository[Entity, ID]) FindAll(ctx context.Context) iterators.Iterator[
 	return iterators.SQLRows[Entity](rows, r.Mapping)
 }
 
+// FindAllFrom is the resumable version of FindAll.
+// The entities are iterated in the order of their ID,
+// and the checkpoint holds the last seen ID, thus the iteration can continue
+// with the entities after it, even if entities were created or deleted in the meantime.
+// An empty checkpoint starts the iteration from the beginning.
+func (r Repository[Entity, ID]) FindAllFrom(ctx context.Context, checkpoint iterators.Checkpoint) iterators.Resumable[Entity] {
+	iter := &iterFindAllFrom[Entity, ID]{}
+	var (
+		query = fmt.Sprintf(`SELECT %s FROM %s`, r.queryColumnList(), r.Mapping.TableRef())
+		args  []any
+	)
+	if checkpoint != "" {
+		if err := json.Unmarshal([]byte(checkpoint), &iter.lastID); err != nil {
+			iter.Iterator = iterators.Error[Entity](fmt.Errorf("%w: %s", iterators.ErrInvalidCheckpoint, err.Error()))
+			return iter
+		}
+		iter.hasLastID = true
+		query += fmt.Sprintf(` WHERE %s > $1`, r.Mapping.IDRef())
+		args = append(args, iter.lastID)
+	}
+	query += fmt.Sprintf(` ORDER BY %s`, r.Mapping.IDRef())
+
+	rows, err := r.Connection.QueryContext(ctx, query, args...)
+	if err != nil {
+		iter.Iterator = iterators.Error[Entity](err)
+		return iter
+	}
+	iter.Iterator = iterators.SQLRows[Entity](rows, r.Mapping)
+	return iter
+}
+
+type iterFindAllFrom[Entity, ID any] struct {
+	iterators.Iterator[Entity]
+	lastID    ID
+	hasLastID bool
+}
+
+func (iter *iterFindAllFrom[Entity, ID]) Next() bool {
+	if !iter.Iterator.Next() {
+		return false
+	}
+	id, ok := extid.Lookup[ID](iter.Iterator.Value())
+	if !ok {
+		return true
+	}
+	iter.lastID, iter.hasLastID = id, true
+	return true
+}
+
+func (iter *iterFindAllFrom[Entity, ID]) Checkpoint() (iterators.Checkpoint, error) {
+	if !iter.hasLastID {
+		return "", nil
+	}
+	data, err := json.Marshal(iter.lastID)
+	if err != nil {
+		return "", err
+	}
+	return iterators.Checkpoint(data), nil
+}
+
 func (r Repository[Entity, ID]) FindByIDs(ctx context.Context, ids ...ID) iterators.Iterator[Entity] {
 	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = ANY($1)`,
 		r.queryColumnList(), r.Mapping.TableRef(), r.Mapping.IDRef())
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"sort"
 	"testing"
 
 	"github.com/jackc/pgx/v5"
@@ -196,6 +197,45 @@ func TestRepository_comprotoOnePhaseCommitProtocol(t *testing.T) {
 	crudtest.IsAbsent[testent.Foo, testent.FooID](t, repo, ctx, v.ID)
 }
 
+func TestRepository_FindAllFrom(t *testing.T) {
+	repo := &postgresql.Repository[testent.Foo, testent.FooID]{
+		Connection: GetConnection(t),
+		Mapping:    FooMapping,
+	}
+	MigrateFoo(t, repo.Connection)
+
+	var (
+		ctx = context.Background()
+		rnd = random.New(random.CryptoSeed{})
+		ids []testent.FooID
+	)
+	for i := 0; i < 5; i++ {
+		v := rnd.Make(testent.Foo{}).(testent.Foo)
+		crudtest.Create[testent.Foo, testent.FooID](t, repo, ctx, &v)
+		ids = append(ids, v.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	iter := repo.FindAllFrom(ctx, "")
+	assert.True(t, iter.Next())
+	assert.True(t, iter.Next())
+	assert.Equal(t, ids[1], iter.Value().ID)
+	checkpoint, err := iter.Checkpoint()
+	assert.NoError(t, err)
+	assert.NoError(t, iter.Close())
+
+	vs, err := iterators.Collect[testent.Foo](repo.FindAllFrom(ctx, checkpoint))
+	assert.NoError(t, err)
+	var got []testent.FooID
+	for _, v := range vs {
+		got = append(got, v.ID)
+	}
+	assert.Equal(t, ids[2:], got)
+
+	_, err = iterators.Collect[testent.Foo](repo.FindAllFrom(ctx, "{malformed"))
+	assert.ErrorIs(t, iterators.ErrInvalidCheckpoint, err)
+}
+
 func Test_pgxTx(t *testing.T) {
 	var (
 		ctx   = context.Background()
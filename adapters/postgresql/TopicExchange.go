@@ -0,0 +1,181 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/ports/comproto"
+	"go.llib.dev/frameless/ports/pubsub"
+)
+
+// TopicExchange delivers messages to the queues which binding pattern matches the message's routing key.
+// The binding pattern is made of dot separated words, where "*" substitutes exactly one word,
+// and "#" substitutes zero or more words.
+//
+// The bindings are persisted in the database, thus publishers and subscribers
+// in different processes share the same routing topology.
+// Every bound queue is a regular Queue, identified by its queue name.
+type TopicExchange[Entity, JSONDTO any] struct {
+	Name       string
+	Connection Connection
+	Mapping    QueueMapper[Entity, JSONDTO]
+
+	// EmptyQueueBreakTime is the time.Duration that the queues made by the exchange waits when they are empty.
+	EmptyQueueBreakTime time.Duration
+}
+
+const topicExchangeBindingTableName = "frameless_topic_exchange_bindings"
+
+const queryCreateTopicExchangeBindingTable = `
+CREATE TABLE IF NOT EXISTS ` + topicExchangeBindingTableName + ` (
+	exchange TEXT NOT NULL,
+	queue    TEXT NOT NULL,
+	pattern  TEXT NOT NULL,
+	PRIMARY KEY (exchange, queue, pattern)
+)
+;`
+
+var topicExchangeMigratorConfig = MigratorGroup{
+	ID: topicExchangeBindingTableName,
+	Steps: []MigratorStep{
+		MigrationStep{UpQuery: queryCreateTopicExchangeBindingTable},
+	},
+}
+
+func (e TopicExchange[Entity, JSONDTO]) Migrate(ctx context.Context) error {
+	if err := e.queue("").Migrate(ctx); err != nil {
+		return err
+	}
+	return Migrator{
+		Connection: e.Connection,
+		Group:      topicExchangeMigratorConfig,
+	}.Migrate(ctx)
+}
+
+const queryTopicExchangeBind = `
+INSERT INTO ` + topicExchangeBindingTableName + ` (exchange, queue, pattern)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+`
+
+// Bind will bind the queue to the exchange with the routing key pattern.
+// Binding the same queue and pattern more than once has no effect.
+func (e TopicExchange[Entity, JSONDTO]) Bind(ctx context.Context, queueName, pattern string) error {
+	if e.Name == "" {
+		return fmt.Errorf("missing exchange name")
+	}
+	if queueName == "" {
+		return fmt.Errorf("missing queue name")
+	}
+	_, err := e.Connection.ExecContext(ctx, queryTopicExchangeBind, e.Name, queueName, pattern)
+	return err
+}
+
+const queryTopicExchangeUnbind = `
+DELETE FROM ` + topicExchangeBindingTableName + `
+WHERE exchange = $1
+  AND queue = $2
+  AND pattern = $3
+`
+
+// Unbind will remove the binding of the queue with the given routing key pattern.
+func (e TopicExchange[Entity, JSONDTO]) Unbind(ctx context.Context, queueName, pattern string) error {
+	_, err := e.Connection.ExecContext(ctx, queryTopicExchangeUnbind, e.Name, queueName, pattern)
+	return err
+}
+
+// MakeQueue binds the queue name to the exchange with the routing key pattern,
+// and returns the Queue that receives the matching messages.
+func (e TopicExchange[Entity, JSONDTO]) MakeQueue(ctx context.Context, queueName, pattern string) (Queue[Entity, JSONDTO], error) {
+	if err := e.Bind(ctx, queueName, pattern); err != nil {
+		return Queue[Entity, JSONDTO]{}, err
+	}
+	return e.queue(queueName), nil
+}
+
+// Publish will publish all data to the bound queues which pattern matches the routing key.
+// It will either all succeed or all fail together.
+func (e TopicExchange[Entity, JSONDTO]) Publish(ctx context.Context, routingKey string, vs ...Entity) (rErr error) {
+	if 0 == len(vs) {
+		return ctx.Err()
+	}
+	if e.Name == "" {
+		return fmt.Errorf("missing exchange name")
+	}
+
+	ctx, err := e.Connection.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer comproto.FinishOnePhaseCommit(&rErr, e.Connection, ctx)
+
+	queueNames, err := e.queueNamesFor(ctx, routingKey)
+	if err != nil {
+		return err
+	}
+	for _, queueName := range queueNames {
+		if err := e.queue(queueName).Publish(ctx, vs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const queryTopicExchangePurge = `
+DELETE FROM ` + queueTableName + `
+WHERE queue IN (
+	SELECT queue
+	FROM ` + topicExchangeBindingTableName + `
+	WHERE exchange = $1
+)
+`
+
+// Purge will flush all data from the exchange's queues
+func (e TopicExchange[Entity, JSONDTO]) Purge(ctx context.Context) error {
+	_, err := e.Connection.ExecContext(ctx, queryTopicExchangePurge, e.Name)
+	return err
+}
+
+const queryTopicExchangeBindings = `
+SELECT queue, pattern
+FROM ` + topicExchangeBindingTableName + `
+WHERE exchange = $1
+`
+
+func (e TopicExchange[Entity, JSONDTO]) queueNamesFor(ctx context.Context, routingKey string) (_ []string, rErr error) {
+	rows, err := e.Connection.QueryContext(ctx, queryTopicExchangeBindings, e.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer errorkit.Finish(&rErr, rows.Close)
+	var (
+		queueNames []string
+		seen       = make(map[string]struct{})
+	)
+	for rows.Next() {
+		var queueName, pattern string
+		if err := rows.Scan(&queueName, &pattern); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[queueName]; ok {
+			continue
+		}
+		if !pubsub.MatchRoutingKey(pattern, routingKey) {
+			continue
+		}
+		seen[queueName] = struct{}{}
+		queueNames = append(queueNames, queueName)
+	}
+	return queueNames, rows.Err()
+}
+
+func (e TopicExchange[Entity, JSONDTO]) queue(name string) Queue[Entity, JSONDTO] {
+	return Queue[Entity, JSONDTO]{
+		Name:                name,
+		Connection:          e.Connection,
+		Mapping:             e.Mapping,
+		EmptyQueueBreakTime: e.EmptyQueueBreakTime,
+	}
+}
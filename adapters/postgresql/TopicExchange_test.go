@@ -0,0 +1,59 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+
+	"go.llib.dev/frameless/adapters/postgresql"
+	"go.llib.dev/frameless/ports/migration"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/frameless/ports/pubsub/pubsubcontracts"
+	"go.llib.dev/frameless/spechelper/testent"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/random"
+)
+
+var (
+	_ migration.Migratable          = postgresql.TopicExchange[Entity, EntityDTO]{}
+	_ pubsub.TopicPublisher[Entity] = postgresql.TopicExchange[Entity, EntityDTO]{}
+)
+
+func TestTopicExchange(t *testing.T) {
+	c := GetConnection(t)
+
+	testcase.RunSuite(t,
+		pubsubcontracts.Topic[testent.Foo](func(tb testing.TB) pubsubcontracts.TopicSubject[testent.Foo] {
+			rnd := random.New(random.CryptoSeed{})
+			exchange := postgresql.TopicExchange[testent.Foo, testent.FooDTO]{
+				Name:       rnd.UUID(),
+				Connection: c,
+				Mapping:    testent.FooJSONMapping{},
+			}
+			assert.NoError(tb, exchange.Migrate(MakeContext(tb)))
+			tb.Cleanup(func() { _ = exchange.Purge(context.Background()) })
+
+			return pubsubcontracts.TopicSubject[testent.Foo]{
+				Exchange: exchange,
+				MakeQueue: func(pattern string) pubsub.Subscriber[testent.Foo] {
+					queueName := rnd.UUID()
+					q, err := exchange.MakeQueue(MakeContext(tb), queueName, pattern)
+					assert.NoError(tb, err)
+					tb.Cleanup(func() {
+						assert.NoError(tb, exchange.Unbind(context.Background(), queueName, pattern))
+					})
+					return q
+				},
+				Bind: func(queue pubsub.Subscriber[testent.Foo], pattern string) {
+					queueName := queue.(postgresql.Queue[testent.Foo, testent.FooDTO]).Name
+					assert.NoError(tb, exchange.Bind(MakeContext(tb), queueName, pattern))
+					tb.Cleanup(func() {
+						assert.NoError(tb, exchange.Unbind(context.Background(), queueName, pattern))
+					})
+				},
+				MakeContext: context.Background,
+				MakeData:    testent.MakeFooFunc(tb),
+			}
+		}),
+	)
+}
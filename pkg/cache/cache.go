@@ -10,7 +10,10 @@ import (
 	"go.llib.dev/frameless/ports/crud"
 	"go.llib.dev/frameless/ports/crud/extid"
 	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/pubsub"
 	"go.llib.dev/testcase/clock"
+	"sync"
+	"time"
 )
 
 func New[Entity any, ID comparable](
@@ -31,6 +34,40 @@ type Cache[Entity any, ID comparable] struct {
 	Repository Repository[Entity, ID]
 
 	CachedQueryInvalidators []CachedQueryInvalidator[Entity, ID]
+	// TimeToLive is the duration until a cached query hit is considered fresh, based on its Timestamp.
+	// Expired hits are re-fetched from the Source transparently.
+	// A query specific time-to-live can be set with ContextWithTimeToLive.
+	//
+	// Default: the cached values never expire.
+	TimeToLive time.Duration
+	// Invalidations is an optional Publisher, where the Cache publishes its invalidations,
+	// so the peer instances sharing the same Source can invalidate their own cache as well.
+	// A FanOutExchange-like topology is expected, where every instance receives the invalidations of every other instance.
+	Invalidations pubsub.Publisher[Invalidation[ID]]
+	// PeerInvalidations is the optional Subscriber of the invalidations published by the peer instances.
+	// The invalidations are applied by Cache.RunInvalidationConsumer.
+	PeerInvalidations pubsub.Subscriber[Invalidation[ID]]
+	// InstanceID identifies the Cache instance in the published invalidations,
+	// which allows the instance to ignore its own invalidations.
+	//
+	// Default: a random UUID
+	InstanceID string
+	// StaleWhileRevalidate is the period after the TimeToLive,
+	// while an expired cached query is still served, and its refresh is done in the background.
+	// After this period, the cached query is re-fetched synchronously, thus TimeToLive+StaleWhileRevalidate is the hard time-to-live.
+	// The background refresh requires a running Cache.RunRevalidation.
+	// A CachedQueryMany or CachedQueryOne is only refreshed in the background, when its query key is registered with Cache.RegisterQuery.
+	//
+	// Default: the expired cached queries are re-fetched synchronously.
+	StaleWhileRevalidate time.Duration
+
+	// flights coalesce the concurrent cache misses of the same query,
+	// so they share a single call to the Source.
+	flights        singleflight[[]Entity]
+	revalidation   revalidation[Entity]
+	instanceIDOnce sync.Once
+	metrics        metrics
+	warmUps        warmUps[Entity, ID]
 }
 
 type CachedQueryInvalidator[Entity, ID any] struct {
@@ -59,7 +96,15 @@ type Repository[Entity, ID any] interface {
 // If you have CachedQueryMany and CachedQueryOne usage, then you must use InvalidateCachedQuery instead of this.
 // This is requires because if absence of an entity is cached in the HitRepository,
 // then it is impossible to determine how to invalidate those queries using an Entity ID.
-func (m *Cache[Entity, ID]) InvalidateByID(ctx context.Context, id ID) (rErr error) {
+func (m *Cache[Entity, ID]) InvalidateByID(ctx context.Context, id ID) error {
+	if err := m.invalidateByID(ctx, id); err != nil {
+		return err
+	}
+	m.publishInvalidation(ctx, Invalidation[ID]{Kind: InvalidateByID, EntityID: id})
+	return nil
+}
+
+func (m *Cache[Entity, ID]) invalidateByID(ctx context.Context, id ID) (rErr error) {
 	ctx, err := m.Repository.BeginTx(ctx)
 	if err != nil {
 		return err
@@ -87,18 +132,22 @@ func (m *Cache[Entity, ID]) InvalidateByID(ctx context.Context, id ID) (rErr err
 				continue
 			}
 			for _, hit := range inv.CheckEntity(ent) {
-				if err := m.InvalidateCachedQuery(ctx, hit); err != nil {
+				if err := m.invalidateCachedQuery(ctx, hit); err != nil {
 					return err
 				}
 			}
 		}
 	}
 
-	if err := m.InvalidateCachedQuery(ctx, m.queryKeyFindByID(id)); err != nil {
+	if err := m.invalidateCachedQuery(ctx, m.queryKeyFindByID(id)); err != nil {
 		return err
 	}
 
 	HitsThatReferenceOurEntity := iterators.Filter[Hit[ID]](m.Repository.Hits().FindAll(ctx), func(h Hit[ID]) bool {
+		if h.QueryID == m.queryKeyFindAll() {
+			// a newly created entity is not referenced yet by the FindAll hit, but it is stale all the same.
+			return true
+		}
 		for _, gotID := range h.EntityIDs {
 			if gotID == id {
 				return true
@@ -137,12 +186,28 @@ func (m *Cache[Entity, ID]) InvalidateByID(ctx context.Context, id ID) (rErr err
 }
 
 func (m *Cache[Entity, ID]) DropCachedValues(ctx context.Context) error {
+	if err := m.dropCachedValues(ctx); err != nil {
+		return err
+	}
+	m.publishInvalidation(ctx, Invalidation[ID]{Kind: DropCachedValues})
+	return nil
+}
+
+func (m *Cache[Entity, ID]) dropCachedValues(ctx context.Context) error {
 	return errorkit.Merge(
 		m.Repository.Hits().DeleteAll(ctx),
 		m.Repository.Entities().DeleteAll(ctx))
 }
 
-func (m *Cache[Entity, ID]) InvalidateCachedQuery(ctx context.Context, queryKey HitID) (rErr error) {
+func (m *Cache[Entity, ID]) InvalidateCachedQuery(ctx context.Context, queryKey HitID) error {
+	if err := m.invalidateCachedQuery(ctx, queryKey); err != nil {
+		return err
+	}
+	m.publishInvalidation(ctx, Invalidation[ID]{Kind: InvalidateCachedQuery, QueryKey: queryKey})
+	return nil
+}
+
+func (m *Cache[Entity, ID]) invalidateCachedQuery(ctx context.Context, queryKey HitID) (rErr error) {
 	ctx, err := m.Repository.BeginTx(ctx)
 	if err != nil {
 		return err
@@ -159,7 +224,7 @@ func (m *Cache[Entity, ID]) InvalidateCachedQuery(ctx context.Context, queryKey
 	}
 
 	for _, entID := range hit.EntityIDs {
-		if err := m.InvalidateByID(ctx, entID); err != nil {
+		if err := m.invalidateByID(ctx, entID); err != nil {
 			return err
 		}
 	}
@@ -175,10 +240,28 @@ func (m *Cache[Entity, ID]) invalidateCachedQueryWithoutCascadeEffect(ctx contex
 	return hit, found, m.Repository.Hits().DeleteByID(ctx, queryKey)
 }
 
+// CachedQueryMany returns the cached results of the query, or executes the query and caches its results.
+//
+// The query most likely depends on the context of the caller's request,
+// thus with Cache.StaleWhileRevalidate, an expired cached query is only refreshed in the background
+// when its query key is registered with Cache.RegisterQuery, otherwise it is re-fetched synchronously.
 func (m *Cache[Entity, ID]) CachedQueryMany(
 	ctx context.Context,
 	queryKey string,
 	query QueryManyFunc[Entity],
+) iterators.Iterator[Entity] {
+	return m.cachedQueryMany(ctx, queryKey, func(context.Context) iterators.Iterator[Entity] {
+		return query()
+	}, false)
+}
+
+// cachedQueryMany returns the cached results of the query, or executes the query and caches its results.
+// A detachable query uses the received context, so it can be executed in the background as well.
+func (m *Cache[Entity, ID]) cachedQueryMany(
+	ctx context.Context,
+	queryKey string,
+	query func(ctx context.Context) iterators.Iterator[Entity],
+	detachable bool,
 ) iterators.Iterator[Entity] {
 	// TODO: double check
 	if ctx != nil && ctx.Err() != nil {
@@ -188,7 +271,14 @@ func (m *Cache[Entity, ID]) CachedQueryMany(
 	hit, found, err := m.Repository.Hits().FindByID(ctx, queryKey)
 	if err != nil {
 		logger.Warn(ctx, fmt.Sprintf("error during retrieving hits for %s", queryKey), logger.ErrField(err))
-		return query()
+		return query(ctx)
+	}
+	if found && m.isExpired(ctx, hit) && !m.revalidate(ctx, hit, query, detachable) {
+		if _, _, err := m.invalidateCachedQueryWithoutCascadeEffect(ctx, queryKey); err != nil {
+			logger.Warn(ctx, fmt.Sprintf("error during removing the expired hit for %s", queryKey), logger.ErrField(err))
+			return query(ctx)
+		}
+		found = false
 	}
 	if found {
 		// TODO: make sure that in case entity ids point to empty cache data
@@ -196,55 +286,125 @@ func (m *Cache[Entity, ID]) CachedQueryMany(
 		iter := m.Repository.Entities().FindByIDs(ctx, hit.EntityIDs...)
 		if err := iter.Err(); err != nil {
 			logger.Warn(ctx, "cache Repository.Entities().FindByIDs had an error", logger.ErrField(err))
-			return query()
+			return query(ctx)
 		}
+		m.metrics.Hit(queryKey)
 		return iter
 	}
 
+	m.metrics.Miss(queryKey)
+	res, err := m.flights.Do(ctx, queryKey, func() ([]Entity, error) {
+		// a previous flight might have finished since the hit lookup.
+		if hit, found, err := m.Repository.Hits().FindByID(ctx, queryKey); err == nil && found && !m.isExpired(ctx, hit) {
+			if vs, err := iterators.Collect(m.Repository.Entities().FindByIDs(ctx, hit.EntityIDs...)); err == nil {
+				return vs, nil
+			}
+		}
+		return m.refresh(ctx, queryKey, query)
+	})
+	if err != nil {
+		return iterators.Error[Entity](err)
+	}
+	return iterators.Slice[Entity](res)
+}
+
+// revalidate schedules the background refresh of an expired hit,
+// and reports whether the stale hit can be served in the meantime.
+func (m *Cache[Entity, ID]) revalidate(ctx context.Context, hit Hit[ID], query func(ctx context.Context) iterators.Iterator[Entity], detachable bool) bool {
+	if !m.isStale(ctx, hit) {
+		return false
+	}
+	if !detachable { // the query might be bound to the caller's context, which is cancelled after the call.
+		registered, ok := m.registeredQuery(hit.QueryID)
+		if !ok {
+			return false
+		}
+		query = registered
+	}
+	return m.revalidation.Schedule(revalidationJob[Entity]{
+		Context:  logger.ContextWithDetailsOf(context.Background(), ctx),
+		QueryKey: hit.QueryID,
+		Query:    query,
+	})
+}
+
+// refresh executes the query, and caches its results.
+func (m *Cache[Entity, ID]) refresh(ctx context.Context, queryKey HitID, query func(ctx context.Context) iterators.Iterator[Entity]) ([]Entity, error) {
 	// this naive MVP approach might take a big burden on the memory.
 	// If this becomes the case, it should be possible to change this into a streaming approach
 	// where iterator being iterated element by element,
 	// and records being created during then in the Repository
-	var ids []ID
-	res, err := iterators.Collect(query())
+	begin := clock.TimeNow()
+	res, err := iterators.Collect(query(ctx))
+	m.metrics.SourceCall(begin)
 	if err != nil {
-		return iterators.Error[Entity](err)
+		return nil, err
 	}
-	for _, v := range res {
-		id, _ := extid.Lookup[ID](v)
-		ids = append(ids, id)
+	if err := m.store(ctx, queryKey, res); err != nil {
+		logger.Warn(ctx, "error during caching the query results", logger.ErrField(err))
 	}
+	return res, nil
+}
 
-	var vs []*Entity
+// store caches the entities, and the query hit which references them.
+func (m *Cache[Entity, ID]) store(ctx context.Context, queryKey HitID, res []Entity) error {
+	var (
+		ids []ID
+		vs  []*Entity
+	)
 	for _, ent := range res {
 		ent := ent // pass by value copy
+		id, _ := extid.Lookup[ID](ent)
+		ids = append(ids, id)
 		vs = append(vs, &ent)
 	}
-
 	if err := m.Repository.Entities().Upsert(ctx, vs...); err != nil {
-		logger.Warn(ctx, "cache Repository.Entities().Upsert had an error", logger.ErrField(err))
-		return iterators.Slice[Entity](res)
+		return fmt.Errorf("cache Repository.Entities().Upsert had an error: %w", err)
 	}
+	return m.storeHit(ctx, Hit[ID]{QueryID: queryKey, EntityIDs: ids})
+}
 
-	if err := m.Repository.Hits().Create(ctx, &Hit[ID]{
-		QueryID:   queryKey,
-		EntityIDs: ids,
-		Timestamp: clock.TimeNow().UTC(),
-	}); err != nil {
-		logger.Warn(ctx, "cache Repository.Hits().Create had an error", logger.ErrField(err))
-		return iterators.Slice[Entity](res)
+// storeHit creates the hit with the current time as its Timestamp,
+// or updates it when it is already present, like a stale hit which is kept until its revalidation is done.
+func (m *Cache[Entity, ID]) storeHit(ctx context.Context, hit Hit[ID]) error {
+	hit.Timestamp = clock.TimeNow().UTC()
+	_, found, err := m.Repository.Hits().FindByID(ctx, hit.QueryID)
+	if err != nil {
+		return fmt.Errorf("cache Repository.Hits().FindByID had an error: %w", err)
 	}
-
-	return iterators.Slice[Entity](res)
+	if found {
+		if err := m.Repository.Hits().Update(ctx, &hit); err != nil {
+			return fmt.Errorf("cache Repository.Hits().Update had an error: %w", err)
+		}
+		return nil
+	}
+	if err := m.Repository.Hits().Create(ctx, &hit); err != nil {
+		return fmt.Errorf("cache Repository.Hits().Create had an error: %w", err)
+	}
+	return nil
 }
 
+// CachedQueryOne returns the cached result of the query, or executes the query and caches its result.
+// Like with CachedQueryMany, the expired cached query is only refreshed in the background
+// when its query key is registered with Cache.RegisterQuery.
 func (m *Cache[Entity, ID]) CachedQueryOne(
 	ctx context.Context,
 	queryKey string,
 	query QueryOneFunc[Entity],
 ) (_ent Entity, _found bool, _err error) {
-	iter := m.CachedQueryMany(ctx, queryKey, func() iterators.Iterator[Entity] {
-		ent, found, err := query()
+	return m.cachedQueryOne(ctx, queryKey, func(context.Context) (Entity, bool, error) {
+		return query()
+	}, false)
+}
+
+func (m *Cache[Entity, ID]) cachedQueryOne(
+	ctx context.Context,
+	queryKey string,
+	query func(ctx context.Context) (Entity, bool, error),
+	detachable bool,
+) (_ent Entity, _found bool, _err error) {
+	iter := m.cachedQueryMany(ctx, queryKey, func(ctx context.Context) iterators.Iterator[Entity] {
+		ent, found, err := query(ctx)
 		if err != nil {
 			return iterators.Error[Entity](err)
 		}
@@ -252,7 +412,7 @@ func (m *Cache[Entity, ID]) CachedQueryOne(
 			return iterators.Empty[Entity]()
 		}
 		return iterators.Slice[Entity]([]Entity{ent})
-	})
+	}, detachable)
 
 	ent, found, err := iterators.First[Entity](iter)
 	if err != nil {
@@ -274,13 +434,27 @@ func (m *Cache[Entity, ID]) Create(ctx context.Context, ptr *Entity) error {
 	if err := source.Create(ctx, ptr); err != nil {
 		return err
 	}
+	// the absence of the new entity might be cached already, just like query results without it.
+	id, hasID := extid.Lookup[ID](*ptr)
+	if hasID {
+		if err := m.invalidateByID(ctx, id); err != nil {
+			logger.Warn(ctx, "error during invalidating the cached queries of the created entity", logger.ErrField(err))
+		}
+	}
 	if err := m.Repository.Entities().Create(ctx, ptr); err != nil {
 		logger.Warn(ctx, "cache Repository.Entities().Create had an error", logger.ErrField(err))
 	}
+	if hasID {
+		m.publishInvalidation(ctx, Invalidation[ID]{Kind: InvalidateByID, EntityID: id})
+	}
 	return nil
 }
 
 func (m *Cache[Entity, ID]) FindByID(ctx context.Context, id ID) (Entity, bool, error) {
+	if 0 < m.timeToLive(ctx) {
+		// cached entities don't have a timestamp, only the FindByID query hit can tell their freshness.
+		return m.findByID(ctx, id)
+	}
 	// fast path
 	ent, found, err := m.Repository.Entities().FindByID(ctx, id)
 	if err != nil {
@@ -288,12 +462,17 @@ func (m *Cache[Entity, ID]) FindByID(ctx context.Context, id ID) (Entity, bool,
 		return m.Source.FindByID(ctx, id)
 	}
 	if found {
+		m.metrics.Hit(m.queryKeyFindByID(id))
 		return ent, true, nil
 	}
 	// slow path
-	return m.CachedQueryOne(ctx, m.queryKeyFindByID(id), func() (ent Entity, found bool, err error) {
+	return m.findByID(ctx, id)
+}
+
+func (m *Cache[Entity, ID]) findByID(ctx context.Context, id ID) (Entity, bool, error) {
+	return m.cachedQueryOne(ctx, m.queryKeyFindByID(id), func(ctx context.Context) (ent Entity, found bool, err error) {
 		return m.Source.FindByID(ctx, id)
-	})
+	}, true)
 }
 
 func (m *Cache[Entity, ID]) queryKeyFindByID(id ID) HitID {
@@ -308,9 +487,13 @@ func (m *Cache[Entity, ID]) FindAll(ctx context.Context) iterators.Iterator[Enti
 	if !ok {
 		return iterators.Errorf[Entity]("%s: %w", "FindAll", ErrNotImplementedBySource)
 	}
-	return m.CachedQueryMany(ctx, QueryKey{ID: "FindAll"}.Encode(), func() iterators.Iterator[Entity] {
+	return m.cachedQueryMany(ctx, m.queryKeyFindAll(), func(ctx context.Context) iterators.Iterator[Entity] {
 		return source.FindAll(ctx)
-	})
+	}, true)
+}
+
+func (m *Cache[Entity, ID]) queryKeyFindAll() HitID {
+	return QueryKey{ID: "FindAll"}.Encode()
 }
 
 func (m *Cache[Entity, ID]) Update(ctx context.Context, ptr *Entity) error {
@@ -321,12 +504,16 @@ func (m *Cache[Entity, ID]) Update(ctx context.Context, ptr *Entity) error {
 	if err := source.Update(ctx, ptr); err != nil {
 		return err
 	}
+	id, hasID := extid.Lookup[ID](*ptr)
 	if err := m.Repository.Entities().Update(ctx, ptr); err != nil {
 		logger.Warn(ctx, "cache Repository.Entities().Update had an error", logger.ErrField(err))
-		if id, ok := extid.Lookup[ID](*ptr); ok {
+		if hasID {
 			return m.InvalidateByID(ctx, id)
 		}
 	}
+	if hasID { // the peer instances still have the old version in their cache
+		m.publishInvalidation(ctx, Invalidation[ID]{Kind: InvalidateByID, EntityID: id})
+	}
 	return nil
 }
 
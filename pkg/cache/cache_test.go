@@ -5,16 +5,26 @@ import (
 	"go.llib.dev/frameless/adapters/memory"
 	"go.llib.dev/frameless/pkg/cache"
 	"go.llib.dev/frameless/pkg/cache/cachecontracts"
+	"go.llib.dev/frameless/pkg/devops/health"
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/frameless/pkg/tasker"
 	"go.llib.dev/frameless/ports/comproto"
 	"go.llib.dev/frameless/ports/crud/crudtest"
 	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/pubsub"
 	"go.llib.dev/frameless/spechelper/testent"
 	"go.llib.dev/testcase"
 	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/clock"
+	"go.llib.dev/testcase/clock/timecop"
 	"go.llib.dev/testcase/pp"
 	"go.llib.dev/testcase/random"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var _ cache.Interface[testent.Foo, testent.FooID] = &cache.Cache[testent.Foo, testent.FooID]{}
@@ -242,6 +252,997 @@ func TestCache_withFaultyCacheRepository(t *testing.T) {
 	// TODO: Update, Delete
 }
 
+func TestCache_requestCoalescing(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	const callers = 16
+	type result struct {
+		Values []testent.Foo
+		Err    error
+	}
+	var (
+		ctx    = context.Background()
+		source = testcase.Let(s, func(t *testcase.T) *memory.Repository[testent.Foo, testent.FooID] {
+			return memory.NewRepository[testent.Foo, testent.FooID](memory.NewMemory())
+		})
+		foo = testcase.Let(s, func(t *testcase.T) testent.Foo {
+			foo := testent.MakeFoo(t)
+			crudtest.Create[testent.Foo, testent.FooID](t, source.Get(t), ctx, &foo)
+			return foo
+		}).EagerLoading(s)
+		// calls counts the source calls, which are blocked until release is closed.
+		calls = testcase.Let(s, func(t *testcase.T) *int32 {
+			return new(int32)
+		})
+		release = testcase.Let(s, func(t *testcase.T) chan struct{} {
+			return make(chan struct{})
+		})
+		subject = testcase.Let(s, func(t *testcase.T) *cache.Cache[testent.Foo, testent.FooID] {
+			return cache.New[testent.Foo, testent.FooID](source.Get(t), memory.NewCacheRepository[testent.Foo, testent.FooID](memory.NewMemory()))
+		})
+	)
+	queryAll := func(t *testcase.T, ctx context.Context, query cache.QueryManyFunc[testent.Foo]) <-chan result {
+		c := subject.Get(t)
+		out := make(chan result, 1)
+		go func() {
+			vs, err := iterators.Collect(c.CachedQueryMany(ctx, "all", query))
+			out <- result{Values: vs, Err: err}
+		}()
+		return out
+	}
+	blockingQuery := func(t *testcase.T, err error) cache.QueryManyFunc[testent.Foo] {
+		calls, release, source := calls.Get(t), release.Get(t), source.Get(t)
+		return func() iterators.Iterator[testent.Foo] {
+			atomic.AddInt32(calls, 1)
+			<-release
+			if err != nil {
+				return iterators.Error[testent.Foo](err)
+			}
+			return source.FindAll(ctx)
+		}
+	}
+	// waitForFlight waits until the source calls are made, and the other callers joined them.
+	waitForFlight := func(t *testcase.T, n int32, waiters int) {
+		t.Eventually(func(it assert.It) {
+			it.Must.Equal(n, atomic.LoadInt32(calls.Get(t)))
+			it.Must.Equal(waiters, subject.Get(t).FlightWaiters())
+		})
+	}
+	assertFoo := func(t *testcase.T, r result) {
+		t.Must.NoError(r.Err)
+		t.Must.ContainExactly([]testent.Foo{foo.Get(t)}, r.Values)
+	}
+
+	s.Test("concurrent cache misses share a single source call", func(t *testcase.T) {
+		query := blockingQuery(t, nil)
+		var results []<-chan result
+		for i := 0; i < callers; i++ {
+			results = append(results, queryAll(t, ctx, query))
+		}
+		waitForFlight(t, 1, callers-1)
+		close(release.Get(t))
+
+		for _, res := range results {
+			assertFoo(t, <-res)
+		}
+		t.Must.Equal(int32(1), atomic.LoadInt32(calls.Get(t)))
+	})
+
+	s.Test("the source error is shared with every waiting caller", func(t *testcase.T) {
+		expErr := t.Random.Error()
+		query := blockingQuery(t, expErr)
+		var results []<-chan result
+		for i := 0; i < callers; i++ {
+			results = append(results, queryAll(t, ctx, query))
+		}
+		waitForFlight(t, 1, callers-1)
+		close(release.Get(t))
+
+		for _, res := range results {
+			t.Must.ErrorIs(expErr, (<-res).Err)
+		}
+		t.Must.Equal(int32(1), atomic.LoadInt32(calls.Get(t)))
+	})
+
+	s.Test("a cancelled waiter returns without affecting the others", func(t *testcase.T) {
+		query := blockingQuery(t, nil)
+		leader := queryAll(t, ctx, query)
+		waitForFlight(t, 1, 0)
+		waiterCtx, cancel := context.WithCancel(ctx)
+		waiter := queryAll(t, waiterCtx, query)
+		other := queryAll(t, ctx, query)
+		waitForFlight(t, 1, 2)
+
+		cancel()
+		t.Must.ErrorIs(context.Canceled, (<-waiter).Err)
+		close(release.Get(t))
+
+		assertFoo(t, <-leader)
+		assertFoo(t, <-other)
+		t.Must.Equal(int32(1), atomic.LoadInt32(calls.Get(t)))
+	})
+
+	s.Test("when the leading caller is cancelled, a waiter retries the query", func(t *testcase.T) {
+		leaderCtx, cancel := context.WithCancel(ctx)
+		calls, release := calls.Get(t), release.Get(t)
+		leader := queryAll(t, leaderCtx, func() iterators.Iterator[testent.Foo] {
+			atomic.AddInt32(calls, 1)
+			<-release
+			return iterators.Error[testent.Foo](leaderCtx.Err())
+		})
+		waitForFlight(t, 1, 0)
+		waiter := queryAll(t, ctx, blockingQuery(t, nil))
+		waitForFlight(t, 1, 1)
+
+		cancel()
+		close(release)
+
+		t.Must.ErrorIs(context.Canceled, (<-leader).Err)
+		assertFoo(t, <-waiter)
+		t.Must.Equal(int32(2), atomic.LoadInt32(calls))
+	})
+
+	s.When("the cache misses are made with FindByID", func(s *testcase.Spec) {
+		subject.Let(s, func(t *testcase.T) *cache.Cache[testent.Foo, testent.FooID] {
+			src := &blockingFindByIDSource{Repository: source.Get(t), Calls: calls.Get(t), Release: release.Get(t)}
+			return cache.New[testent.Foo, testent.FooID](src, memory.NewCacheRepository[testent.Foo, testent.FooID](memory.NewMemory()))
+		})
+
+		s.Then("they share a single source call", func(t *testcase.T) {
+			c, foo := subject.Get(t), foo.Get(t)
+			var wg sync.WaitGroup
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					got, found, err := c.FindByID(ctx, foo.ID)
+					t.Should.NoError(err)
+					t.Should.True(found)
+					t.Should.Equal(foo, got)
+				}()
+			}
+			waitForFlight(t, 1, callers-1)
+			close(release.Get(t))
+			wg.Wait()
+			t.Must.Equal(int32(1), atomic.LoadInt32(calls.Get(t)))
+		})
+	})
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	const (
+		ttl = time.Minute
+		swr = time.Hour
+	)
+	var (
+		source = testcase.Let(s, func(t *testcase.T) *memory.Repository[testent.Foo, testent.FooID] {
+			return memory.NewRepository[testent.Foo, testent.FooID](memory.NewMemory())
+		})
+		subject = testcase.Let(s, func(t *testcase.T) *cache.Cache[testent.Foo, testent.FooID] {
+			c := cache.New[testent.Foo, testent.FooID](source.Get(t), memory.NewCacheRepository[testent.Foo, testent.FooID](memory.NewMemory()))
+			c.TimeToLive = ttl
+			c.StaleWhileRevalidate = swr
+			return c
+		})
+		foo = testcase.Let(s, func(t *testcase.T) testent.Foo {
+			foo := testent.MakeFoo(t)
+			crudtest.Create[testent.Foo, testent.FooID](t, source.Get(t), context.Background(), &foo)
+			crudtest.IsPresent[testent.Foo, testent.FooID](t, subject.Get(t), context.Background(), foo.ID) // cache it
+			return foo
+		}).EagerLoading(s)
+		// updated is the foo, which is updated in the Source, but not in the Cache.
+		updated = testcase.Let(s, func(t *testcase.T) testent.Foo {
+			foo := foo.Get(t)
+			foo.Foo = t.Random.UUID()
+			crudtest.Update[testent.Foo, testent.FooID](t, source.Get(t), context.Background(), &foo)
+			return foo
+		})
+	)
+	findByID := func(tb testing.TB, c *cache.Cache[testent.Foo, testent.FooID], id testent.FooID) testent.Foo {
+		got, found, err := c.FindByID(context.Background(), id)
+		assert.NoError(tb, err)
+		assert.True(tb, found)
+		return got
+	}
+
+	s.Context("with a running revalidation", func(s *testcase.Spec) {
+		s.Before(func(t *testcase.T) {
+			runRevalidation(t, subject.Get(t))
+		})
+
+		s.Test("after the time-to-live, the stale value is served while it is refreshed in the background", func(t *testcase.T) {
+			c := subject.Get(t)
+			updated := updated.Get(t)
+			timecop.Travel(t, ttl+time.Second)
+
+			t.Must.Equal(foo.Get(t), findByID(t, c, foo.Get(t).ID))
+			t.Eventually(func(it assert.It) {
+				it.Must.Equal(updated, findByID(it, c, foo.Get(t).ID))
+			})
+		})
+
+		s.Test("cached queries are refreshed in the background as well", func(t *testcase.T) {
+			ctx, c := context.Background(), subject.Get(t)
+			_, err := iterators.Collect(c.FindAll(ctx))
+			t.Must.NoError(err)
+			updated := updated.Get(t)
+			timecop.Travel(t, ttl+time.Second)
+
+			vs, err := iterators.Collect(c.FindAll(ctx))
+			t.Must.NoError(err)
+			t.Must.Equal([]testent.Foo{foo.Get(t)}, vs)
+			t.Eventually(func(it assert.It) {
+				vs, err := iterators.Collect(c.FindAll(ctx))
+				it.Must.NoError(err)
+				it.Must.Equal([]testent.Foo{updated}, vs)
+			})
+		})
+
+		s.Test("after the hard time-to-live, the value is re-fetched synchronously", func(t *testcase.T) {
+			updated := updated.Get(t)
+			timecop.Travel(t, ttl+swr+time.Second)
+
+			t.Must.Equal(updated, findByID(t, subject.Get(t), foo.Get(t).ID))
+		})
+
+		s.Test("the background refresh keeps the logging details of the caller's context, but not its other values nor its cancellation", func(t *testcase.T) {
+			type ctxKey struct{}
+			var (
+				gotValue = make(chan any, 1)
+				gotErr   = make(chan error, 1)
+			)
+			out := logger.Stub(t)
+			c := subject.Get(t)
+			c.Source = &ctxSpySource{Repository: source.Get(t), OnFindByID: func(ctx context.Context) {
+				logger.Info(ctx, "refresh")
+				gotValue <- ctx.Value(ctxKey{})
+				gotErr <- ctx.Err()
+			}}
+			updated.Get(t)
+			timecop.Travel(t, ttl+time.Second)
+
+			ctx := context.WithValue(context.Background(), ctxKey{}, "value") // e.g. an open transaction
+			ctx = logger.ContextWith(ctx, logger.Field("request_id", "42"))
+			ctx, cancel := context.WithCancel(ctx)
+			_, _, err := c.FindByID(ctx, foo.Get(t).ID)
+			t.Must.NoError(err)
+			cancel()
+
+			t.Must.Nil(<-gotValue)
+			t.Must.NoError(<-gotErr)
+			t.Must.Contain(out.String(), `"request_id":"42"`)
+		})
+
+		s.Test("a cached query is refreshed in the background, when it is registered", func(t *testcase.T) {
+			ctx, c := context.Background(), subject.Get(t)
+			const queryKey = "all"
+			c.RegisterQuery(queryKey, func(ctx context.Context) iterators.Iterator[testent.Foo] {
+				return source.Get(t).FindAll(ctx)
+			})
+			query := func() iterators.Iterator[testent.Foo] { return source.Get(t).FindAll(ctx) }
+			_, err := iterators.Collect(c.CachedQueryMany(ctx, queryKey, query))
+			t.Must.NoError(err)
+			updated := updated.Get(t)
+			timecop.Travel(t, ttl+time.Second)
+
+			vs, err := iterators.Collect(c.CachedQueryMany(ctx, queryKey, query))
+			t.Must.NoError(err)
+			t.Must.Equal([]testent.Foo{foo.Get(t)}, vs)
+			t.Eventually(func(it assert.It) {
+				vs, err := iterators.Collect(c.CachedQueryMany(ctx, queryKey, query))
+				it.Must.NoError(err)
+				it.Must.Equal([]testent.Foo{updated}, vs)
+			})
+		})
+
+		s.Test("an unregistered cached query is re-fetched synchronously, since its query might be bound to the caller's context", func(t *testcase.T) {
+			ctx, c := context.Background(), subject.Get(t)
+			query := func() iterators.Iterator[testent.Foo] { return source.Get(t).FindAll(ctx) }
+			_, err := iterators.Collect(c.CachedQueryMany(ctx, "all", query))
+			t.Must.NoError(err)
+			updated := updated.Get(t)
+			timecop.Travel(t, ttl+time.Second)
+
+			vs, err := iterators.Collect(c.CachedQueryMany(ctx, "all", query))
+			t.Must.NoError(err)
+			t.Must.Equal([]testent.Foo{updated}, vs)
+		})
+
+		s.Test("a fresh value is served from the cache", func(t *testcase.T) {
+			c := subject.Get(t)
+			updated.Get(t)
+			timecop.Travel(t, ttl/2)
+
+			t.Must.Equal(foo.Get(t), findByID(t, c, foo.Get(t).ID))
+			t.Must.Equal(foo.Get(t), findByID(t, c, foo.Get(t).ID))
+			t.Must.Equal(0, c.Stats().Refreshes)
+		})
+	})
+
+	s.Test("after a restart, the queries which were still queued are revalidated again", func(t *testcase.T) {
+		ctx, c := context.Background(), subject.Get(t)
+		other := testent.MakeFoo(t)
+		crudtest.Create[testent.Foo, testent.FooID](t, source.Get(t), ctx, &other)
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, other.ID)
+		var (
+			entered = make(chan struct{})
+			once    sync.Once
+		)
+		c.Source = &ctxSpySource{Repository: source.Get(t), OnFindByID: func(ctx context.Context) {
+			once.Do(func() { // the first refresh keeps the worker busy until it is stopped
+				close(entered)
+				<-ctx.Done()
+			})
+		}}
+		updated.Get(t)
+		timecop.Travel(t, ttl+time.Second)
+
+		workerCTX, stop := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- c.RunRevalidation(workerCTX) }()
+		t.Eventually(func(it assert.It) {
+			it.Must.Equal(1, c.RevalidationWorkers())
+		})
+		t.Must.Equal(foo.Get(t), findByID(t, c, foo.Get(t).ID))
+		<-entered
+		t.Must.Equal(other, findByID(t, c, other.ID)) // queued behind the busy worker
+		stop()
+		t.Must.NoError(<-done)
+
+		updatedOther := other
+		updatedOther.Foo = t.Random.UUID()
+		crudtest.Update[testent.Foo, testent.FooID](t, source.Get(t), ctx, &updatedOther)
+		runRevalidation(t, c)
+
+		t.Eventually(func(it assert.It) {
+			it.Must.Equal(updatedOther, findByID(it, c, other.ID))
+		})
+	})
+
+	s.Test("without a running revalidation, the value is re-fetched synchronously", func(t *testcase.T) {
+		updated := updated.Get(t)
+		timecop.Travel(t, ttl+time.Second)
+
+		t.Must.Equal(updated, findByID(t, subject.Get(t), foo.Get(t).ID))
+	})
+}
+
+func TestCache_distributedInvalidation(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	type instance struct {
+		Cache *cache.Cache[testent.Foo, testent.FooID]
+		Repo  *memory.CacheRepository[testent.Foo, testent.FooID]
+	}
+
+	var (
+		m = testcase.Let(s, func(t *testcase.T) *memory.Memory {
+			return memory.NewMemory()
+		})
+		source = testcase.Let(s, func(t *testcase.T) *memory.Repository[testent.Foo, testent.FooID] {
+			return memory.NewRepository[testent.Foo, testent.FooID](m.Get(t))
+		})
+		exchange = testcase.Let(s, func(t *testcase.T) *memory.FanOutExchange[cache.Invalidation[testent.FooID]] {
+			return &memory.FanOutExchange[cache.Invalidation[testent.FooID]]{Memory: m.Get(t)}
+		})
+		publisher = testcase.Let(s, func(t *testcase.T) *countingPublisher[cache.Invalidation[testent.FooID]] {
+			return &countingPublisher[cache.Invalidation[testent.FooID]]{Publisher: exchange.Get(t)}
+		})
+	)
+	makeInstance := func(t *testcase.T) instance {
+		repo := memory.NewCacheRepository[testent.Foo, testent.FooID](memory.NewMemory())
+		c := cache.New[testent.Foo, testent.FooID](source.Get(t), repo)
+		c.Invalidations = publisher.Get(t)
+		c.PeerInvalidations = exchange.Get(t).MakeQueue()
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- c.RunInvalidationConsumer(ctx) }()
+		t.Cleanup(func() {
+			cancel()
+			t.Must.NoError(<-done)
+		})
+		return instance{Cache: c, Repo: repo}
+	}
+	var (
+		a = testcase.Let(s, makeInstance)
+		b = testcase.Let(s, makeInstance)
+	)
+	foo := testcase.Let(s, func(t *testcase.T) testent.Foo {
+		foo := testent.MakeFoo(t)
+		crudtest.Create[testent.Foo, testent.FooID](t, source.Get(t), context.Background(), &foo)
+		return foo
+	}).EagerLoading(s)
+	isCached := func(tb testing.TB, i instance, id testent.FooID) bool {
+		_, found, err := i.Repo.Entities().FindByID(context.Background(), id)
+		assert.NoError(tb, err)
+		return found
+	}
+
+	s.Test("an update on one instance invalidates the entity in the peers", func(t *testcase.T) {
+		ctx := context.Background()
+		foo := foo.Get(t)
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, a.Get(t).Cache, ctx, foo.ID)
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, b.Get(t).Cache, ctx, foo.ID)
+
+		foo.Foo = t.Random.UUID()
+		t.Must.NoError(a.Get(t).Cache.Update(ctx, &foo))
+
+		t.Eventually(func(it assert.It) {
+			it.Must.False(isCached(it, b.Get(t), foo.ID))
+		})
+		got, found, err := b.Get(t).Cache.FindByID(ctx, foo.ID)
+		t.Must.NoError(err)
+		t.Must.True(found)
+		t.Must.Equal(foo, got)
+		t.Must.True(isCached(t, a.Get(t), foo.ID), "the instance should ignore its own invalidation")
+		t.Must.Equal(1, publisher.Get(t).Count(), "applying a peer's invalidation should not be published again")
+	})
+
+	s.Test("InvalidateCachedQuery is applied on the peers", func(t *testcase.T) {
+		ctx := context.Background()
+		const queryKey = "query"
+		query := func() (testent.Foo, bool, error) { return source.Get(t).FindByID(ctx, foo.Get(t).ID) }
+		for _, i := range []instance{a.Get(t), b.Get(t)} {
+			_, _, err := i.Cache.CachedQueryOne(ctx, queryKey, query)
+			t.Must.NoError(err)
+		}
+
+		t.Must.NoError(a.Get(t).Cache.InvalidateCachedQuery(ctx, queryKey))
+
+		t.Eventually(func(it assert.It) {
+			_, found, err := b.Get(t).Repo.Hits().FindByID(ctx, queryKey)
+			it.Must.NoError(err)
+			it.Must.False(found)
+		})
+		t.Must.Equal(1, publisher.Get(t).Count())
+	})
+
+	s.Test("DropCachedValues is applied on the peers", func(t *testcase.T) {
+		ctx := context.Background()
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, b.Get(t).Cache, ctx, foo.Get(t).ID)
+
+		t.Must.NoError(a.Get(t).Cache.DropCachedValues(ctx))
+
+		t.Eventually(func(it assert.It) {
+			it.Must.False(isCached(it, b.Get(t), foo.Get(t).ID))
+		})
+		t.Must.Equal(1, publisher.Get(t).Count())
+	})
+
+	s.Test("nested invalidations are published as a single event", func(t *testcase.T) {
+		ctx := context.Background()
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, a.Get(t).Cache, ctx, foo.Get(t).ID)
+
+		t.Must.NoError(a.Get(t).Cache.InvalidateByID(ctx, foo.Get(t).ID))
+		t.Must.Equal(1, publisher.Get(t).Count())
+	})
+
+	s.Test("a create on one instance invalidates the cached queries of the peers", func(t *testcase.T) {
+		ctx := context.Background()
+		newFoo := testent.MakeFoo(t)
+		newFoo.ID = testent.FooID(t.Random.UUID())
+		_, found, err := b.Get(t).Cache.FindByID(ctx, newFoo.ID)
+		t.Must.NoError(err)
+		t.Must.False(found)
+		vs, err := iterators.Collect(b.Get(t).Cache.FindAll(ctx))
+		t.Must.NoError(err)
+		t.Must.ContainExactly([]testent.Foo{foo.Get(t)}, vs)
+
+		t.Must.NoError(a.Get(t).Cache.Create(ctx, &newFoo))
+
+		t.Eventually(func(it assert.It) {
+			got, found, err := b.Get(t).Cache.FindByID(ctx, newFoo.ID)
+			it.Must.NoError(err)
+			it.Must.True(found)
+			it.Must.Equal(newFoo, got)
+		})
+		vs, err = iterators.Collect(b.Get(t).Cache.FindAll(ctx))
+		t.Must.NoError(err)
+		t.Must.ContainExactly([]testent.Foo{foo.Get(t), newFoo}, vs)
+		t.Must.Equal(1, publisher.Get(t).Count())
+	})
+
+	s.Test("a create invalidates the cached FindAll of the instance as well", func(t *testcase.T) {
+		ctx := context.Background()
+		vs, err := iterators.Collect(a.Get(t).Cache.FindAll(ctx))
+		t.Must.NoError(err)
+		t.Must.ContainExactly([]testent.Foo{foo.Get(t)}, vs)
+
+		newFoo := testent.MakeFoo(t)
+		t.Must.NoError(a.Get(t).Cache.Create(ctx, &newFoo))
+
+		vs, err = iterators.Collect(a.Get(t).Cache.FindAll(ctx))
+		t.Must.NoError(err)
+		t.Must.ContainExactly([]testent.Foo{foo.Get(t), newFoo}, vs)
+	})
+
+	s.Context("when the publishing fails", func(s *testcase.Spec) {
+		publisher.Let(s, func(t *testcase.T) *countingPublisher[cache.Invalidation[testent.FooID]] {
+			return &countingPublisher[cache.Invalidation[testent.FooID]]{Publisher: exchange.Get(t), Err: t.Random.Error()}
+		})
+
+		s.Test("the local changes are still reported as successful", func(t *testcase.T) {
+			ctx := context.Background()
+			c := a.Get(t).Cache
+			crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo.Get(t).ID)
+			t.Must.NoError(c.InvalidateByID(ctx, foo.Get(t).ID))
+			t.Must.False(isCached(t, a.Get(t), foo.Get(t).ID))
+			t.Must.NoError(c.InvalidateCachedQuery(ctx, "query"))
+			t.Must.NoError(c.DropCachedValues(ctx))
+
+			newFoo := testent.MakeFoo(t)
+			t.Must.NoError(c.Create(ctx, &newFoo))
+			newFoo.Foo = t.Random.UUID()
+			t.Must.NoError(c.Update(ctx, &newFoo))
+			t.Must.NoError(c.DeleteByID(ctx, newFoo.ID))
+			t.Must.NoError(c.DeleteAll(ctx))
+			crudtest.IsAbsent[testent.Foo, testent.FooID](t, source.Get(t), ctx, foo.Get(t).ID)
+		})
+	})
+
+	s.Test("RunInvalidationConsumer requires a PeerInvalidations", func(t *testcase.T) {
+		c := cache.New[testent.Foo, testent.FooID](source.Get(t),
+			memory.NewCacheRepository[testent.Foo, testent.FooID](memory.NewMemory()))
+		t.Must.Error(c.RunInvalidationConsumer(context.Background()))
+	})
+}
+
+type countingPublisher[Data any] struct {
+	Publisher pubsub.Publisher[Data]
+	// Err is returned by Publish instead of publishing, when it is set.
+	Err   error
+	count int32
+}
+
+func (p *countingPublisher[Data]) Publish(ctx context.Context, vs ...Data) error {
+	atomic.AddInt32(&p.count, int32(len(vs)))
+	if p.Err != nil {
+		return p.Err
+	}
+	return p.Publisher.Publish(ctx, vs...)
+}
+
+func (p *countingPublisher[Data]) Count() int {
+	return int(atomic.LoadInt32(&p.count))
+}
+
+func TestCache_Stats(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		source = testcase.Let(s, func(t *testcase.T) *memory.Repository[testent.Foo, testent.FooID] {
+			return memory.NewRepository[testent.Foo, testent.FooID](memory.NewMemory())
+		})
+		cacheSource = testcase.Let(s, func(t *testcase.T) cache.Source[testent.Foo, testent.FooID] {
+			return source.Get(t)
+		})
+		cacheRepository = testcase.Let(s, func(t *testcase.T) cache.Repository[testent.Foo, testent.FooID] {
+			return memory.NewCacheRepository[testent.Foo, testent.FooID](memory.NewMemory())
+		})
+		subject = testcase.Let(s, func(t *testcase.T) *cache.Cache[testent.Foo, testent.FooID] {
+			return cache.New[testent.Foo, testent.FooID](cacheSource.Get(t), cacheRepository.Get(t))
+		})
+		makeFoo = func(t *testcase.T) testent.Foo {
+			foo := testent.MakeFoo(t)
+			crudtest.Create[testent.Foo, testent.FooID](t, source.Get(t), context.Background(), &foo)
+			return foo
+		}
+		foo1 = testcase.Let(s, makeFoo).EagerLoading(s)
+		foo2 = testcase.Let(s, makeFoo).EagerLoading(s)
+	)
+
+	s.Test("hits, misses and source calls are counted", func(t *testcase.T) {
+		ctx, c := context.Background(), subject.Get(t)
+		for i := 0; i < 3; i++ {
+			crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo1.Get(t).ID)
+		}
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo2.Get(t).ID)
+
+		stats := c.Stats()
+		t.Must.Equal(2, stats.Hits)
+		t.Must.Equal(2, stats.Misses)
+		t.Must.Equal(0.5, stats.HitRatio)
+		t.Must.Equal(2, stats.SourceCalls)
+	})
+
+	s.Context("when the Source takes time to respond", func(s *testcase.Spec) {
+		cacheSource.Let(s, func(t *testcase.T) cache.Source[testent.Foo, testent.FooID] {
+			return &latencySource{Repository: source.Get(t), TB: t, Latencies: []time.Duration{time.Second, 3 * time.Second}}
+		})
+
+		s.Test("the source latency is measured with the clock", func(t *testcase.T) {
+			ctx, c := context.Background(), subject.Get(t)
+			timecop.Travel(t, clock.TimeNow(), timecop.Freeze())
+			crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo1.Get(t).ID)
+			crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo2.Get(t).ID)
+
+			stats := c.Stats()
+			t.Must.Equal(3*time.Second, stats.SourceLatencyMax)
+			t.Must.Equal(2*time.Second, stats.SourceLatencyAverage)
+		})
+	})
+
+	s.Test("the hottest query keys are listed in descending order", func(t *testcase.T) {
+		ctx, c := context.Background(), subject.Get(t)
+		for i := 0; i < 3; i++ {
+			crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo2.Get(t).ID)
+		}
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo1.Get(t).ID)
+
+		hottest := c.HottestQueries(1)
+		t.Must.Equal(1, len(hottest))
+		t.Must.Contain(hottest[0].QueryKey, string(foo2.Get(t).ID))
+		t.Must.Equal(2, hottest[0].Hits)
+		t.Must.Equal(1, hottest[0].Misses)
+		t.Must.Equal(2, len(c.Stats().HottestQueries))
+	})
+
+	s.Context("when the Repository evicts cached values", func(s *testcase.Spec) {
+		cacheRepository.Let(s, func(t *testcase.T) cache.Repository[testent.Foo, testent.FooID] {
+			return memory.NewBoundedCacheRepository[testent.Foo, testent.FooID](memory.NewMemory(), 2)
+		})
+
+		s.Test("evictions are reported", func(t *testcase.T) {
+			ctx, c := context.Background(), subject.Get(t)
+			for _, foo := range []testent.Foo{foo1.Get(t), foo2.Get(t), makeFoo(t)} {
+				crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo.ID)
+			}
+			t.Must.True(0 < c.Stats().Evictions)
+		})
+	})
+
+	s.Test("refreshes are counted", func(t *testcase.T) {
+		ctx, c := context.Background(), subject.Get(t)
+		c.TimeToLive = time.Minute
+		c.StaleWhileRevalidate = time.Hour
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo1.Get(t).ID)
+		runRevalidation(t, c)
+		timecop.Travel(t, 2*time.Minute)
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo1.Get(t).ID)
+
+		t.Eventually(func(it assert.It) {
+			it.Must.Equal(1, c.Stats().Refreshes)
+		})
+	})
+
+	s.Test("the stats are reported as a health metric", func(t *testcase.T) {
+		ctx, c := context.Background(), subject.Get(t)
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo1.Get(t).ID)
+		monitor := health.Monitor{Metrics: health.MonitorMetrics{"foo-cache": c.Metric}}
+
+		report := monitor.HealthCheck(ctx)
+		t.Must.Empty(report.Issues)
+		stats, ok := report.Metrics["foo-cache"].(cache.Stats)
+		t.Must.True(ok)
+		t.Must.Equal(1, stats.Misses)
+	})
+
+	s.Test("the stats can be logged as a field", func(t *testcase.T) {
+		ctx, c := context.Background(), subject.Get(t)
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo1.Get(t).ID)
+		out := logger.Stub(t)
+
+		logger.Info(ctx, "cache stats", logger.Field("cache", c.Stats()))
+		t.Must.Contain(out.String(), `"misses":1`)
+		t.Must.Contain(out.String(), `"source_calls":1`)
+	})
+}
+
+// ctxSpySource calls OnFindByID with the context of each FindByID.
+type ctxSpySource struct {
+	*memory.Repository[testent.Foo, testent.FooID]
+	OnFindByID func(ctx context.Context)
+}
+
+func (s *ctxSpySource) FindByID(ctx context.Context, id testent.FooID) (testent.Foo, bool, error) {
+	s.OnFindByID(ctx)
+	return s.Repository.FindByID(ctx, id)
+}
+
+// latencySource travels in time during each FindByID by the next duration of the Latencies.
+type latencySource struct {
+	*memory.Repository[testent.Foo, testent.FooID]
+	TB        testing.TB
+	Latencies []time.Duration
+}
+
+func (s *latencySource) FindByID(ctx context.Context, id testent.FooID) (testent.Foo, bool, error) {
+	if 0 < len(s.Latencies) {
+		timecop.Travel(s.TB, s.Latencies[0], timecop.Freeze())
+		s.Latencies = s.Latencies[1:]
+	}
+	return s.Repository.FindByID(ctx, id)
+}
+
+// runRevalidation runs the background refresh of the Cache until the end of the test.
+func runRevalidation(t *testcase.T, c *cache.Cache[testent.Foo, testent.FooID]) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.RunRevalidation(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		t.Must.NoError(<-done)
+	})
+	t.Eventually(func(it assert.It) {
+		it.Must.Equal(1, c.RevalidationWorkers())
+	})
+}
+
+func TestCache_WarmUp(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		source = testcase.Let(s, func(t *testcase.T) *spySource {
+			return &spySource{Repository: memory.NewRepository[testent.Foo, testent.FooID](memory.NewMemory())}
+		})
+		cacheRepository = testcase.Let(s, func(t *testcase.T) *spyCacheRepository {
+			return &spyCacheRepository{CacheRepository: memory.NewCacheRepository[testent.Foo, testent.FooID](memory.NewMemory())}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) *cache.Cache[testent.Foo, testent.FooID] {
+			return cache.New[testent.Foo, testent.FooID](source.Get(t), cacheRepository.Get(t))
+		})
+		length = testcase.LetValue(s, 3)
+		foos   = testcase.Let(s, func(t *testcase.T) []testent.Foo {
+			var foos []testent.Foo
+			for i := 0; i < length.Get(t); i++ {
+				foo := testent.MakeFoo(t)
+				crudtest.Create[testent.Foo, testent.FooID](t, source.Get(t).Repository, context.Background(), &foo)
+				foos = append(foos, foo)
+			}
+			return foos
+		}).EagerLoading(s)
+	)
+
+	s.Context("with many entities in the Source", func(s *testcase.Spec) {
+		length.LetValue(s, 7)
+
+		s.Test("All preloads every entity from the Source", func(t *testcase.T) {
+			ctx := context.Background()
+			c := subject.Get(t)
+			c.TimeToLive = time.Hour
+			t.Must.NoError(c.WarmUp(cache.WarmUp[testent.FooID]{All: true, BatchSize: 2, Concurrency: 3})(ctx))
+			calls := source.Get(t).Calls()
+
+			for _, foo := range foos.Get(t) {
+				crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo.ID)
+			}
+			vs, err := iterators.Collect(c.FindAll(ctx))
+			t.Must.NoError(err)
+			t.Must.ContainExactly(foos.Get(t), vs)
+			t.Must.Equal(calls, source.Get(t).Calls(), "no source call was expected after the warm-up")
+		})
+
+		s.Test("All keeps the order of the Source in the cached FindAll, regardless of the concurrency", func(t *testcase.T) {
+			ctx := context.Background()
+			c := subject.Get(t)
+			t.Must.NoError(c.WarmUp(cache.WarmUp[testent.FooID]{All: true, BatchSize: 1, Concurrency: 7})(ctx))
+
+			var expected []testent.FooID
+			for _, foo := range foos.Get(t) {
+				expected = append(expected, foo.ID)
+			}
+			sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+
+			hit, found, err := c.Repository.Hits().FindByID(ctx, cache.QueryKey{ID: "FindAll"}.Encode())
+			t.Must.NoError(err)
+			t.Must.True(found)
+			t.Must.Equal(expected, hit.EntityIDs)
+		})
+
+		s.Test("All stores each batch with a single upsert", func(t *testcase.T) {
+			t.Must.NoError(subject.Get(t).WarmUp(cache.WarmUp[testent.FooID]{All: true, BatchSize: 2})(context.Background()))
+			t.Must.Equal(4, cacheRepository.Get(t).UpsertCalls())
+		})
+
+		s.Test("IDs are fetched in batches, when the Source supports FindByIDs", func(t *testcase.T) {
+			ctx := context.Background()
+			c := subject.Get(t)
+			var ids []testent.FooID
+			for _, foo := range foos.Get(t) {
+				ids = append(ids, foo.ID)
+			}
+			t.Must.NoError(c.WarmUp(cache.WarmUp[testent.FooID]{IDs: ids, BatchSize: 4})(ctx))
+			t.Must.Equal(2, source.Get(t).FindByIDsCalls())
+			t.Must.Equal(0, source.Get(t).Calls())
+			t.Must.Equal(2, cacheRepository.Get(t).UpsertCalls(), "each batch was expected to be stored with a single upsert")
+
+			for _, foo := range foos.Get(t) {
+				crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo.ID)
+			}
+			t.Must.Equal(0, source.Get(t).Calls(), "no source call was expected after the warm-up")
+		})
+	})
+
+	s.Test("IDs which are absent from the Source are preloaded as absent", func(t *testcase.T) {
+		ctx := context.Background()
+		c := subject.Get(t)
+		absentID := testent.FooID(t.Random.UUID())
+		t.Must.NoError(c.WarmUp(cache.WarmUp[testent.FooID]{IDs: []testent.FooID{foos.Get(t)[0].ID, absentID}})(ctx))
+		calls := source.Get(t).Calls()
+
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foos.Get(t)[0].ID)
+		_, found, err := c.FindByID(ctx, absentID)
+		t.Must.NoError(err)
+		t.Must.False(found)
+		t.Must.Equal(calls, source.Get(t).Calls())
+	})
+
+	s.Test("IDs preloads the listed entities", func(t *testcase.T) {
+		ctx := context.Background()
+		c, foos := subject.Get(t), foos.Get(t)
+		t.Must.NoError(c.WarmUp(cache.WarmUp[testent.FooID]{IDs: []testent.FooID{foos[0].ID, foos[1].ID}})(ctx))
+		calls := source.Get(t).Calls()
+
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foos[0].ID)
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foos[1].ID)
+		t.Must.Equal(calls, source.Get(t).Calls())
+		crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foos[2].ID)
+		t.Must.Equal(calls+1, source.Get(t).Calls())
+	})
+
+	s.Test("QueryKeys preloads the registered queries", func(t *testcase.T) {
+		ctx := context.Background()
+		c := subject.Get(t)
+		var queryCalls int32
+		c.RegisterQuery("all", func(ctx context.Context) iterators.Iterator[testent.Foo] {
+			atomic.AddInt32(&queryCalls, 1)
+			return source.Get(t).FindAll(ctx)
+		})
+		t.Must.NoError(c.WarmUp(cache.WarmUp[testent.FooID]{QueryKeys: []cache.HitID{"all"}})(ctx))
+
+		vs, err := iterators.Collect(c.CachedQueryMany(ctx, "all", func() iterators.Iterator[testent.Foo] {
+			t.Fatal("the query was expected to be preloaded")
+			return nil
+		}))
+		t.Must.NoError(err)
+		t.Must.ContainExactly(foos.Get(t), vs)
+		t.Must.Equal(int32(1), atomic.LoadInt32(&queryCalls))
+	})
+
+	s.Test("an unregistered query key fails the warm-up", func(t *testcase.T) {
+		t.Must.Error(subject.Get(t).WarmUp(cache.WarmUp[testent.FooID]{QueryKeys: []cache.HitID{"unknown"}})(context.Background()))
+	})
+
+	s.Test("the readiness check reports not ready until the warm-up succeeds", func(t *testcase.T) {
+		ctx := context.Background()
+		c := subject.Get(t)
+		monitor := health.Monitor{Checks: health.MonitorChecks{c.ReadinessCheck}}
+		t.Must.NoError(c.ReadinessCheck(ctx))
+
+		task := c.WarmUp(cache.WarmUp[testent.FooID]{All: true})
+		t.Must.Error(c.ReadinessCheck(ctx))
+		t.Must.NotEqual(health.Up, monitor.HealthCheck(ctx).Status)
+		t.Must.Equal(0, source.Get(t).Calls(), "the readiness check was not expected to start a warm-up")
+
+		t.Must.NoError(task(ctx))
+		t.Must.NoError(c.ReadinessCheck(ctx))
+		t.Must.Equal(health.Up, monitor.HealthCheck(ctx).Status)
+	})
+
+	s.When("the warm-up fails", func(s *testcase.Spec) {
+		expErr := testcase.Let(s, func(t *testcase.T) error { return t.Random.Error() })
+		task := testcase.Let(s, func(t *testcase.T) tasker.Task {
+			task := subject.Get(t).WarmUp(cache.WarmUp[testent.FooID]{All: true})
+			source.Get(t).Err = expErr.Get(t)
+			t.Must.ErrorIs(expErr.Get(t), task(context.Background()))
+			return task
+		}).EagerLoading(s)
+
+		s.Then("the readiness check reports the error of the failed warm-up", func(t *testcase.T) {
+			err := subject.Get(t).ReadinessCheck(context.Background())
+			t.Must.Error(err)
+			t.Must.Contain(err.Error(), expErr.Get(t).Error())
+		})
+
+		s.Then("the readiness check retries the failed warm-up", func(t *testcase.T) {
+			ctx := context.Background()
+			c := subject.Get(t)
+			t.Must.Error(c.ReadinessCheck(ctx))
+
+			source.Get(t).Err = nil
+			t.Must.NoError(c.ReadinessCheck(ctx))
+			for _, foo := range foos.Get(t) {
+				crudtest.IsPresent[testent.Foo, testent.FooID](t, c, ctx, foo.ID)
+			}
+		})
+
+		s.Then("rerunning the warm-up task makes the cache ready", func(t *testcase.T) {
+			ctx := context.Background()
+			source.Get(t).Err = nil
+			t.Must.NoError(task.Get(t)(ctx))
+			t.Must.NoError(subject.Get(t).ReadinessCheck(ctx))
+		})
+
+		s.Then("a once succeeded warm-up keeps the cache ready, even if a later run fails", func(t *testcase.T) {
+			ctx := context.Background()
+			source.Get(t).Err = nil
+			t.Must.NoError(task.Get(t)(ctx))
+
+			source.Get(t).Err = expErr.Get(t)
+			t.Must.ErrorIs(expErr.Get(t), task.Get(t)(ctx))
+			t.Must.NoError(subject.Get(t).ReadinessCheck(ctx))
+		})
+	})
+}
+
+// spyCacheRepository counts the upsert calls of the cached entities.
+type spyCacheRepository struct {
+	*memory.CacheRepository[testent.Foo, testent.FooID]
+	upsertCalls int32
+}
+
+func (r *spyCacheRepository) UpsertCalls() int { return int(atomic.LoadInt32(&r.upsertCalls)) }
+
+func (r *spyCacheRepository) Entities() cache.EntityRepository[testent.Foo, testent.FooID] {
+	return spyCacheEntityRepository{EntityRepository: r.CacheRepository.Entities(), calls: &r.upsertCalls}
+}
+
+type spyCacheEntityRepository struct {
+	cache.EntityRepository[testent.Foo, testent.FooID]
+	calls *int32
+}
+
+func (r spyCacheEntityRepository) Upsert(ctx context.Context, ptrs ...*testent.Foo) error {
+	atomic.AddInt32(r.calls, 1)
+	return r.EntityRepository.Upsert(ctx, ptrs...)
+}
+
+// spySource counts the calls of the Source, and returns the entities of the FindAll in the order of their ID.
+type spySource struct {
+	*memory.Repository[testent.Foo, testent.FooID]
+	Err   error
+	calls int32
+
+	findByIDsCalls int32
+}
+
+func (s *spySource) Calls() int { return int(atomic.LoadInt32(&s.calls)) }
+
+func (s *spySource) FindByIDsCalls() int { return int(atomic.LoadInt32(&s.findByIDsCalls)) }
+
+func (s *spySource) FindByIDs(ctx context.Context, ids ...testent.FooID) iterators.Iterator[testent.Foo] {
+	atomic.AddInt32(&s.findByIDsCalls, 1)
+	if s.Err != nil {
+		return iterators.Error[testent.Foo](s.Err)
+	}
+	return s.Repository.FindByIDs(ctx, ids...)
+}
+
+func (s *spySource) FindByID(ctx context.Context, id testent.FooID) (testent.Foo, bool, error) {
+	atomic.AddInt32(&s.calls, 1)
+	if s.Err != nil {
+		return testent.Foo{}, false, s.Err
+	}
+	return s.Repository.FindByID(ctx, id)
+}
+
+func (s *spySource) FindAll(ctx context.Context) iterators.Iterator[testent.Foo] {
+	atomic.AddInt32(&s.calls, 1)
+	if s.Err != nil {
+		return iterators.Error[testent.Foo](s.Err)
+	}
+	vs, err := iterators.Collect(s.Repository.FindAll(ctx))
+	if err != nil {
+		return iterators.Error[testent.Foo](err)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].ID < vs[j].ID })
+	return iterators.Slice(vs)
+}
+
+type blockingFindByIDSource struct {
+	*memory.Repository[testent.Foo, testent.FooID]
+	Calls   *int32
+	Release <-chan struct{}
+}
+
+func (s *blockingFindByIDSource) FindByID(ctx context.Context, id testent.FooID) (testent.Foo, bool, error) {
+	atomic.AddInt32(s.Calls, 1)
+	<-s.Release
+	return s.Repository.FindByID(ctx, id)
+}
+
 func NewFaultyCacheRepository[Entity, ID any](FailurePercentage float64) *FaultyCacheRepository[Entity, ID] {
 	m := memory.NewMemory()
 	return &FaultyCacheRepository[Entity, ID]{
@@ -18,6 +18,8 @@ import (
 	sh "go.llib.dev/frameless/spechelper"
 	"go.llib.dev/testcase"
 	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/clock"
+	"go.llib.dev/testcase/clock/timecop"
 )
 
 var (
@@ -160,6 +162,7 @@ func (c Cache[Entity, ID]) Spec(s *testcase.Spec) {
 	s.Describe(".InvalidateCachedQuery", c.specInvalidateCachedQuery)
 	s.Describe(".InvalidateByID", c.specInvalidateByID)
 	s.Describe(".CachedQueryMany", c.specCachedQueryMany)
+	s.Describe(".TimeToLive", c.specTimeToLive)
 
 	s.Context(``, func(s *testcase.Spec) {
 		c.describeResultCaching(s)
@@ -947,3 +950,119 @@ func (c Cache[Entity, ID]) specInvalidateByID(s *testcase.Spec) {
 		})
 	})
 }
+
+func (c Cache[Entity, ID]) specTimeToLive(s *testcase.Spec) {
+	var (
+		ttl = testcase.Let(s, func(t *testcase.T) time.Duration {
+			return time.Duration(t.Random.IntB(10, 60)) * time.Minute
+		})
+		subject = testcase.Let(s, func(t *testcase.T) *cache.Cache[Entity, ID] {
+			ch := c.cache().Get(t)
+			ch.TimeToLive = ttl.Get(t)
+			return ch
+		})
+		value = testcase.Let(s, func(t *testcase.T) *Entity {
+			ptr := pointer.Of(c.subject().Get(t).MakeEntity())
+			crudtest.Create[Entity, ID](t, c.source().Get(t), c.subject().Get(t).MakeContext(), ptr)
+			id := crudtest.HasID[Entity, ID](t, *ptr)
+			t.Defer(c.source().Get(t).DeleteByID, c.subject().Get(t).MakeContext(), id)
+			return ptr
+		})
+	)
+	s.Before(func(t *testcase.T) {
+		t.Must.NoError(subject.Get(t).DropCachedValues(c.subject().Get(t).MakeContext()))
+	})
+
+	// changeInSource alters the entity in the source, without the cache being aware of it.
+	changeInSource := func(t *testcase.T) *Entity {
+		id := crudtest.HasID[Entity, ID](t, *value.Get(t))
+		nv := pointer.Of(c.subject().Get(t).MakeEntity())
+		t.Must.NoError(extid.Set(nv, id))
+		crudtest.Update[Entity, ID](t, c.source().Get(t), c.subject().Get(t).MakeContext(), nv)
+		return nv
+	}
+
+	s.Then("the cached entity is served until its time-to-live is reached, then it is re-fetched from the source", func(t *testcase.T) {
+		ctx := c.subject().Get(t).MakeContext()
+		id := crudtest.HasID[Entity, ID](t, *value.Get(t))
+		crudtest.IsPresent[Entity, ID](t, subject.Get(t), ctx, id) // should trigger caching
+		updated := changeInSource(t)
+
+		timecop.Travel(t, ttl.Get(t)/2)
+		got, found, err := subject.Get(t).FindByID(ctx, id)
+		t.Must.NoError(err)
+		t.Must.True(found)
+		t.Must.Equal(*value.Get(t), got, "fresh cached value was expected")
+
+		timecop.Travel(t, ttl.Get(t))
+		got, found, err = subject.Get(t).FindByID(ctx, id)
+		t.Must.NoError(err)
+		t.Must.True(found)
+		t.Must.Equal(*updated, got, "the expired value was expected to be re-fetched")
+	})
+
+	s.Then("the query of an expired hit is executed again", func(t *testcase.T) {
+		var (
+			ctx      = c.subject().Get(t).MakeContext()
+			queryKey = t.Random.UUID()
+			calls    int
+		)
+		query := func() iterators.Iterator[Entity] {
+			calls++
+			return iterators.SingleValue(*value.Get(t))
+		}
+		_, err := iterators.Collect(subject.Get(t).CachedQueryMany(ctx, queryKey, query))
+		t.Must.NoError(err)
+		_, err = iterators.Collect(subject.Get(t).CachedQueryMany(ctx, queryKey, query))
+		t.Must.NoError(err)
+		t.Must.Equal(1, calls)
+
+		timecop.Travel(t, ttl.Get(t)+time.Second)
+		vs, err := iterators.Collect(subject.Get(t).CachedQueryMany(ctx, queryKey, query))
+		t.Must.NoError(err)
+		t.Must.Equal([]Entity{*value.Get(t)}, vs)
+		t.Must.Equal(2, calls)
+
+		hit, found, err := c.subject().Get(t).Repository.Hits().FindByID(ctx, queryKey)
+		t.Must.NoError(err)
+		t.Must.True(found)
+		t.Must.True(clock.TimeNow().Sub(hit.Timestamp) < subject.Get(t).TimeToLive,
+			"the refreshed hit was expected to be fresh")
+	})
+
+	s.Then("a query specific time-to-live overrides the cache's time-to-live", func(t *testcase.T) {
+		var (
+			ctx      = cache.ContextWithTimeToLive(c.subject().Get(t).MakeContext(), time.Second)
+			queryKey = t.Random.UUID()
+			calls    int
+		)
+		query := func() (Entity, bool, error) {
+			calls++
+			return *value.Get(t), true, nil
+		}
+		_, _, err := subject.Get(t).CachedQueryOne(ctx, queryKey, query)
+		t.Must.NoError(err)
+
+		timecop.Travel(t, 2*time.Second)
+		_, _, err = subject.Get(t).CachedQueryOne(ctx, queryKey, query)
+		t.Must.NoError(err)
+		t.Must.Equal(2, calls)
+	})
+
+	s.When("time-to-live is not set", func(s *testcase.Spec) {
+		ttl.LetValue(s, 0)
+
+		s.Then("cached values never expire", func(t *testcase.T) {
+			ctx := c.subject().Get(t).MakeContext()
+			id := crudtest.HasID[Entity, ID](t, *value.Get(t))
+			crudtest.IsPresent[Entity, ID](t, subject.Get(t), ctx, id) // should trigger caching
+			changeInSource(t)
+
+			timecop.Travel(t, 24*365*time.Hour)
+			got, found, err := subject.Get(t).FindByID(ctx, id)
+			t.Must.NoError(err)
+			t.Must.True(found)
+			t.Must.Equal(*value.Get(t), got)
+		})
+	})
+}
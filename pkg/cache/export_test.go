@@ -0,0 +1,15 @@
+package cache
+
+// RevalidationWorkers returns the number of running RunRevalidation,
+// so the tests can wait for the background refresh to be available.
+func (m *Cache[Entity, ID]) RevalidationWorkers() int {
+	m.revalidation.mutex.Lock()
+	defer m.revalidation.mutex.Unlock()
+	return m.revalidation.workers
+}
+
+// FlightWaiters returns the number of callers waiting for a shared source call,
+// so the tests can release the source call once every caller joined it.
+func (m *Cache[Entity, ID]) FlightWaiters() int {
+	return m.flights.waiting()
+}
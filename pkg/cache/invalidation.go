@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase/random"
+)
+
+// Invalidation is the event which is published by a Cache when its cached values are invalidated,
+// to let the peer instances invalidate their own cache as well.
+type Invalidation[ID any] struct {
+	Kind InvalidationKind
+	// EntityID is the invalidated entity's ID, when the Kind is InvalidateByID.
+	EntityID ID
+	// QueryKey is the invalidated query's key, when the Kind is InvalidateCachedQuery.
+	QueryKey HitID
+	// Origin is the Cache.InstanceID of the publisher.
+	Origin string
+}
+
+type InvalidationKind string
+
+const (
+	InvalidateByID        InvalidationKind = "InvalidateByID"
+	InvalidateCachedQuery InvalidationKind = "InvalidateCachedQuery"
+	DropCachedValues      InvalidationKind = "DropCachedValues"
+)
+
+// RunInvalidationConsumer applies the invalidations of the peer instances on the local cache,
+// until the context is done.
+// The invalidations which originate from this instance are ignored.
+//
+// RunInvalidationConsumer is a tasker.Task compatible function.
+func (m *Cache[Entity, ID]) RunInvalidationConsumer(ctx context.Context) error {
+	if m.PeerInvalidations == nil {
+		return fmt.Errorf("missing cache.Cache.PeerInvalidations")
+	}
+	return pubsub.Consumer[Invalidation[ID]]{
+		Subscriber: m.PeerInvalidations,
+		Handler:    m.applyInvalidation,
+	}.Run(ctx)
+}
+
+func (m *Cache[Entity, ID]) applyInvalidation(ctx context.Context, inv Invalidation[ID]) error {
+	if inv.Origin == m.instanceID() {
+		return nil
+	}
+	switch inv.Kind {
+	case InvalidateByID:
+		return m.invalidateByID(ctx, inv.EntityID)
+	case InvalidateCachedQuery:
+		return m.invalidateCachedQuery(ctx, inv.QueryKey)
+	case DropCachedValues:
+		return m.dropCachedValues(ctx)
+	default: // retrying wouldn't help, the invalidation is possibly from a newer version of the peer.
+		logger.Warn(ctx, fmt.Sprintf("unknown cache invalidation kind: %q", inv.Kind))
+		return nil
+	}
+}
+
+// publishInvalidation shares the invalidation with the peer instances.
+// The local cache is already up to date, and the change in the Source is already done,
+// thus a failed publishing is only logged, and the peers catch up when their cached values expire.
+func (m *Cache[Entity, ID]) publishInvalidation(ctx context.Context, invs ...Invalidation[ID]) {
+	if m.Invalidations == nil || len(invs) == 0 {
+		return
+	}
+	for i := range invs {
+		invs[i].Origin = m.instanceID()
+	}
+	if err := m.Invalidations.Publish(ctx, invs...); err != nil {
+		logger.Warn(ctx, "error during publishing the cache invalidation", logger.ErrField(err))
+	}
+}
+
+func (m *Cache[Entity, ID]) instanceID() string {
+	m.instanceIDOnce.Do(func() {
+		if m.InstanceID == "" {
+			m.InstanceID = random.New(random.CryptoSeed{}).UUID()
+		}
+	})
+	return m.InstanceID
+}
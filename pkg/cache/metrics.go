@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.llib.dev/frameless/pkg/devops/health"
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/testcase/clock"
+)
+
+// hottestQueriesLimit is the number of query keys listed in the Stats.HottestQueries.
+const hottestQueriesLimit = 10
+
+// trackedQueriesLimit is the maximum number of query keys which usage is counted.
+// When the limit is reached, the least used query key is dropped in favour of a new one.
+const trackedQueriesLimit = 1024
+
+// Stats describe the effectiveness of a Cache.
+type Stats struct {
+	// Hits is the number of queries served from the cache, including the stale ones.
+	Hits int `json:"hits"`
+	// Misses is the number of queries which had to be fetched from the Source.
+	Misses int `json:"misses"`
+	// HitRatio is the ratio of the Hits to all the queries.
+	HitRatio float64 `json:"hit_ratio"`
+	// Refreshes is the number of stale queries refreshed in the background.
+	Refreshes int `json:"refreshes"`
+	// Evictions is the number of cached values evicted by the Repository,
+	// when the Repository implements EvictionCounter.
+	Evictions int `json:"evictions"`
+	// SourceCalls is the number of queries executed against the Source.
+	SourceCalls int `json:"source_calls"`
+	// SourceLatencyAverage is the average duration of the queries executed against the Source.
+	SourceLatencyAverage time.Duration `json:"source_latency_average"`
+	// SourceLatencyMax is the longest duration of a query executed against the Source.
+	SourceLatencyMax time.Duration `json:"source_latency_max"`
+	// HottestQueries lists the most used query keys, in descending order.
+	HottestQueries []QueryStats `json:"hottest_queries,omitempty"`
+}
+
+// QueryStats describe the usage of a cached query.
+type QueryStats struct {
+	QueryKey HitID `json:"query_key"`
+	Hits     int   `json:"hits"`
+	Misses   int   `json:"misses"`
+}
+
+// EvictionCounter is an optional interface for the Repository implementations,
+// which evict cached values on their own.
+type EvictionCounter interface {
+	// EvictionCount returns the number of cached values evicted so far.
+	EvictionCount() int
+}
+
+var _ = logger.RegisterFieldType(func(s Stats) logger.LoggingDetail {
+	return logger.Fields{
+		"hits":                   s.Hits,
+		"misses":                 s.Misses,
+		"hit_ratio":              s.HitRatio,
+		"refreshes":              s.Refreshes,
+		"evictions":              s.Evictions,
+		"source_calls":           s.SourceCalls,
+		"source_latency_average": s.SourceLatencyAverage.String(),
+		"source_latency_max":     s.SourceLatencyMax.String(),
+	}
+})
+
+// Stats returns the current statistics of the Cache.
+// Stats can be used as a logging field with logger.Field.
+func (m *Cache[Entity, ID]) Stats() Stats {
+	return m.metrics.Stats(m.Repository)
+}
+
+// Metric is a health.Metric compatible function, which reports the Cache's Stats.
+//
+//	health.Monitor{Metrics: health.MonitorMetrics{"foo-cache": fooCache.Metric}}
+func (m *Cache[Entity, ID]) Metric(ctx context.Context) (any, error) {
+	return m.Stats(), nil
+}
+
+var _ health.Metric = (&Cache[struct{}, string]{}).Metric
+
+// HottestQueries returns the n most used query keys, in descending order.
+func (m *Cache[Entity, ID]) HottestQueries(n int) []QueryStats {
+	return m.metrics.HottestQueries(n)
+}
+
+type metrics struct {
+	hits          atomic.Int64
+	misses        atomic.Int64
+	refreshes     atomic.Int64
+	sourceCalls   atomic.Int64
+	sourceLatency atomic.Int64
+	sourceMax     atomic.Int64
+
+	mutex   sync.Mutex
+	queries map[HitID]*QueryStats
+}
+
+func (ms *metrics) Hit(queryKey HitID) {
+	ms.hits.Add(1)
+	ms.query(queryKey, func(qs *QueryStats) { qs.Hits++ })
+}
+
+func (ms *metrics) Miss(queryKey HitID) {
+	ms.misses.Add(1)
+	ms.query(queryKey, func(qs *QueryStats) { qs.Misses++ })
+}
+
+func (ms *metrics) Refresh() {
+	ms.refreshes.Add(1)
+}
+
+// SourceCall measures the duration of a query execution against the Source.
+func (ms *metrics) SourceCall(begin time.Time) {
+	latency := int64(clock.TimeNow().Sub(begin))
+	ms.sourceCalls.Add(1)
+	ms.sourceLatency.Add(latency)
+	for {
+		max := ms.sourceMax.Load()
+		if latency <= max || ms.sourceMax.CompareAndSwap(max, latency) {
+			break
+		}
+	}
+}
+
+func (ms *metrics) query(queryKey HitID, update func(*QueryStats)) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if ms.queries == nil {
+		ms.queries = make(map[HitID]*QueryStats)
+	}
+	qs, ok := ms.queries[queryKey]
+	if !ok {
+		if trackedQueriesLimit <= len(ms.queries) {
+			ms.dropLeastUsedQuery()
+		}
+		qs = &QueryStats{QueryKey: queryKey}
+		ms.queries[queryKey] = qs
+	}
+	update(qs)
+}
+
+func (ms *metrics) dropLeastUsedQuery() {
+	var least *QueryStats
+	for _, qs := range ms.queries {
+		if least == nil || qs.Hits+qs.Misses < least.Hits+least.Misses {
+			least = qs
+		}
+	}
+	if least != nil {
+		delete(ms.queries, least.QueryKey)
+	}
+}
+
+func (ms *metrics) HottestQueries(n int) []QueryStats {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	var out []QueryStats
+	for _, qs := range ms.queries {
+		out = append(out, *qs)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := out[i].Hits+out[i].Misses, out[j].Hits+out[j].Misses; a != b {
+			return a > b
+		}
+		return out[i].QueryKey < out[j].QueryKey
+	})
+	if n < len(out) {
+		out = out[:n]
+	}
+	return out
+}
+
+func (ms *metrics) Stats(repo any) Stats {
+	stats := Stats{
+		Hits:             int(ms.hits.Load()),
+		Misses:           int(ms.misses.Load()),
+		Refreshes:        int(ms.refreshes.Load()),
+		SourceCalls:      int(ms.sourceCalls.Load()),
+		SourceLatencyMax: time.Duration(ms.sourceMax.Load()),
+		HottestQueries:   ms.HottestQueries(hottestQueriesLimit),
+	}
+	if total := stats.Hits + stats.Misses; 0 < total {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	if 0 < stats.SourceCalls {
+		stats.SourceLatencyAverage = time.Duration(ms.sourceLatency.Load() / int64(stats.SourceCalls))
+	}
+	if ec, ok := repo.(EvictionCounter); ok {
+		stats.Evictions = ec.EvictionCount()
+	}
+	return stats
+}
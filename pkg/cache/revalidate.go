@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/testcase/clock"
+)
+
+// revalidationQueueSize is the maximum number of stale cached queries waiting for their background refresh.
+const revalidationQueueSize = 64
+
+// RunRevalidation refreshes the stale cached queries in the background until the context is done.
+// Without a running RunRevalidation, the Cache.StaleWhileRevalidate has no effect,
+// and the expired cached queries are re-fetched synchronously.
+// Running multiple RunRevalidation makes the refreshes concurrent.
+//
+// RunRevalidation is a tasker.Task compatible function.
+func (m *Cache[Entity, ID]) RunRevalidation(ctx context.Context) error {
+	queue := m.revalidation.start()
+	defer m.revalidation.stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case job := <-queue:
+			m.runRevalidationJob(ctx, job)
+		}
+	}
+}
+
+// runRevalidationJob refreshes the cached query with the context of the job,
+// which is cancelled only when the RunRevalidation is done.
+func (m *Cache[Entity, ID]) runRevalidationJob(workerCTX context.Context, job revalidationJob[Entity]) {
+	defer m.revalidation.done(job.QueryKey)
+	if workerCTX.Err() != nil { // the RunRevalidation is shutting down
+		return
+	}
+	ctx, cancel := context.WithCancel(job.Context)
+	defer cancel()
+	go func() {
+		select {
+		case <-workerCTX.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	_, err := m.flights.Do(ctx, job.QueryKey, func() ([]Entity, error) {
+		return m.refresh(ctx, job.QueryKey, job.Query)
+	})
+	if err != nil && ctx.Err() == nil {
+		logger.Warn(ctx, fmt.Sprintf("error during the revalidation of %s", job.QueryKey), logger.ErrField(err))
+	}
+	if err == nil {
+		m.metrics.Refresh()
+	}
+}
+
+// isStale tells if an expired hit can still be served while it is revalidated in the background.
+func (m *Cache[Entity, ID]) isStale(ctx context.Context, hit Hit[ID]) bool {
+	ttl := m.timeToLive(ctx)
+	if ttl <= 0 || m.StaleWhileRevalidate <= 0 {
+		return false
+	}
+	return clock.TimeNow().Before(hit.Timestamp.Add(ttl + m.StaleWhileRevalidate))
+}
+
+type revalidation[Entity any] struct {
+	mutex   sync.Mutex
+	workers int
+	queue   chan revalidationJob[Entity]
+	pending map[HitID]struct{}
+}
+
+type revalidationJob[Entity any] struct {
+	// Context is a fresh context with the logging details of the caller.
+	// It doesn't carry the caller's other values, such as an open transaction, nor its cancellation.
+	Context  context.Context
+	QueryKey HitID
+	Query    func(ctx context.Context) iterators.Iterator[Entity]
+}
+
+func (r *revalidation[Entity]) init() {
+	if r.queue == nil {
+		r.queue = make(chan revalidationJob[Entity], revalidationQueueSize)
+	}
+	if r.pending == nil {
+		r.pending = make(map[HitID]struct{})
+	}
+}
+
+func (r *revalidation[Entity]) start() <-chan revalidationJob[Entity] {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.init()
+	r.workers++
+	return r.queue
+}
+
+func (r *revalidation[Entity]) stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.workers--
+	if 0 < r.workers {
+		return
+	}
+	for { // drain the queue, so the skipped queries can be scheduled again by the next worker.
+		select {
+		case job := <-r.queue:
+			delete(r.pending, job.QueryKey)
+		default:
+			return
+		}
+	}
+}
+
+// Schedule schedules the refresh of a cached query, unless it is already scheduled.
+// It reports false when there is no running worker to do the refresh.
+// When the queue is full, the refresh is skipped, and a later call will schedule it again.
+func (r *revalidation[Entity]) Schedule(job revalidationJob[Entity]) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.workers == 0 {
+		return false
+	}
+	if _, ok := r.pending[job.QueryKey]; ok {
+		return true
+	}
+	select {
+	case r.queue <- job:
+		r.pending[job.QueryKey] = struct{}{}
+	default:
+	}
+	return true
+}
+
+func (r *revalidation[Entity]) done(queryKey HitID) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.pending, queryKey)
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// singleflight coalesces the concurrent calls with the same key into a single execution,
+// and shares its result with every caller.
+type singleflight[T any] struct {
+	mutex sync.Mutex
+	calls map[string]*flight[T]
+}
+
+type flight[T any] struct {
+	done    chan struct{}
+	value   T
+	err     error
+	waiters int
+}
+
+// Do executes fn, unless an execution with the same key is already in progress,
+// in which case it waits for its result instead.
+//
+// A waiting caller returns early when its context is done.
+// When the shared execution failed due to the cancellation of the caller who started it,
+// then the waiting callers with a still active context don't receive the foreign cancellation,
+// but one of them executes fn again.
+func (sf *singleflight[T]) Do(ctx context.Context, key string, fn func() (T, error)) (T, error) {
+	for {
+		sf.mutex.Lock()
+		if sf.calls == nil {
+			sf.calls = make(map[string]*flight[T])
+		}
+		if f, ok := sf.calls[key]; ok {
+			f.waiters++
+			sf.mutex.Unlock()
+			select {
+			case <-ctx.Done():
+				sf.leave(f)
+				var zero T
+				return zero, ctx.Err()
+			case <-f.done:
+				sf.leave(f)
+			}
+			if isContextError(f.err) && ctx.Err() == nil {
+				continue
+			}
+			return f.value, f.err
+		}
+		f := &flight[T]{done: make(chan struct{})}
+		sf.calls[key] = f
+		sf.mutex.Unlock()
+		sf.execute(key, f, fn)
+		return f.value, f.err
+	}
+}
+
+func (sf *singleflight[T]) leave(f *flight[T]) {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+	f.waiters--
+}
+
+// waiting returns the number of callers who wait for an execution in progress.
+func (sf *singleflight[T]) waiting() int {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+	var n int
+	for _, f := range sf.calls {
+		n += f.waiters
+	}
+	return n
+}
+
+func (sf *singleflight[T]) execute(key string, f *flight[T], fn func() (T, error)) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			f.err = fmt.Errorf("cache: the shared query panicked: %v", r)
+		}
+		sf.mutex.Lock()
+		delete(sf.calls, key)
+		sf.mutex.Unlock()
+		close(f.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+	f.value, f.err = fn()
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
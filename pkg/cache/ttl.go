@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go.llib.dev/testcase/clock"
+)
+
+type ctxKeyTimeToLive struct{}
+
+// ContextWithTimeToLive returns a context that carries a query specific time-to-live,
+// which overrides the Cache.TimeToLive for the cached queries made with it.
+func ContextWithTimeToLive(ctx context.Context, ttl time.Duration) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, ctxKeyTimeToLive{}, ttl)
+}
+
+// LookupTimeToLive returns the query specific time-to-live carried by the context.
+func LookupTimeToLive(ctx context.Context) (time.Duration, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	ttl, ok := ctx.Value(ctxKeyTimeToLive{}).(time.Duration)
+	return ttl, ok
+}
+
+func (m *Cache[Entity, ID]) timeToLive(ctx context.Context) time.Duration {
+	if ttl, ok := LookupTimeToLive(ctx); ok {
+		return ttl
+	}
+	return m.TimeToLive
+}
+
+// isExpired tells if the hit is older than the time-to-live.
+// A zero time-to-live means that hits never expire.
+func (m *Cache[Entity, ID]) isExpired(ctx context.Context, hit Hit[ID]) bool {
+	ttl := m.timeToLive(ctx)
+	if ttl <= 0 {
+		return false
+	}
+	return !clock.TimeNow().Before(hit.Timestamp.Add(ttl))
+}
@@ -0,0 +1,330 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.llib.dev/frameless/pkg/devops/health"
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/pkg/tasker"
+	"go.llib.dev/frameless/ports/crud"
+	"go.llib.dev/frameless/ports/crud/extid"
+	"go.llib.dev/frameless/ports/iterators"
+)
+
+// WarmUp describes what a Cache should preload from its Source.
+type WarmUp[ID any] struct {
+	// All preloads every entity from the Source using its FindAll.
+	// The Source must implement crud.AllFinder.
+	All bool
+	// IDs are the IDs of the entities to preload.
+	IDs []ID
+	// QueryKeys are the keys of the queries to preload,
+	// which were registered with Cache.RegisterQuery.
+	QueryKeys []HitID
+	// BatchSize is the number of entities, IDs or queries preloaded together.
+	//
+	// Default: 100
+	BatchSize int
+	// Concurrency is the maximum number of batches preloaded in parallel.
+	//
+	// Default: 1
+	Concurrency int
+}
+
+// RegisterQuery registers a cached query, so it can be preloaded with WarmUp.QueryKeys,
+// and refreshed in the background when Cache.StaleWhileRevalidate is set.
+// The query should use the received context.
+func (m *Cache[Entity, ID]) RegisterQuery(queryKey HitID, query func(ctx context.Context) iterators.Iterator[Entity]) {
+	m.warmUps.mutex.Lock()
+	defer m.warmUps.mutex.Unlock()
+	if m.warmUps.queries == nil {
+		m.warmUps.queries = make(map[HitID]func(ctx context.Context) iterators.Iterator[Entity])
+	}
+	m.warmUps.queries[queryKey] = query
+}
+
+func (m *Cache[Entity, ID]) registeredQuery(queryKey HitID) (func(ctx context.Context) iterators.Iterator[Entity], bool) {
+	m.warmUps.mutex.Lock()
+	defer m.warmUps.mutex.Unlock()
+	query, ok := m.warmUps.queries[queryKey]
+	return query, ok
+}
+
+// WarmUp returns a task which preloads the cache from the Source, as described by the WarmUp.
+// Until every warm-up task has succeeded, Cache.ReadinessCheck reports the Cache as not ready.
+func (m *Cache[Entity, ID]) WarmUp(w WarmUp[ID]) tasker.Task {
+	task := &warmUpTask[ID]{WarmUp: w}
+	m.warmUps.mutex.Lock()
+	m.warmUps.tasks = append(m.warmUps.tasks, task)
+	m.warmUps.mutex.Unlock()
+	return func(ctx context.Context) error {
+		return m.runWarmUp(ctx, task)
+	}
+}
+
+// ReadinessCheck is a health.Check compatible function,
+// which reports an issue until the warm-up tasks of the Cache have succeeded.
+// When a warm-up task has failed, the issue contains its error,
+// and the failed warm-up is retried with the context of the check.
+func (m *Cache[Entity, ID]) ReadinessCheck(ctx context.Context) error {
+	m.warmUps.mutex.Lock()
+	tasks := append([]*warmUpTask[ID]{}, m.warmUps.tasks...)
+	m.warmUps.mutex.Unlock()
+
+	var (
+		pending int
+		errs    []error
+	)
+	for _, task := range tasks {
+		done, err := m.checkWarmUp(ctx, task)
+		if done {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		pending++
+	}
+	if pending == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%d cache warm-up is not yet completed", pending)
+	if 0 < len(errs) {
+		msg += ": " + errorkit.Merge(errs...).Error()
+	}
+	return health.Issue{
+		Code:    "cache-warm-up",
+		Message: msg,
+		Causes:  health.Degraded,
+	}
+}
+
+var _ health.Check = (&Cache[struct{}, string]{}).ReadinessCheck
+
+type warmUps[Entity, ID any] struct {
+	mutex   sync.Mutex
+	tasks   []*warmUpTask[ID]
+	queries map[HitID]func(ctx context.Context) iterators.Iterator[Entity]
+}
+
+// warmUpTask is the state of a warm-up task, guarded by the mutex of the warmUps.
+type warmUpTask[ID any] struct {
+	WarmUp  WarmUp[ID]
+	running int
+	done    bool
+	err     error
+}
+
+// runWarmUp runs the warm-up task.
+// A warm-up which succeeded once keeps the Cache ready, even if a later run fails.
+func (m *Cache[Entity, ID]) runWarmUp(ctx context.Context, task *warmUpTask[ID]) error {
+	m.warmUps.mutex.Lock()
+	task.running++
+	m.warmUps.mutex.Unlock()
+	return m.finishWarmUp(task, m.warmUp(ctx, task.WarmUp))
+}
+
+// checkWarmUp tells whether the warm-up task has succeeded.
+// A failed warm-up, which is not running at the moment, is retried.
+func (m *Cache[Entity, ID]) checkWarmUp(ctx context.Context, task *warmUpTask[ID]) (bool, error) {
+	m.warmUps.mutex.Lock()
+	if task.done || task.err == nil || 0 < task.running {
+		defer m.warmUps.mutex.Unlock()
+		return task.done, nil
+	}
+	task.running++
+	m.warmUps.mutex.Unlock()
+	err := m.finishWarmUp(task, m.warmUp(ctx, task.WarmUp))
+	return err == nil, err
+}
+
+func (m *Cache[Entity, ID]) finishWarmUp(task *warmUpTask[ID], err error) error {
+	m.warmUps.mutex.Lock()
+	defer m.warmUps.mutex.Unlock()
+	task.running--
+	task.err = err
+	if err == nil {
+		task.done = true
+	}
+	return err
+}
+
+func (m *Cache[Entity, ID]) warmUp(ctx context.Context, w WarmUp[ID]) error {
+	if w.All {
+		if err := m.warmUpAll(ctx, w); err != nil {
+			return err
+		}
+	}
+	if 0 < len(w.IDs) {
+		err := inBatches(ctx, iterators.Slice(w.IDs), w, func(ctx context.Context, _ int, ids []ID) error {
+			return m.warmUpIDs(ctx, ids)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if 0 < len(w.QueryKeys) {
+		err := inBatches(ctx, iterators.Slice(w.QueryKeys), w, func(ctx context.Context, _ int, queryKeys []HitID) error {
+			for _, queryKey := range queryKeys {
+				query, ok := m.registeredQuery(queryKey)
+				if !ok {
+					return fmt.Errorf("cache warm-up: the %q query is not registered", queryKey)
+				}
+				if _, err := iterators.Count(m.cachedQueryMany(ctx, queryKey, query, true)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warmUpIDs caches the FindByID queries of the IDs.
+// When the Source implements crud.ByIDsFinder, the entities are fetched together with a FindByIDs.
+func (m *Cache[Entity, ID]) warmUpIDs(ctx context.Context, ids []ID) error {
+	if source, ok := m.Source.(crud.ByIDsFinder[Entity, ID]); ok {
+		ents, err := iterators.Collect(source.FindByIDs(ctx, ids...))
+		if err == nil {
+			return m.storeByIDs(ctx, ents)
+		}
+		// FindByIDs fails when an ID is absent from the Source,
+		// so the IDs are fetched one by one, which caches the absence as well.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	for _, id := range ids {
+		if _, _, err := m.findByID(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warmUpAll caches every entity of the Source along with their FindByID queries,
+// and when all of them succeeded, then the FindAll query as well.
+func (m *Cache[Entity, ID]) warmUpAll(ctx context.Context, w WarmUp[ID]) error {
+	source, ok := m.Source.(crud.AllFinder[Entity])
+	if !ok {
+		return fmt.Errorf("%s: %w", "WarmUp.All", ErrNotImplementedBySource)
+	}
+	var (
+		mutex sync.Mutex
+		// batches holds the IDs of each batch by the batch's index,
+		// so the FindAll hit keeps the order of the Source, regardless of the concurrency.
+		batches = make(map[int][]ID)
+	)
+	err := inBatches(ctx, source.FindAll(ctx), w, func(ctx context.Context, index int, ents []Entity) error {
+		ids := make([]ID, 0, len(ents))
+		for _, ent := range ents {
+			id, ok := extid.Lookup[ID](ent)
+			if !ok {
+				return fmt.Errorf("cache warm-up: entity without an ID: %#v", ent)
+			}
+			ids = append(ids, id)
+		}
+		if err := m.storeByIDs(ctx, ents); err != nil {
+			return err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		batches[index] = ids
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var ids []ID
+	for i := 0; i < len(batches); i++ {
+		ids = append(ids, batches[i]...)
+	}
+	return m.storeHit(ctx, Hit[ID]{QueryID: m.queryKeyFindAll(), EntityIDs: ids})
+}
+
+// storeByIDs caches the entities with a single upsert, along with the FindByID query of each entity.
+func (m *Cache[Entity, ID]) storeByIDs(ctx context.Context, ents []Entity) error {
+	if len(ents) == 0 {
+		return nil
+	}
+	vs := make([]*Entity, 0, len(ents))
+	for _, ent := range ents {
+		ent := ent // pass by value copy
+		vs = append(vs, &ent)
+	}
+	if err := m.Repository.Entities().Upsert(ctx, vs...); err != nil {
+		return fmt.Errorf("cache Repository.Entities().Upsert had an error: %w", err)
+	}
+	for _, ent := range ents {
+		id, _ := extid.Lookup[ID](ent)
+		if err := m.storeHit(ctx, Hit[ID]{QueryID: m.queryKeyFindByID(id), EntityIDs: []ID{id}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inBatches handles the elements of the iterator in batches, with the configured concurrency.
+// The handle function receives the index of the batch, which reflects the batch's position in the iterator.
+// On the first failure, the rest of the batches are cancelled.
+func inBatches[T, ID any](ctx context.Context, iter iterators.Iterator[T], w WarmUp[ID], handle func(ctx context.Context, index int, batch []T) error) (rErr error) {
+	defer errorkit.Finish(&rErr, iter.Close)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := w.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	type batch struct {
+		Index  int
+		Values []T
+	}
+	var (
+		batches = make(chan batch)
+		wg      sync.WaitGroup
+		mutex   sync.Mutex
+		errs    []error
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				if err := handle(ctx, b.Index, b.Values); err != nil {
+					mutex.Lock()
+					errs = append(errs, err)
+					mutex.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+
+	batchIter := iterators.Batch(iter, w.BatchSize)
+	var index int
+sending:
+	for batchIter.Next() {
+		select {
+		case batches <- batch{Index: index, Values: batchIter.Value()}:
+			index++
+		case <-ctx.Done():
+			break sending
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	if 0 < len(errs) {
+		return errorkit.Merge(errs...)
+	}
+	if err := batchIter.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
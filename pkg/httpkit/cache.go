@@ -0,0 +1,604 @@
+package httpkit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/testcase/clock"
+	"go.llib.dev/testcase/random"
+)
+
+// CacheRoundTripper is a private HTTP cache, which follows the caching rules of RFC 9111.
+// It stores the cacheable responses of GET requests in its Store,
+// and serves them while they are fresh according to their Cache-Control, Expires and Last-Modified headers.
+// A stale response is revalidated with a conditional request, using its ETag and Last-Modified headers,
+// and when the server replies with 304 Not Modified, the stored response is served with the updated headers.
+// Responses with a Vary header are only served to requests with matching header values,
+// and a variant is stored for each combination of these header values.
+// Successful unsafe requests, like a POST, PUT or DELETE, invalidate the stored response of their URL.
+type CacheRoundTripper struct {
+	// Transport specifies the mechanism by which individual
+	// HTTP requests are made.
+	//
+	// Default: http.DefaultTransport
+	Transport http.RoundTripper
+	// Store is where the responses are cached.
+	//
+	// Default: a MemoryCacheStore with its default limits.
+	Store CacheStore
+
+	init sync.Once
+}
+
+// CacheStore is the storage of a CacheRoundTripper.
+type CacheStore interface {
+	// Get returns the data stored under the key.
+	Get(ctx context.Context, key string) (data []byte, found bool, err error)
+	// Set stores the data under the key, and replaces the previously stored data.
+	Set(ctx context.Context, key string, data []byte) error
+	// Delete removes the data stored under the key.
+	// Deleting an absent key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// heuristicallyCacheableStatusCodes are the status codes which are cacheable without explicit freshness information.
+// https://www.rfc-editor.org/rfc/rfc9110#section-15.1
+var heuristicallyCacheableStatusCodes = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusNonAuthoritativeInfo: {},
+	http.StatusNoContent:            {},
+	http.StatusMultipleChoices:      {},
+	http.StatusMovedPermanently:     {},
+	http.StatusPermanentRedirect:    {},
+	http.StatusNotFound:             {},
+	http.StatusMethodNotAllowed:     {},
+	http.StatusGone:                 {},
+	http.StatusRequestURITooLong:    {},
+	http.StatusNotImplemented:       {},
+}
+
+func (rt *CacheRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return rt.roundTripUncached(req)
+	}
+	reqCC := parseCacheControl(req.Header)
+	if reqCC.Has("no-store") || isConditionalRequest(req) || req.Header.Get("Range") != "" {
+		return rt.transport().RoundTrip(req)
+	}
+
+	var (
+		ctx = req.Context()
+		key = cacheKey(req.URL)
+	)
+	entry, found := rt.lookup(ctx, key, req)
+	if found {
+		if rt.isServable(entry, reqCC) {
+			return entry.ToResponse(req)
+		}
+		if entry.HasValidator() {
+			return rt.revalidate(ctx, key, req, entry)
+		}
+	}
+	if reqCC.Has("only-if-cached") {
+		return &http.Response{
+			Status:     http.StatusText(http.StatusGatewayTimeout),
+			StatusCode: http.StatusGatewayTimeout,
+			Proto:      req.Proto,
+			ProtoMajor: req.ProtoMajor,
+			ProtoMinor: req.ProtoMinor,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	requestTime := clock.TimeNow()
+	resp, err := rt.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return rt.store(ctx, key, req, resp, requestTime)
+}
+
+// roundTripUncached forwards the request,
+// and when the request is unsafe and succeeds, it invalidates the affected responses.
+// https://www.rfc-editor.org/rfc/rfc9111#section-4.4
+func (rt *CacheRoundTripper) roundTripUncached(req *http.Request) (*http.Response, error) {
+	resp, err := rt.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if isSafeMethod(req.Method) || !(200 <= resp.StatusCode && resp.StatusCode < 400) {
+		return resp, nil
+	}
+	ctx := req.Context()
+	keys := []string{cacheKey(req.URL)}
+	for _, hdr := range []string{"Location", "Content-Location"} {
+		loc, err := req.URL.Parse(resp.Header.Get(hdr))
+		if err != nil || resp.Header.Get(hdr) == "" || loc.Host != req.URL.Host {
+			continue
+		}
+		keys = append(keys, cacheKey(loc))
+	}
+	for _, key := range keys {
+		if err := rt.invalidate(ctx, key); err != nil {
+			logger.Warn(ctx, "httpkit.CacheRoundTripper failed to invalidate a cached response", logger.ErrField(err))
+		}
+	}
+	return resp, nil
+}
+
+// invalidate removes every stored variant of the URL's response.
+func (rt *CacheRoundTripper) invalidate(ctx context.Context, key string) error {
+	variants, found, err := rt.variants(ctx, key)
+	if err != nil || !found {
+		return err
+	}
+	// the variants which fail to be removed are unreachable anyway without the index under the URL's key.
+	var errs []error
+	for _, variantKey := range variants.Keys {
+		errs = append(errs, rt.getStore().Delete(ctx, variantKey))
+	}
+	return errorkit.Merge(append(errs, rt.getStore().Delete(ctx, key))...)
+}
+
+func (rt *CacheRoundTripper) revalidate(ctx context.Context, key string, req *http.Request, entry cacheEntry) (*http.Response, error) {
+	stored, err := entry.ToResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(stored.Body)
+	if err := errorkit.Merge(err, stored.Body.Close()); err != nil {
+		return nil, err
+	}
+	stored.Header.Del("Age")
+
+	conditional := req.Clone(ctx)
+	if etag := stored.Header.Get("ETag"); etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := stored.Header.Get("Last-Modified"); lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := clock.TimeNow()
+	resp, err := rt.transport().RoundTrip(conditional)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		return rt.store(ctx, key, req, resp, requestTime)
+	}
+	if err := drain(resp.Body); err != nil {
+		return nil, err
+	}
+
+	// https://www.rfc-editor.org/rfc/rfc9111#section-4.3.4
+	for name, values := range resp.Header {
+		if strings.EqualFold(name, "Content-Length") {
+			continue
+		}
+		stored.Header[name] = values
+	}
+	stored.Body = io.NopCloser(bytes.NewReader(body))
+	updated, err := newCacheEntry(req, stored, requestTime, clock.TimeNow())
+	if err != nil {
+		return nil, err
+	}
+	rt.set(ctx, key, req, updated)
+	return updated.ToResponse(req)
+}
+
+// store caches the response when it is storable, and returns a response that can be passed to the caller.
+// https://www.rfc-editor.org/rfc/rfc9111#section-3
+func (rt *CacheRoundTripper) store(ctx context.Context, key string, req *http.Request, resp *http.Response, requestTime time.Time) (*http.Response, error) {
+	if !isStorable(resp) {
+		return resp, nil
+	}
+	entry, err := newCacheEntry(req, resp, requestTime, clock.TimeNow())
+	if err != nil {
+		return nil, err
+	}
+	rt.set(ctx, key, req, entry)
+	return entry.ToResponse(req)
+}
+
+// set stores the entry as the variant of the URL's response which matches the request.
+func (rt *CacheRoundTripper) set(ctx context.Context, key string, req *http.Request, entry cacheEntry) {
+	if err := rt.setVariant(ctx, key, req, entry); err != nil {
+		logger.Warn(ctx, "httpkit.CacheRoundTripper failed to store a response", logger.ErrField(err))
+	}
+}
+
+func (rt *CacheRoundTripper) setVariant(ctx context.Context, key string, req *http.Request, entry cacheEntry) error {
+	variants, found, err := rt.variants(ctx, key)
+	if err != nil {
+		return err
+	}
+	if vary := entry.VaryNames(); !found || strings.Join(variants.Vary, ",") != strings.Join(vary, ",") {
+		if found { // the old variants were selected by other headers, thus they are outdated.
+			if err := rt.invalidate(ctx, key); err != nil {
+				logger.Warn(ctx, "httpkit.CacheRoundTripper failed to invalidate the outdated variants", logger.ErrField(err))
+			}
+		}
+		variants = cacheVariants{ID: random.New(random.CryptoSeed{}).UUID(), Vary: vary}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	variantKey := variants.Key(key, req)
+	if err := rt.getStore().Set(ctx, variantKey, data); err != nil {
+		return err
+	}
+	for _, k := range variants.Keys {
+		if k == variantKey {
+			return nil
+		}
+	}
+	variants.Keys = append(variants.Keys, variantKey)
+	data, err = json.Marshal(variants)
+	if err != nil {
+		return err
+	}
+	return rt.getStore().Set(ctx, key, data)
+}
+
+func (rt *CacheRoundTripper) variants(ctx context.Context, key string) (cacheVariants, bool, error) {
+	data, found, err := rt.getStore().Get(ctx, key)
+	if err != nil || !found {
+		return cacheVariants{}, false, err
+	}
+	var variants cacheVariants
+	if err := json.Unmarshal(data, &variants); err != nil || variants.ID == "" {
+		logger.Warn(ctx, "httpkit.CacheRoundTripper found a corrupt cached response index", logger.ErrField(err))
+		return cacheVariants{}, false, nil
+	}
+	return variants, true, nil
+}
+
+func (rt *CacheRoundTripper) lookup(ctx context.Context, key string, req *http.Request) (cacheEntry, bool) {
+	variants, found, err := rt.variants(ctx, key)
+	if err != nil {
+		logger.Warn(ctx, "httpkit.CacheRoundTripper failed to look up a cached response", logger.ErrField(err))
+		return cacheEntry{}, false
+	}
+	if !found {
+		return cacheEntry{}, false
+	}
+	data, found, err := rt.getStore().Get(ctx, variants.Key(key, req))
+	if err != nil {
+		logger.Warn(ctx, "httpkit.CacheRoundTripper failed to look up a cached response", logger.ErrField(err))
+		return cacheEntry{}, false
+	}
+	if !found {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logger.Warn(ctx, "httpkit.CacheRoundTripper found a corrupt cached response", logger.ErrField(err))
+		return cacheEntry{}, false
+	}
+	return entry, entry.MatchVary(req)
+}
+
+// isServable tells if the stored response can be served without revalidation.
+// https://www.rfc-editor.org/rfc/rfc9111#section-4.2
+func (rt *CacheRoundTripper) isServable(entry cacheEntry, reqCC cacheControl) bool {
+	respCC := parseCacheControl(entry.Header)
+	if reqCC.Has("no-cache") || respCC.Has("no-cache") {
+		return false
+	}
+	var (
+		age      = entry.Age(clock.TimeNow())
+		lifetime = entry.FreshnessLifetime()
+	)
+	if maxAge, ok := reqCC.Seconds("max-age"); ok && maxAge < age {
+		return false
+	}
+	if minFresh, ok := reqCC.Seconds("min-fresh"); ok {
+		age += minFresh
+	}
+	if age < lifetime {
+		return true
+	}
+	if respCC.Has("must-revalidate") || !reqCC.Has("max-stale") {
+		return false
+	}
+	maxStale, ok := reqCC.Seconds("max-stale")
+	return !ok || age < lifetime+maxStale // a max-stale without a value accepts any staleness
+}
+
+func (rt *CacheRoundTripper) transport() http.RoundTripper {
+	if rt.Transport == nil {
+		return http.DefaultTransport
+	}
+	return rt.Transport
+}
+
+func (rt *CacheRoundTripper) getStore() CacheStore {
+	rt.init.Do(func() {
+		if rt.Store == nil {
+			rt.Store = &MemoryCacheStore{}
+		}
+	})
+	return rt.Store
+}
+
+func isStorable(resp *http.Response) bool {
+	respCC := parseCacheControl(resp.Header)
+	if respCC.Has("no-store") {
+		return false
+	}
+	for _, field := range headerList(resp.Header, "Vary") {
+		if field == "*" {
+			return false
+		}
+	}
+	_, explicitFreshness := respCC.Seconds("max-age")
+	explicitFreshness = explicitFreshness || resp.Header.Get("Expires") != "" || respCC.Has("public")
+	if _, ok := heuristicallyCacheableStatusCodes[resp.StatusCode]; !ok && !(explicitFreshness && 200 <= resp.StatusCode && resp.StatusCode != http.StatusPartialContent) {
+		return false
+	}
+	// a response without any freshness or validator would be stale and unusable right away.
+	hasValidator := resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+	return explicitFreshness || hasValidator
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func isConditionalRequest(req *http.Request) bool {
+	for _, hdr := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"} {
+		if req.Header.Get(hdr) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheKey(u *url.URL) string {
+	u = &url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery}
+	return http.MethodGet + " " + u.String()
+}
+
+func drain(body io.ReadCloser) error {
+	if body == nil {
+		return nil
+	}
+	_, err := io.Copy(io.Discard, body)
+	return errorkit.Merge(err, body.Close())
+}
+
+// headerList returns the comma separated values of a header field.
+func headerList(h http.Header, name string) []string {
+	var out []string
+	for _, value := range h.Values(name) {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// cacheVariants is stored under the cache key of a URL,
+// and it tells where the variants of the URL's response are stored.
+// The variants are selected by the request header values which are named in the response's Vary header.
+// https://www.rfc-editor.org/rfc/rfc9111#section-4.1
+type cacheVariants struct {
+	// ID is part of the variant keys,
+	// thus a variant which outlives the invalidation of its index never becomes reachable again.
+	ID string `json:"id"`
+	// Vary is the sorted list of the request header names which select the variant.
+	Vary []string `json:"vary,omitempty"`
+	// Keys are the keys of the stored variants.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Key returns the key of the variant which matches the request.
+func (v cacheVariants) Key(key string, req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	b.WriteString(" ")
+	b.WriteString(v.ID)
+	for _, name := range v.Vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(varyValue(req.Header, name))
+	}
+	return b.String()
+}
+
+type cacheEntry struct {
+	// Response is the stored response in HTTP/1.1 wire format.
+	Response []byte `json:"response"`
+	// Header is the header of the stored response.
+	Header http.Header `json:"header"`
+	// Vary holds the request header values which were selected by the response's Vary header.
+	Vary         map[string]string `json:"vary,omitempty"`
+	RequestTime  time.Time         `json:"request_time"`
+	ResponseTime time.Time         `json:"response_time"`
+}
+
+func newCacheEntry(req *http.Request, resp *http.Response, requestTime, responseTime time.Time) (cacheEntry, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err := errorkit.Merge(err, resp.Body.Close()); err != nil {
+		return cacheEntry{}, err
+	}
+	var (
+		header = resp.Header.Clone()
+		buf    bytes.Buffer
+	)
+	header.Del("Age") // the age is calculated on serving
+	out := &http.Response{
+		Status:        resp.Status,
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+	if err := out.Write(&buf); err != nil {
+		return cacheEntry{}, err
+	}
+	entry := cacheEntry{
+		Response:     buf.Bytes(),
+		Header:       resp.Header.Clone(),
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+	}
+	for _, field := range headerList(resp.Header, "Vary") {
+		if entry.Vary == nil {
+			entry.Vary = make(map[string]string)
+		}
+		entry.Vary[http.CanonicalHeaderKey(field)] = varyValue(req.Header, field)
+	}
+	return entry, nil
+}
+
+func varyValue(h http.Header, name string) string {
+	return strings.Join(headerList(h, name), ",")
+}
+
+func (e cacheEntry) MatchVary(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if varyValue(req.Header, name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// VaryNames returns the sorted names of the request headers which select this variant.
+func (e cacheEntry) VaryNames() []string {
+	var names []string
+	for name := range e.Vary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e cacheEntry) HasValidator() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+func (e cacheEntry) ToResponse(req *http.Request) (*http.Response, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(e.Response)), req)
+	if err != nil {
+		return nil, fmt.Errorf("httpkit.CacheRoundTripper failed to read the cached response: %w", err)
+	}
+	resp.Header.Set("Age", strconv.Itoa(int(e.Age(clock.TimeNow())/time.Second)))
+	return resp, nil
+}
+
+// Age calculates the current age of the stored response.
+// https://www.rfc-editor.org/rfc/rfc9111#section-4.2.3
+func (e cacheEntry) Age(now time.Time) time.Duration {
+	var (
+		dateValue         = e.date()
+		apparentAge       = maxDuration(0, e.ResponseTime.Sub(dateValue))
+		responseDelay     = e.ResponseTime.Sub(e.RequestTime)
+		ageValue          time.Duration
+		residentTime      = now.Sub(e.ResponseTime)
+		correctedAgeValue time.Duration
+	)
+	if age, err := strconv.Atoi(e.Header.Get("Age")); err == nil && 0 <= age {
+		ageValue = time.Duration(age) * time.Second
+	}
+	correctedAgeValue = ageValue + responseDelay
+	return maxDuration(apparentAge, correctedAgeValue) + residentTime
+}
+
+// FreshnessLifetime calculates how long the stored response is fresh after its generation.
+// https://www.rfc-editor.org/rfc/rfc9111#section-4.2.1
+func (e cacheEntry) FreshnessLifetime() time.Duration {
+	cc := parseCacheControl(e.Header)
+	if maxAge, ok := cc.Seconds("max-age"); ok {
+		return maxAge
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil { // an invalid Expires means that the response is already expired
+			return 0
+		}
+		return exp.Sub(e.date())
+	}
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil {
+		// https://www.rfc-editor.org/rfc/rfc9111#section-4.2.2
+		return maxDuration(0, e.date().Sub(lastModified)/10)
+	}
+	return 0
+}
+
+func (e cacheEntry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return b
+	}
+	return a
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// cacheControl holds the directives of a Cache-Control header.
+// https://www.rfc-editor.org/rfc/rfc9111#section-5.2
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, directive := range headerList(h, "Cache-Control") {
+		name, value, _ := strings.Cut(directive, "=")
+		cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return cc
+}
+
+func (cc cacheControl) Has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+func (cc cacheControl) Seconds(directive string) (time.Duration, bool) {
+	value, ok := cc[directive]
+	if !ok || value == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
@@ -0,0 +1,502 @@
+package httpkit_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/adapters/localfs"
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/pkg/httpkit"
+	"go.llib.dev/frameless/ports/filesystem"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/clock"
+	"go.llib.dev/testcase/clock/timecop"
+)
+
+var _ http.RoundTripper = &httpkit.CacheRoundTripper{}
+
+// cacheTestServer records the received requests, and replies with the response made by the Reply function.
+type cacheTestServer struct {
+	Reply func(w http.ResponseWriter, r *http.Request)
+
+	mutex    sync.Mutex
+	requests []*http.Request
+}
+
+func (srv *cacheTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mutex.Lock()
+	srv.requests = append(srv.requests, r)
+	srv.mutex.Unlock()
+	srv.Reply(w, r)
+}
+
+func (srv *cacheTestServer) Requests() []*http.Request {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	return append([]*http.Request{}, srv.requests...)
+}
+
+func TestCacheRoundTripper(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		reply  = testcase.Var[func(w http.ResponseWriter, r *http.Request)]{ID: "server reply"}
+		server = testcase.Let(s, func(t *testcase.T) *cacheTestServer {
+			return &cacheTestServer{Reply: reply.Get(t)}
+		})
+		httpServer = testcase.Let(s, func(t *testcase.T) *httptest.Server {
+			hs := httptest.NewServer(server.Get(t))
+			t.Defer(hs.Close)
+			return hs
+		})
+		client = testcase.Let(s, func(t *testcase.T) *http.Client {
+			return &http.Client{Transport: &httpkit.CacheRoundTripper{Transport: httpServer.Get(t).Client().Transport}}
+		})
+	)
+	do := func(t *testcase.T, method string, header http.Header) (*http.Response, string) {
+		req, err := http.NewRequest(method, httpServer.Get(t).URL, nil)
+		t.Must.NoError(err)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := client.Get(t).Do(req)
+		t.Must.NoError(err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		t.Must.NoError(err)
+		return resp, string(body)
+	}
+	get := func(t *testcase.T) (*http.Response, string) {
+		return do(t, http.MethodGet, nil)
+	}
+	requestCount := func(t *testcase.T) int {
+		return len(server.Get(t).Requests())
+	}
+
+	s.Context("when the response has a max-age", func(s *testcase.Spec) {
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			var n int
+			return func(w http.ResponseWriter, r *http.Request) {
+				n++
+				w.Header().Set("Cache-Control", "max-age=60")
+				_, _ = io.WriteString(w, strings.Repeat("x", n))
+			}
+		})
+
+		s.Test("a fresh response is served from the cache until its max-age", func(t *testcase.T) {
+			_, body1 := get(t)
+			resp, body2 := get(t)
+			t.Must.Equal(body1, body2)
+			t.Must.Equal(1, requestCount(t))
+			t.Must.NotEmpty(resp.Header.Get("Age"))
+
+			timecop.Travel(t, time.Minute+time.Second)
+			_, body3 := get(t)
+			t.Must.NotEqual(body1, body3)
+			t.Must.Equal(2, requestCount(t))
+		})
+
+		s.Test("a successful unsafe request invalidates the stored response", func(t *testcase.T) {
+			get(t)
+			do(t, http.MethodPost, nil)
+			get(t)
+			t.Must.Equal(3, requestCount(t))
+		})
+	})
+
+	s.Context("when the response is revalidated with its ETag", func(s *testcase.Spec) {
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.Header().Set("X-Revalidated", "true")
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				_, _ = io.WriteString(w, "hello")
+			}
+		})
+
+		s.Test("a stale response is served after a conditional request", func(t *testcase.T) {
+			_, body1 := get(t)
+			resp, body2 := get(t)
+			t.Must.Equal(http.StatusOK, resp.StatusCode)
+			t.Must.Equal("hello", body1)
+			t.Must.Equal("hello", body2)
+			t.Must.Equal("true", resp.Header.Get("X-Revalidated"), "the headers of the 304 response should update the stored ones")
+			reqs := server.Get(t).Requests()
+			t.Must.Equal(2, len(reqs))
+			t.Must.Equal(`"v1"`, reqs[1].Header.Get("If-None-Match"))
+		})
+	})
+
+	s.Context("when the response is revalidated with its Last-Modified", func(s *testcase.Spec) {
+		lastModified := testcase.Let(s, func(t *testcase.T) string {
+			return clock.TimeNow().Add(-time.Hour).UTC().Format(http.TimeFormat)
+		})
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			lastModified := lastModified.Get(t)
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=0")
+				w.Header().Set("Last-Modified", lastModified)
+				if r.Header.Get("If-Modified-Since") == lastModified {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				_, _ = io.WriteString(w, "hello")
+			}
+		})
+
+		s.Test("a stale response is served after a conditional request", func(t *testcase.T) {
+			get(t)
+			_, body := get(t)
+			t.Must.Equal("hello", body)
+			reqs := server.Get(t).Requests()
+			t.Must.Equal(2, len(reqs))
+			t.Must.Equal(lastModified.Get(t), reqs[1].Header.Get("If-Modified-Since"))
+		})
+	})
+
+	s.Context("when the response changes", func(s *testcase.Spec) {
+		version := testcase.LetValue(s, "v1")
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				version := version.Get(t)
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("ETag", version)
+				if r.Header.Get("If-None-Match") == version {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				_, _ = io.WriteString(w, version)
+			}
+		})
+
+		s.Test("the changed response replaces the stored one on revalidation", func(t *testcase.T) {
+			_, body := get(t)
+			t.Must.Equal("v1", body)
+			version.Set(t, "v2")
+			_, body = get(t)
+			t.Must.Equal("v2", body)
+			_, body = get(t)
+			t.Must.Equal("v2", body)
+		})
+	})
+
+	s.Context("when the response has a Vary header", func(s *testcase.Spec) {
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("Vary", "Accept-Language")
+				_, _ = io.WriteString(w, r.Header.Get("Accept-Language"))
+			}
+		})
+		var (
+			en = http.Header{"Accept-Language": {"en"}}
+			hu = http.Header{"Accept-Language": {"hu"}}
+		)
+
+		s.Test("the cache selects the variant by the request headers", func(t *testcase.T) {
+			_, body := do(t, http.MethodGet, en)
+			t.Must.Equal("en", body)
+			_, body = do(t, http.MethodGet, en)
+			t.Must.Equal("en", body)
+			t.Must.Equal(1, requestCount(t))
+
+			_, body = do(t, http.MethodGet, hu)
+			t.Must.Equal("hu", body)
+			t.Must.Equal(2, requestCount(t))
+
+			_, body = do(t, http.MethodGet, en)
+			t.Must.Equal("en", body)
+			_, body = do(t, http.MethodGet, hu)
+			t.Must.Equal("hu", body)
+			t.Must.Equal(2, requestCount(t), "every variant was expected to be stored")
+		})
+
+		s.Test("a successful unsafe request invalidates every variant", func(t *testcase.T) {
+			do(t, http.MethodGet, en)
+			do(t, http.MethodGet, hu)
+			do(t, http.MethodDelete, nil)
+			do(t, http.MethodGet, en)
+			do(t, http.MethodGet, hu)
+			t.Must.Equal(5, requestCount(t))
+		})
+	})
+
+	s.Context("when the response has no-store", func(s *testcase.Spec) {
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60, no-store")
+				_, _ = io.WriteString(w, "hello")
+			}
+		})
+
+		s.Test("the response is not cached", func(t *testcase.T) {
+			get(t)
+			get(t)
+			t.Must.Equal(2, requestCount(t))
+		})
+	})
+
+	s.Context("when the response has neither freshness nor validator", func(s *testcase.Spec) {
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "hello")
+			}
+		})
+
+		s.Test("the response is not cached", func(t *testcase.T) {
+			get(t)
+			get(t)
+			t.Must.Equal(2, requestCount(t))
+		})
+	})
+
+	s.Context("when the response has an Expires but no max-age", func(s *testcase.Spec) {
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				now := clock.TimeNow().UTC()
+				w.Header().Set("Date", now.Format(http.TimeFormat))
+				w.Header().Set("Expires", now.Add(time.Hour).Format(http.TimeFormat))
+				_, _ = io.WriteString(w, "hello")
+			}
+		})
+
+		s.Test("the response is fresh until it expires", func(t *testcase.T) {
+			get(t)
+			get(t)
+			t.Must.Equal(1, requestCount(t))
+			timecop.Travel(t, time.Hour+time.Minute)
+			get(t)
+			t.Must.Equal(2, requestCount(t))
+		})
+	})
+
+	s.Context("when the request has cache directives", func(s *testcase.Spec) {
+		reply.Let(s, func(t *testcase.T) func(w http.ResponseWriter, r *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("ETag", `"v1"`)
+				_, _ = io.WriteString(w, "hello")
+			}
+		})
+		withCacheControl := func(directive string) http.Header {
+			return http.Header{"Cache-Control": {directive}}
+		}
+
+		s.Test("only-if-cached responds with 504 when nothing is cached", func(t *testcase.T) {
+			resp, _ := do(t, http.MethodGet, withCacheControl("only-if-cached"))
+			t.Must.Equal(http.StatusGatewayTimeout, resp.StatusCode)
+			t.Must.Equal(0, requestCount(t))
+		})
+
+		s.Test("only-if-cached is served from the cache", func(t *testcase.T) {
+			get(t)
+			resp, _ := do(t, http.MethodGet, withCacheControl("only-if-cached"))
+			t.Must.Equal(http.StatusOK, resp.StatusCode)
+			t.Must.Equal(1, requestCount(t))
+		})
+
+		s.Test("no-cache skips the stored response", func(t *testcase.T) {
+			get(t)
+			do(t, http.MethodGet, withCacheControl("no-cache"))
+			t.Must.Equal(2, requestCount(t))
+		})
+
+		s.Test("max-age rejects an older stored response", func(t *testcase.T) {
+			get(t)
+			timecop.Travel(t, 10*time.Second)
+			do(t, http.MethodGet, withCacheControl("max-age=5"))
+			t.Must.Equal(2, requestCount(t))
+		})
+
+		s.Test("max-stale accepts a stale response", func(t *testcase.T) {
+			get(t)
+			timecop.Travel(t, 2*time.Minute)
+			do(t, http.MethodGet, withCacheControl("max-stale=3600"))
+			t.Must.Equal(1, requestCount(t))
+		})
+	})
+}
+
+func TestCacheStore(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		store = testcase.Var[httpkit.CacheStore]{ID: "httpkit.CacheStore"}
+		key   = testcase.Let(s, func(t *testcase.T) string {
+			return "GET http://example.com/" + t.Random.StringNWithCharset(8, "abcdef") + "?bar=baz"
+		})
+	)
+	ctx := context.Background()
+
+	storeContract := func(s *testcase.Spec) {
+		s.Test("an absent key is not found", func(t *testcase.T) {
+			_, found, err := store.Get(t).Get(ctx, key.Get(t))
+			t.Must.NoError(err)
+			t.Must.False(found)
+		})
+
+		s.Test("Set replaces the stored data", func(t *testcase.T) {
+			t.Must.NoError(store.Get(t).Set(ctx, key.Get(t), []byte("v1")))
+			t.Must.NoError(store.Get(t).Set(ctx, key.Get(t), []byte("v2")))
+			data, found, err := store.Get(t).Get(ctx, key.Get(t))
+			t.Must.NoError(err)
+			t.Must.True(found)
+			t.Must.Equal("v2", string(data))
+		})
+
+		s.Test("Delete removes the stored data, and tolerates an absent key", func(t *testcase.T) {
+			t.Must.NoError(store.Get(t).Set(ctx, key.Get(t), []byte("v1")))
+			t.Must.NoError(store.Get(t).Delete(ctx, key.Get(t)))
+			t.Must.NoError(store.Get(t).Delete(ctx, key.Get(t)))
+			_, found, err := store.Get(t).Get(ctx, key.Get(t))
+			t.Must.NoError(err)
+			t.Must.False(found)
+		})
+	}
+
+	s.Context("MemoryCacheStore", func(s *testcase.Spec) {
+		memoryStore := testcase.Let(s, func(t *testcase.T) *httpkit.MemoryCacheStore {
+			return &httpkit.MemoryCacheStore{}
+		})
+		store.Let(s, func(t *testcase.T) httpkit.CacheStore {
+			return memoryStore.Get(t)
+		})
+
+		storeContract(s)
+
+		s.Test("it evicts the least recently used entries", func(t *testcase.T) {
+			memoryStore.Get(t).MaxEntries = 2
+			t.Must.NoError(store.Get(t).Set(ctx, "a", []byte("a")))
+			t.Must.NoError(store.Get(t).Set(ctx, "b", []byte("b")))
+			_, _, _ = store.Get(t).Get(ctx, "a")
+			t.Must.NoError(store.Get(t).Set(ctx, "c", []byte("c")))
+
+			t.Must.Equal(2, memoryStore.Get(t).Len())
+			_, found, _ := store.Get(t).Get(ctx, "b")
+			t.Must.False(found)
+			_, found, _ = store.Get(t).Get(ctx, "a")
+			t.Must.True(found)
+		})
+
+		s.Test("it is bounded by the size of the entries", func(t *testcase.T) {
+			memoryStore.Get(t).MaxBytes = 10
+			t.Must.NoError(store.Get(t).Set(ctx, "a", []byte("12345")))
+			t.Must.NoError(store.Get(t).Set(ctx, "b", []byte("12345")))
+			t.Must.NoError(store.Get(t).Set(ctx, "c", []byte("12345")))
+			t.Must.Equal(2, memoryStore.Get(t).Len())
+			t.Must.NoError(store.Get(t).Set(ctx, "d", []byte("12345678901")))
+			_, found, _ := store.Get(t).Get(ctx, "d")
+			t.Must.False(found)
+		})
+	})
+
+	s.Context("FileSystemCacheStore with memory.FileSystem", func(s *testcase.Spec) {
+		fsys := testcase.Let(s, func(t *testcase.T) *spyFileSystem {
+			return &spyFileSystem{FileSystem: &memory.FileSystem{}}
+		})
+		store.Let(s, func(t *testcase.T) httpkit.CacheStore {
+			return &httpkit.FileSystemCacheStore{FileSystem: fsys.Get(t), Directory: "cache"}
+		})
+
+		storeContract(s)
+
+		s.Test("it creates its directory once, and renames the temporary files", func(t *testcase.T) {
+			for i := 0; i < 3; i++ {
+				t.Must.NoError(store.Get(t).Set(ctx, strconv.Itoa(i), []byte("data")))
+			}
+			t.Must.Equal(1, fsys.Get(t).Mkdirs)
+			t.Must.Equal(3, fsys.Get(t).Renames)
+			entries, err := filesystem.ReadDir(fsys.Get(t), "cache")
+			t.Must.NoError(err)
+			t.Must.Equal(3, len(entries))
+			for _, entry := range entries {
+				t.Must.NotContain(entry.Name(), ".tmp")
+			}
+		})
+	})
+
+	s.Context("FileSystemCacheStore with a file system without Rename support", func(s *testcase.Spec) {
+		fsys := testcase.Let(s, func(t *testcase.T) filesystem.FileSystem {
+			return struct{ filesystem.FileSystem }{FileSystem: &memory.FileSystem{}}
+		})
+		store.Let(s, func(t *testcase.T) httpkit.CacheStore {
+			return &httpkit.FileSystemCacheStore{FileSystem: fsys.Get(t), Directory: "cache"}
+		})
+
+		storeContract(s)
+
+		s.Test("the responses are written in place", func(t *testcase.T) {
+			t.Must.NoError(store.Get(t).Set(ctx, key.Get(t), []byte("data")))
+			entries, err := filesystem.ReadDir(fsys.Get(t), "cache")
+			t.Must.NoError(err)
+			t.Must.Equal(1, len(entries))
+			t.Must.NotContain(entries[0].Name(), ".tmp")
+		})
+	})
+
+	s.Context("FileSystemCacheStore with a nested Directory", func(s *testcase.Spec) {
+		store.Let(s, func(t *testcase.T) httpkit.CacheStore {
+			return &httpkit.FileSystemCacheStore{FileSystem: localfs.FileSystem{RootPath: t.TempDir()}, Directory: "var/cache/http"}
+		})
+
+		storeContract(s)
+	})
+
+	s.Context("FileSystemCacheStore with localfs.FileSystem", func(s *testcase.Spec) {
+		store.Let(s, func(t *testcase.T) httpkit.CacheStore {
+			return &httpkit.FileSystemCacheStore{FileSystem: localfs.FileSystem{RootPath: t.TempDir()}}
+		})
+
+		storeContract(s)
+
+		s.Test("the cache survives a restart", func(t *testcase.T) {
+			var n int
+			hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n++
+				w.Header().Set("Cache-Control", "max-age=60")
+				_, _ = io.WriteString(w, "hello")
+			}))
+			t.Defer(hs.Close)
+
+			for i := 0; i < 2; i++ { // a new client for each request, like after a restart
+				client := &http.Client{Transport: &httpkit.CacheRoundTripper{Transport: hs.Client().Transport, Store: store.Get(t)}}
+				resp, err := client.Get(hs.URL)
+				t.Must.NoError(err)
+				body, err := io.ReadAll(resp.Body)
+				t.Must.NoError(err)
+				t.Must.NoError(resp.Body.Close())
+				t.Must.Equal("hello", string(body))
+			}
+			t.Must.Equal(1, n)
+		})
+	})
+}
+
+// spyFileSystem counts the directory creations and the renames.
+type spyFileSystem struct {
+	*memory.FileSystem
+	Mkdirs  int
+	Renames int
+}
+
+func (fsys *spyFileSystem) Mkdir(name string, perm fs.FileMode) error {
+	fsys.Mkdirs++
+	return fsys.FileSystem.Mkdir(name, perm)
+}
+
+func (fsys *spyFileSystem) Rename(oldpath, newpath string) error {
+	fsys.Renames++
+	return fsys.FileSystem.Rename(oldpath, newpath)
+}
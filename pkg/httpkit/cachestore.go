@@ -0,0 +1,234 @@
+package httpkit
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/ports/filesystem"
+	"go.llib.dev/testcase/random"
+)
+
+// MemoryCacheStore is an in-memory CacheStore,
+// which is bounded by the number of entries and their total size,
+// and evicts the least recently used entries first.
+type MemoryCacheStore struct {
+	// MaxEntries is the maximum number of stored responses.
+	//
+	// Default: 1024
+	MaxEntries int
+	// MaxBytes is the maximum total size of the stored responses.
+	//
+	// Default: 64MiB
+	MaxBytes int
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     list.List
+	bytes   int
+}
+
+type memoryCacheStoreEntry struct {
+	Key  string
+	Data []byte
+}
+
+func (s *MemoryCacheStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*memoryCacheStoreEntry).Data, true, nil
+}
+
+func (s *MemoryCacheStore) Set(ctx context.Context, key string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*list.Element)
+	}
+	s.remove(key)
+	if s.maxBytes() < len(data) {
+		return nil // it would evict everything, and still wouldn't fit
+	}
+	s.entries[key] = s.lru.PushFront(&memoryCacheStoreEntry{Key: key, Data: data})
+	s.bytes += len(data)
+	for s.maxEntries() < len(s.entries) || s.maxBytes() < s.bytes {
+		s.remove(s.lru.Back().Value.(*memoryCacheStoreEntry).Key)
+	}
+	return nil
+}
+
+func (s *MemoryCacheStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.remove(key)
+	return nil
+}
+
+// Len returns the number of stored responses.
+func (s *MemoryCacheStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryCacheStore) remove(key string) {
+	elem, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	s.lru.Remove(elem)
+	delete(s.entries, key)
+	s.bytes -= len(elem.Value.(*memoryCacheStoreEntry).Data)
+}
+
+func (s *MemoryCacheStore) maxEntries() int {
+	if s.MaxEntries <= 0 {
+		return 1024
+	}
+	return s.MaxEntries
+}
+
+func (s *MemoryCacheStore) maxBytes() int {
+	if s.MaxBytes <= 0 {
+		return 64 << 20
+	}
+	return s.MaxBytes
+}
+
+// FileSystemCacheStore is a CacheStore which keeps the responses as files in a filesystem.FileSystem,
+// which makes the cache survive restarts when it is backed by a disk.
+// When the FileSystem supports Rename, like localfs.FileSystem and memory.FileSystem,
+// a response is written into a temporary file first, and then renamed,
+// thus a concurrent Get or a crash never observes a partially written response.
+// Otherwise the response is written into its file in place.
+type FileSystemCacheStore struct {
+	FileSystem filesystem.FileSystem
+	// Directory is where the responses are stored.
+	// It is created together with its missing parent directories on the first Set.
+	//
+	// Default: the root of the FileSystem
+	Directory string
+
+	mutex  sync.Mutex
+	hasDir bool
+}
+
+func (s *FileSystemCacheStore) Get(ctx context.Context, key string) (_ []byte, _ bool, rErr error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	f, err := filesystem.Open(s.FileSystem, s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer errorkit.Finish(&rErr, f.Close)
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s *FileSystemCacheStore) Set(ctx context.Context, key string, data []byte) (rErr error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.mkdir(); err != nil {
+		return err
+	}
+	name := s.path(key)
+	renamer, ok := s.FileSystem.(fileSystemRenamer)
+	if !ok {
+		return s.write(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, data)
+	}
+	temp := name + ".tmp-" + random.New(random.CryptoSeed{}).StringNWithCharset(8, "0123456789abcdef") // unique between the concurrent Set calls
+	defer func() {
+		if rErr != nil {
+			_ = s.FileSystem.Remove(temp)
+		}
+	}()
+	if err := s.write(temp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, data); err != nil {
+		return err
+	}
+	return renamer.Rename(temp, name)
+}
+
+// fileSystemRenamer is implemented by the filesystem.FileSystem implementations which can rename a file.
+type fileSystemRenamer interface {
+	Rename(oldpath, newpath string) error
+}
+
+func (s *FileSystemCacheStore) write(name string, flag int, data []byte) error {
+	f, err := s.FileSystem.OpenFile(name, flag, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return errorkit.Merge(err, f.Close())
+}
+
+func (s *FileSystemCacheStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := s.FileSystem.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// mkdir creates the Directory and its missing parents, unless it is already done.
+// A failed attempt is retried on the next Set.
+func (s *FileSystemCacheStore) mkdir() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.hasDir || s.Directory == "" {
+		return nil
+	}
+	var dir string
+	for _, elem := range strings.Split(path.Clean(s.Directory), "/") {
+		if elem == "" { // the root of an absolute Directory
+			dir = "/"
+			continue
+		}
+		dir = path.Join(dir, elem)
+		if err := s.FileSystem.Mkdir(dir, 0700); err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	s.hasDir = true
+	return nil
+}
+
+// path maps the key to a file name, which is safe regardless of the characters in the URL.
+func (s *FileSystemCacheStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return path.Join(s.Directory, hex.EncodeToString(sum[:]))
+}
@@ -47,3 +47,10 @@ func lookupValue(ctx context.Context) (*ctxValue, bool) {
 	}
 	return nil, false
 }
+
+// ContextWithDetailsOf returns a copy of ctx which also carries the logging details of src.
+// It is useful when a background work should log like its caller,
+// but it must not inherit the rest of the caller's context values.
+func ContextWithDetailsOf(ctx, src context.Context) context.Context {
+	return ContextWith(ctx, getLoggingDetailsFromContext(src, nil)...)
+}
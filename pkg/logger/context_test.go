@@ -61,3 +61,36 @@ func TestContextWith(t *testing.T) {
 		assert.Contain(t, buf.String(), `"bar":42`)
 	})
 }
+
+func TestContextWithDetailsOf(t *testing.T) {
+	type ctxKey struct{}
+
+	t.Run("the logging details of the source context are carried over, but not its other values", func(t *testing.T) {
+		buf := logger.Stub(t)
+		src := context.WithValue(context.Background(), ctxKey{}, "value")
+		src = logger.ContextWith(src, logger.Field("foo", "bar"))
+		src = logger.ContextWith(src, logger.Field("bar", 42))
+
+		ctx := logger.ContextWithDetailsOf(context.Background(), src)
+		assert.Nil(t, ctx.Value(ctxKey{}))
+
+		logger.Info(ctx, "msg")
+		assert.Contain(t, buf.String(), `"foo":"bar"`)
+		assert.Contain(t, buf.String(), `"bar":42`)
+	})
+
+	t.Run("the logging details of the context are kept", func(t *testing.T) {
+		buf := logger.Stub(t)
+		src := logger.ContextWith(context.Background(), logger.Field("foo", "bar"))
+		ctx := logger.ContextWith(context.Background(), logger.Field("baz", "qux"))
+
+		logger.Info(logger.ContextWithDetailsOf(ctx, src), "msg")
+		assert.Contain(t, buf.String(), `"foo":"bar"`)
+		assert.Contain(t, buf.String(), `"baz":"qux"`)
+	})
+
+	t.Run("without logging details in the source context, the original context is returned", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+		assert.Equal(t, ctx, logger.ContextWithDetailsOf(ctx, context.Background()))
+	})
+}
@@ -101,36 +101,85 @@ func (r Client[Entity, ID]) Create(ctx context.Context, ptr *Entity) error {
 	return nil
 }
 
+// FindAll will iterate through the entities of the resource.
+// When the resource responds in pages, the next pages are requested lazily,
+// by following the "next" relation of the RFC 8288 Link header.
 func (r Client[Entity, ID]) FindAll(ctx context.Context) iterators.Iterator[Entity] {
 	baseURL, err := r.getBaseURL(ctx)
 	if err != nil {
 		return iterators.Error[Entity](err)
 	}
 
-	//mapping := r.getMapping()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pathkit.Join(baseURL, "/"), nil)
+	next, err := url.Parse(pathkit.Join(baseURL, "/"))
 	if err != nil {
 		return iterators.Error[Entity](err)
 	}
 
+	// pages yields the URL of the next page, once the response of the previous page is received.
+	pages := iterators.Func[*url.URL](func() (*url.URL, bool, error) {
+		if next == nil {
+			return nil, false, nil
+		}
+		pageURL := next
+		next = nil
+		return pageURL, true, nil
+	})
+	return iterators.FlatMap[Entity](pages, func(pageURL *url.URL) (iterators.Iterator[Entity], error) {
+		ents, link, err := r.findAllPage(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		next = link
+		return ents, nil
+	})
+}
+
+// findAllPage requests a single page of the index, and returns the URL of the next page, if there is one.
+// The entities of the page are decoded lazily from the response body.
+func (r Client[Entity, ID]) findAllPage(ctx context.Context, pageURL *url.URL) (_ iterators.Iterator[Entity], next *url.URL, rErr error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	req.Header.Set(headerKeyContentType, r.getMIMEType().String())
 	req.Header.Set(headerKeyAccept, r.getMIMEType().String())
 
 	resp, err := r.httpClient().Do(req)
 	if err != nil {
-		return iterators.Error[Entity](err)
+		return nil, nil, err
+	}
+	defer func() {
+		if rErr != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if !statusOK(resp) {
+		responseBody, err := bodyReadAll(resp.Body, DefaultBodyReadLimit)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, makeClientErrUnexpectedResponse(req, resp, responseBody)
+	}
+
+	if link, ok := parseLinkHeader(resp.Header.Values(headerKeyLink))["next"]; ok {
+		next, err = req.URL.Parse(link)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	mapping := r.getMapping()
 
 	mimeType, ser, ok := r.contentTypeBasedSerializer(resp)
 	if !ok {
-		return iterators.Error[Entity](fmt.Errorf("no serializer configured for response content type: %s", mimeType.String()))
+		return nil, nil, fmt.Errorf("no serializer configured for response content type: %s", mimeType.String())
 	}
 
 	dm, ok := ser.(serializers.ListDecoderMaker)
 	if !ok {
-		return iterators.Error[Entity](fmt.Errorf("no serializer found for the received mime type"))
+		return nil, nil, fmt.Errorf("no serializer found for the received mime type")
 	}
 
 	dec := dm.MakeListDecoder(resp.Body)
@@ -151,7 +200,7 @@ func (r Client[Entity, ID]) FindAll(ctx context.Context) iterators.Iterator[Enti
 		}
 
 		return ent, true, nil
-	}, iterators.OnClose(dec.Close))
+	}, iterators.OnClose(dec.Close), iterators.OnClose(resp.Body.Close)), next, nil
 }
 
 func (r Client[Entity, ID]) contentTypeBasedSerializer(resp *http.Response) (MIMEType, Serializer, bool) {
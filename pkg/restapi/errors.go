@@ -39,6 +39,11 @@ var ErrInvalidRequestBody = errorkit.UserError{
 	Message: "The request body is invalid.",
 }
 
+var ErrInvalidPagination = errorkit.UserError{
+	ID:      "invalid-pagination",
+	Message: "The requested page is invalid.",
+}
+
 var ErrInternalServerError = errorkit.UserError{
 	ID:      "internal-server-error",
 	Message: "An unexpected internal server error occurred.",
@@ -67,7 +72,8 @@ func ErrorMapping(ctx context.Context, err error, dto *rfc7807.DTO) {
 		errors.Is(err, ErrPathNotFound):
 		dto.Status = http.StatusNotFound
 	case errors.Is(err, ErrMalformedID),
-		errors.Is(err, ErrInvalidRequestBody):
+		errors.Is(err, ErrInvalidRequestBody),
+		errors.Is(err, ErrInvalidPagination):
 		dto.Status = http.StatusBadRequest
 	}
 }
@@ -0,0 +1,481 @@
+package restapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/frameless/pkg/mapkit"
+	"go.llib.dev/frameless/pkg/reflectkit"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification that Router.OpenAPI documents follow.
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIDocument is an OpenAPI 3.1 document, which describes the routes of a Router.
+// https://spec.openapis.org/oas/v3.1.0
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Servers    []OpenAPIServer            `json:"servers,omitempty"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIPathItem holds the operations of a path by their lowercase HTTP method name.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *OpenAPISchema `json:"schema,omitempty"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIHeader struct {
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenAPISchema `json:"schema,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+// OpenAPISchema is the subset of the JSON Schema, which is used to describe the DTOs.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPI will generate an OpenAPI document by walking the routes of the Router.
+//
+// A Resource is described with its supported operations, its ID path parameter, and its DTO schemas,
+// which are inferred from the Resource.Mapping and Resource.MappingForMIME.
+// Plain http.Handler routes, like the ones registered with Router.On and its HTTP verb shorthands,
+// are opaque to the Router, so their request and response can't be described,
+// thus they are not part of the document.
+func (router *Router) OpenAPI(info OpenAPIInfo) OpenAPIDocument {
+	b := newOpenAPIBuilder(info)
+	if router.root != nil {
+		b.walk("/", nil, router.root)
+	}
+	return b.doc
+}
+
+// ServeOpenAPI will register a GET endpoint on the given path, which serves the Router's OpenAPI document as JSON.
+// The document is generated on request, thus routes registered later are included as well.
+func (router *Router) ServeOpenAPI(path string, info OpenAPIInfo) {
+	router.Get(path, openAPIHandler{Router: router, Info: info})
+}
+
+type openAPIHandler struct {
+	Router *Router
+	Info   OpenAPIInfo
+}
+
+func (h openAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(h.Router.OpenAPI(h.Info))
+	if err != nil {
+		defaultErrorHandler.HandleError(w, r, ErrInternalServerError.With().Wrap(err))
+		return
+	}
+	w.Header().Set(headerKeyContentType, JSON.String())
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		logger.Debug(r.Context(), "error while writing back the OpenAPI document", logger.ErrField(err))
+	}
+}
+
+const openAPIErrorSchemaName = "ErrorDTO"
+
+type openAPIBuilder struct {
+	doc   OpenAPIDocument
+	names map[reflect.Type]string
+}
+
+func newOpenAPIBuilder(info OpenAPIInfo) *openAPIBuilder {
+	return &openAPIBuilder{
+		doc: OpenAPIDocument{
+			OpenAPI: OpenAPIVersion,
+			Info:    info,
+			Paths:   make(map[string]OpenAPIPathItem),
+			Components: OpenAPIComponents{Schemas: map[string]*OpenAPISchema{
+				openAPIErrorSchemaName: openAPIErrorSchema(),
+			}},
+		},
+		names: make(map[reflect.Type]string),
+	}
+}
+
+// openAPIErrorSchema describes the rfc7807.DTO, which is used by the default ErrorHandler.
+func openAPIErrorSchema() *OpenAPISchema {
+	return &OpenAPISchema{
+		Type:        "object",
+		Description: "RFC 7807 problem details",
+		Properties: map[string]*OpenAPISchema{
+			"type":     {Type: "string", Format: "uri-reference"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer"},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string"},
+		},
+		Required: []string{"type", "title"},
+	}
+}
+
+func (b *openAPIBuilder) walk(path string, params []OpenAPIParameter, node *_Node) {
+	if res, ok := node.defaultH.(resource); ok {
+		res.openAPI(b, path, params)
+	}
+	for _, name := range sortedKeys(node.fixNodes) {
+		b.walk(openAPIJoin(path, name), params, node.fixNodes[name])
+	}
+	if dn := node.dynNodes; dn.node != nil && 0 < len(dn.varnames) {
+		name := dn.varnames[0]
+		b.walk(openAPIJoin(path, "{"+name+"}"),
+			openAPIAppendParam(params, name, &OpenAPISchema{Type: "string"}),
+			dn.node)
+	}
+}
+
+func (b *openAPIBuilder) operation(path, method string, op *OpenAPIOperation) {
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = make(OpenAPIPathItem)
+		b.doc.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+func (res Resource[Entity, ID]) openAPI(b *openAPIBuilder, path string, params []OpenAPIParameter) {
+	var (
+		name     = reflectkit.TypeOf[Entity]().Name()
+		tags     []string
+		idName   = "id"
+		idPath   = openAPIJoin(path, "{id}")
+		idParams []OpenAPIParameter
+		content  = res.openAPIContent(b)
+	)
+	if name != "" {
+		tags = []string{name}
+		idName = openAPILowerFirst(name) + "ID"
+		idPath = openAPIJoin(path, "{"+idName+"}")
+	}
+	idParams = openAPIAppendParam(params, idName, b.schemaOf(reflectkit.TypeOf[ID]()))
+
+	if res.Index != nil {
+		items := make(map[string]OpenAPIMediaType, len(content))
+		for mimeType, mt := range content {
+			items[mimeType] = OpenAPIMediaType{Schema: &OpenAPISchema{Type: "array", Items: mt.Schema}}
+		}
+		var (
+			indexParams  = params
+			indexHeaders map[string]OpenAPIHeader
+			errorCodes   []int
+		)
+		if res.Pagination.enabled() {
+			indexParams, indexHeaders = res.Pagination.openAPI(params)
+			errorCodes = append(errorCodes, http.StatusBadRequest)
+		}
+		b.operation(path, http.MethodGet, &OpenAPIOperation{
+			Summary:    "List " + name,
+			Tags:       tags,
+			Parameters: indexParams,
+			Responses: openAPIResponses(map[string]OpenAPIResponse{
+				"200": {Description: "OK", Headers: indexHeaders, Content: items},
+			}, errorCodes...),
+		})
+	}
+	if res.Create != nil {
+		b.operation(path, http.MethodPost, &OpenAPIOperation{
+			Summary:     "Create " + name,
+			Tags:        tags,
+			Parameters:  params,
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: content},
+			Responses: openAPIResponses(map[string]OpenAPIResponse{
+				"201": {Description: "Created", Content: content},
+			}, http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge),
+		})
+	}
+	if res.DestroyAll != nil {
+		b.operation(path, http.MethodDelete, &OpenAPIOperation{
+			Summary:    "Delete all " + name,
+			Tags:       tags,
+			Parameters: params,
+			Responses: openAPIResponses(map[string]OpenAPIResponse{
+				"204": {Description: "No Content"},
+			}),
+		})
+	}
+	if res.Show != nil {
+		b.operation(idPath, http.MethodGet, &OpenAPIOperation{
+			Summary:    "Show " + name,
+			Tags:       tags,
+			Parameters: idParams,
+			Responses: openAPIResponses(map[string]OpenAPIResponse{
+				"200": {Description: "OK", Content: content},
+			}, http.StatusBadRequest, http.StatusNotFound),
+		})
+	}
+	if res.Update != nil {
+		b.operation(idPath, http.MethodPut, &OpenAPIOperation{
+			Summary:     "Update " + name,
+			Tags:        tags,
+			Parameters:  idParams,
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: content},
+			Responses: openAPIResponses(map[string]OpenAPIResponse{
+				"204": {Description: "No Content"},
+			}, http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge),
+		})
+	}
+	if res.Destroy != nil {
+		b.operation(idPath, http.MethodDelete, &OpenAPIOperation{
+			Summary:    "Delete " + name,
+			Tags:       tags,
+			Parameters: idParams,
+			Responses: openAPIResponses(map[string]OpenAPIResponse{
+				"204": {Description: "No Content"},
+			}, http.StatusBadRequest, http.StatusNotFound),
+		})
+	}
+	if sr, ok := res.SubRoutes.(*Router); ok && sr.root != nil {
+		b.walk(idPath, idParams, sr.root)
+	}
+}
+
+// openAPIContent describes the request and response bodies of the resource by their MIMEType.
+func (res Resource[Entity, ID]) openAPIContent(b *openAPIBuilder) map[string]OpenAPIMediaType {
+	mimeType := DefaultSerializer.MIMEType.Base()
+	content := map[string]OpenAPIMediaType{
+		mimeType.String(): {Schema: b.schemaOf(res.getMapping(mimeType).dtoType())},
+	}
+	for mimeType, mapping := range res.MappingForMIME {
+		content[mimeType.Base().String()] = OpenAPIMediaType{Schema: b.schemaOf(mapping.dtoType())}
+	}
+	return content
+}
+
+// openAPI describes the pagination query parameters and response headers of an Index operation.
+func (p Pagination) openAPI(params []OpenAPIParameter) ([]OpenAPIParameter, map[string]OpenAPIHeader) {
+	out := make([]OpenAPIParameter, 0, len(params)+2)
+	out = append(out, params...)
+	out = append(out, OpenAPIParameter{Name: queryKeyLimit, In: "query", Schema: &OpenAPISchema{Type: "integer"}})
+	if p.Cursor {
+		out = append(out, OpenAPIParameter{Name: queryKeyCursor, In: "query", Schema: &OpenAPISchema{Type: "string"}})
+	} else {
+		out = append(out, OpenAPIParameter{Name: queryKeyOffset, In: "query", Schema: &OpenAPISchema{Type: "integer"}})
+	}
+	headers := map[string]OpenAPIHeader{
+		headerKeyLink: {Description: "RFC 8288 links to the first, previous and next pages", Schema: &OpenAPISchema{Type: "string"}},
+	}
+	if p.Count != nil {
+		headers[headerKeyTotalCount] = OpenAPIHeader{Description: "total number of entities", Schema: &OpenAPISchema{Type: "integer"}}
+	}
+	return out, headers
+}
+
+// openAPIResponses adds the rfc7807 error responses to the successful responses of an operation.
+func openAPIResponses(responses map[string]OpenAPIResponse, errorCodes ...int) map[string]OpenAPIResponse {
+	for _, code := range append(errorCodes, http.StatusInternalServerError) {
+		responses[strconv.Itoa(code)] = OpenAPIResponse{
+			Description: http.StatusText(code),
+			Content: map[string]OpenAPIMediaType{
+				"application/problem+json": {Schema: &OpenAPISchema{Ref: "#/components/schemas/" + openAPIErrorSchemaName}},
+			},
+		}
+	}
+	return responses
+}
+
+var (
+	openAPITimeType          = reflectkit.TypeOf[time.Time]()
+	openAPIDurationType      = reflectkit.TypeOf[time.Duration]()
+	openAPITextMarshalerType = reflectkit.TypeOf[encoding.TextMarshaler]()
+)
+
+// schemaOf makes a JSON schema for the given type, based on how encoding/json would serialize it.
+// Named struct types are registered as components, and referenced by their name.
+func (b *openAPIBuilder) schemaOf(typ reflect.Type) *OpenAPISchema {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == openAPITimeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case typ == openAPIDurationType:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case typ.Implements(openAPITextMarshalerType) || reflect.PointerTo(typ).Implements(openAPITextMarshalerType):
+		return &OpenAPISchema{Type: "string"}
+	}
+	// the output of a json.Marshaler is unknown,
+	// but it is most likely encoded in the same shape as its underlying kind.
+	switch typ.Kind() {
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: b.schemaOf(typ.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: b.schemaOf(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return b.structSchemaOf(typ)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + b.componentOf(typ)}
+	default: // interface and the types which are not supported by encoding/json
+		return &OpenAPISchema{}
+	}
+}
+
+func (b *openAPIBuilder) componentOf(typ reflect.Type) string {
+	if name, ok := b.names[typ]; ok {
+		return name
+	}
+	name := openAPISchemaName(typ)
+	for i := 2; b.isNameTaken(name); i++ {
+		name = openAPISchemaName(typ) + strconv.Itoa(i)
+	}
+	b.names[typ] = name
+	// the name is reserved before the schema is made to support recursive types
+	b.doc.Components.Schemas[name] = &OpenAPISchema{}
+	*b.doc.Components.Schemas[name] = *b.structSchemaOf(typ)
+	return name
+}
+
+func (b *openAPIBuilder) isNameTaken(name string) bool {
+	_, ok := b.doc.Components.Schemas[name]
+	return ok
+}
+
+func (b *openAPIBuilder) structSchemaOf(typ reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	b.addFields(schema, typ)
+	return schema
+}
+
+func (b *openAPIBuilder) addFields(schema *OpenAPISchema, typ reflect.Type) {
+	for i, n := 0, typ.NumField(); i < n; i++ {
+		field := typ.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(schema, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldSchema := b.schemaOf(field.Type)
+		if strings.Contains(opts, "string") {
+			fieldSchema = &OpenAPISchema{Type: "string"}
+		}
+		schema.Properties[name] = fieldSchema
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func openAPISchemaName(typ reflect.Type) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, typ.Name())
+}
+
+func openAPIAppendParam(params []OpenAPIParameter, name string, schema *OpenAPISchema) []OpenAPIParameter {
+	out := make([]OpenAPIParameter, 0, len(params)+1)
+	out = append(out, params...)
+	return append(out, OpenAPIParameter{Name: name, In: "path", Required: true, Schema: schema})
+}
+
+func openAPIJoin(path, part string) string {
+	return strings.TrimSuffix(path, "/") + "/" + part
+}
+
+func openAPILowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	rs := []rune(s)
+	rs[0] = unicode.ToLower(rs[0])
+	return string(rs)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := mapkit.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
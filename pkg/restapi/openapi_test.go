@@ -0,0 +1,214 @@
+package restapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"go.llib.dev/frameless/pkg/restapi"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+)
+
+type OpenAPINote struct {
+	ID   int
+	Text string
+}
+
+type OpenAPINoteDTO struct {
+	ID       int               `json:"id"`
+	Text     string            `json:"text"`
+	Tags     []string          `json:"tags,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Owner    *XDTO             `json:"owner"`
+	Skip     string            `json:"-"`
+	Status   OpenAPIStatus     `json:"status"`
+	Priority OpenAPIPriority   `json:"priority"`
+}
+
+// OpenAPIStatus is encoded as text, while its underlying kind is an integer.
+type OpenAPIStatus int
+
+func (s OpenAPIStatus) MarshalText() ([]byte, error) { return []byte(strconv.Itoa(int(s))), nil }
+
+// OpenAPIPriority has a custom JSON encoding.
+type OpenAPIPriority int
+
+func (p OpenAPIPriority) MarshalJSON() ([]byte, error) { return json.Marshal(int(p)) }
+
+func TestRouter_OpenAPI(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		info      = testcase.LetValue(s, restapi.OpenAPIInfo{Title: "test", Version: "1.0.0"})
+		xResource = testcase.Let(s, func(t *testcase.T) restapi.Resource[X, XID] {
+			return restapi.Resource[X, XID]{
+				Mapping: restapi.DTOMapping[X, XDTO]{},
+				Create:  func(ctx context.Context, ptr *X) error { return nil },
+				Index: func(ctx context.Context, query url.Values) (iterators.Iterator[X], error) {
+					return iterators.Empty[X](), nil
+				},
+				Show:    func(ctx context.Context, id XID) (ent X, found bool, err error) { return X{}, false, nil },
+				Update:  func(ctx context.Context, id XID, ptr *X) error { return nil },
+				Destroy: func(ctx context.Context, id XID) error { return nil },
+				SubRoutes: restapi.NewRouter(func(r *restapi.Router) {
+					r.Resource("notes", restapi.Resource[OpenAPINote, int]{
+						Mapping: restapi.DTOMapping[OpenAPINote, OpenAPINoteDTO]{},
+						Show: func(ctx context.Context, id int) (ent OpenAPINote, found bool, err error) {
+							return OpenAPINote{}, false, nil
+						},
+					})
+				}),
+			}
+		})
+		router = testcase.Let(s, func(t *testcase.T) *restapi.Router {
+			return restapi.NewRouter(func(r *restapi.Router) {
+				r.Resource("/xs", xResource.Get(t))
+				r.Get("/status/:name", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+				r.ServeOpenAPI("/openapi.json", info.Get(t))
+			})
+		})
+		doc = testcase.Let(s, func(t *testcase.T) restapi.OpenAPIDocument {
+			return router.Get(t).OpenAPI(info.Get(t))
+		})
+	)
+
+	s.Test("document metadata", func(t *testcase.T) {
+		t.Must.Equal(restapi.OpenAPIVersion, doc.Get(t).OpenAPI)
+		t.Must.Equal("test", doc.Get(t).Info.Title)
+		t.Must.Equal("1.0.0", doc.Get(t).Info.Version)
+	})
+
+	s.Test("resource operations are inferred from the Resource", func(t *testcase.T) {
+		collection, ok := doc.Get(t).Paths["/xs"]
+		t.Must.True(ok)
+		t.Must.NotNil(collection["get"])
+		t.Must.NotNil(collection["post"])
+		t.Must.Nil(collection["delete"], "DestroyAll is not supported by the resource")
+
+		entity, ok := doc.Get(t).Paths["/xs/{xID}"]
+		t.Must.True(ok)
+		t.Must.NotNil(entity["get"])
+		t.Must.NotNil(entity["put"])
+		t.Must.NotNil(entity["delete"])
+	})
+
+	s.Test("path parameters are described", func(t *testcase.T) {
+		op := doc.Get(t).Paths["/xs/{xID}"]["get"]
+		t.Must.Equal(1, len(op.Parameters))
+		t.Must.Equal("xID", op.Parameters[0].Name)
+		t.Must.Equal("path", op.Parameters[0].In)
+		t.Must.True(op.Parameters[0].Required)
+		t.Must.Equal("integer", op.Parameters[0].Schema.Type)
+	})
+
+	s.Test("plain handler routes are not documented", func(t *testcase.T) {
+		_, ok := doc.Get(t).Paths["/status/{name}"]
+		t.Must.False(ok, "the request and response of a plain http.Handler is unknown")
+	})
+
+	s.Test("sub routes are nested under the entity path", func(t *testcase.T) {
+		op := doc.Get(t).Paths["/xs/{xID}/notes/{openAPINoteID}"]["get"]
+		t.Must.NotNil(op)
+		t.Must.Equal(2, len(op.Parameters))
+		t.Must.Equal("xID", op.Parameters[0].Name)
+		t.Must.Equal("openAPINoteID", op.Parameters[1].Name)
+	})
+
+	s.Test("DTO schemas are inferred from the Mapping", func(t *testcase.T) {
+		doc := doc.Get(t)
+		create := doc.Paths["/xs"]["post"]
+		t.Must.NotNil(create.RequestBody)
+		t.Must.Equal("#/components/schemas/XDTO", create.RequestBody.Content["application/json"].Schema.Ref)
+		t.Must.Equal("#/components/schemas/XDTO", create.Responses["201"].Content["application/json"].Schema.Ref)
+
+		index := doc.Paths["/xs"]["get"]
+		list := index.Responses["200"].Content["application/json"].Schema
+		t.Must.Equal("array", list.Type)
+		t.Must.Equal("#/components/schemas/XDTO", list.Items.Ref)
+
+		xdto, ok := doc.Components.Schemas["XDTO"]
+		t.Must.True(ok)
+		t.Must.Equal("object", xdto.Type)
+		t.Must.Equal("integer", xdto.Properties["id"].Type)
+		t.Must.Equal("integer", xdto.Properties["xnum"].Type)
+		t.Must.ContainExactly([]string{"id", "xnum"}, xdto.Required)
+
+		note, ok := doc.Components.Schemas["OpenAPINoteDTO"]
+		t.Must.True(ok)
+		t.Must.Equal("array", note.Properties["tags"].Type)
+		t.Must.Equal("string", note.Properties["tags"].Items.Type)
+		t.Must.Equal("object", note.Properties["meta"].Type)
+		t.Must.Equal("#/components/schemas/XDTO", note.Properties["owner"].Ref)
+		_, hasSkip := note.Properties["-"]
+		t.Must.False(hasSkip)
+		_, hasSkip = note.Properties["Skip"]
+		t.Must.False(hasSkip)
+		t.Must.ContainExactly([]string{"id", "text", "status", "priority"}, note.Required)
+	})
+
+	s.Test("encoding.TextMarshaler types are described as string", func(t *testcase.T) {
+		note := doc.Get(t).Components.Schemas["OpenAPINoteDTO"]
+		t.Must.Equal("string", note.Properties["status"].Type)
+	})
+
+	s.Test("json.Marshaler types are described by their underlying kind", func(t *testcase.T) {
+		note := doc.Get(t).Components.Schemas["OpenAPINoteDTO"]
+		t.Must.Equal("integer", note.Properties["priority"].Type)
+	})
+
+	s.Test("error responses are described as rfc7807 problem details", func(t *testcase.T) {
+		show := doc.Get(t).Paths["/xs/{xID}"]["get"]
+		for _, code := range []string{"400", "404", "500"} {
+			res, ok := show.Responses[code]
+			t.Must.True(ok, assert.Message(code))
+			t.Must.Equal("#/components/schemas/ErrorDTO", res.Content["application/problem+json"].Schema.Ref)
+		}
+		errDTO, ok := doc.Get(t).Components.Schemas["ErrorDTO"]
+		t.Must.True(ok)
+		t.Must.NotNil(errDTO.Properties["title"])
+		t.Must.NotNil(errDTO.Properties["status"])
+	})
+
+	s.Test("the document is served on the configured path", func(t *testcase.T) {
+		_, ok := doc.Get(t).Paths["/openapi.json"]
+		t.Must.False(ok, "the OpenAPI endpoint itself is not expected to be documented")
+
+		rr := httptest.NewRecorder()
+		router.Get(t).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		t.Must.Equal(http.StatusOK, rr.Code)
+		t.Must.Contain(rr.Header().Get("Content-Type"), "application/json")
+
+		var got restapi.OpenAPIDocument
+		t.Must.NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+		t.Must.Equal(restapi.OpenAPIVersion, got.OpenAPI)
+		t.Must.Equal(len(doc.Get(t).Paths), len(got.Paths))
+	})
+
+	s.When("the resource has a mapping for a specific MIME type", func(s *testcase.Spec) {
+		router.Let(s, func(t *testcase.T) *restapi.Router {
+			return restapi.NewRouter(func(r *restapi.Router) {
+				r.Resource("/ys", restapi.Resource[Y, string]{
+					Mapping: restapi.DTOMapping[Y, YDTO]{},
+					MappingForMIME: map[restapi.MIMEType]restapi.Mapping[Y]{
+						"application/x-ndjson": restapi.DTOMapping[Y, XDTO]{},
+					},
+					Show: func(ctx context.Context, id string) (ent Y, found bool, err error) { return Y{}, false, nil },
+				})
+			})
+		})
+
+		s.Then("the response is described per content type", func(t *testcase.T) {
+			op := doc.Get(t).Paths["/ys/{yID}"]["get"]
+			content := op.Responses["200"].Content
+			t.Must.Equal("#/components/schemas/YDTO", content["application/json"].Schema.Ref)
+			t.Must.Equal("#/components/schemas/XDTO", content["application/x-ndjson"].Schema.Ref)
+			t.Must.Equal("string", op.Parameters[0].Schema.Type)
+		})
+	})
+}
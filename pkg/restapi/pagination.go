@@ -0,0 +1,255 @@
+package restapi
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.llib.dev/frameless/ports/iterators"
+)
+
+// Pagination configures the paginated responses of a Resource.Index.
+//
+// The page is selected with the "limit" and the "offset" query parameters,
+// or with the "cursor" query parameter when Pagination.Cursor is enabled.
+// The navigation between the pages is described with RFC 8288 Link headers,
+// and the total number of entities is reported in the X-Total-Count header when Pagination.Count is supplied.
+//
+// The Resource.Index receives the query without the pagination parameters,
+// and the requested Page is available in its context through LookupPage.
+// By default, the page is cut out from the iterator returned by the Resource.Index,
+// which means the skipped entities are iterated through for every page,
+// thus the Resource.Index is expected to return its results in a stable order.
+// When the Resource.Index can apply the Page itself, for example with an OFFSET in its SQL query,
+// then Pagination.PushDown lets the Resource.Index take over the skipping.
+//
+// The cursor is an opaque encoding of the offset, not a reference to the last seen entity,
+// so entities created or deleted between two page requests can shift the pages.
+type Pagination struct {
+	// PageSize is the number of entities per page, unless the request asks for a different limit.
+	// Pagination is disabled when PageSize is zero.
+	PageSize int
+	// MaxPageSize is the upper limit of the page size that a request can ask for.
+	//
+	// Default: PageSize
+	MaxPageSize int
+	// Cursor makes the pages addressed with an opaque "cursor" query parameter instead of the "offset".
+	Cursor bool
+	// Count is an optional function that returns the total number of entities for the index query.
+	Count func(ctx context.Context, query url.Values) (int, error)
+	// PushDown tells that the Resource.Index applies the Page from its context,
+	// and it returns the entities starting from the Page.Offset.
+	// Entities beyond the Page.Limit are ignored, except for the first one,
+	// which tells whether there is a next page.
+	PushDown bool
+}
+
+// Page is the part of the index requested by a paginated Resource.Index call.
+type Page struct {
+	// Offset is the number of entities skipped before the page.
+	Offset int
+	// Limit is the maximum number of entities on the page.
+	Limit int
+}
+
+type ctxKeyPage struct{}
+
+// LookupPage returns the Page requested from the Resource.Index,
+// when the Resource has Pagination enabled.
+func LookupPage(ctx context.Context) (Page, bool) {
+	if ctx == nil {
+		return Page{}, false
+	}
+	pg, ok := ctx.Value(ctxKeyPage{}).(Page)
+	return pg, ok
+}
+
+func contextWithPage(ctx context.Context, pg Page) context.Context {
+	return context.WithValue(ctx, ctxKeyPage{}, pg)
+}
+
+// indexQuery returns the query without the pagination parameters.
+func (p Pagination) indexQuery(query url.Values) url.Values {
+	out := make(url.Values, len(query))
+	for key, vs := range query {
+		switch key {
+		case queryKeyLimit, queryKeyOffset, queryKeyCursor:
+			continue
+		}
+		out[key] = vs
+	}
+	return out
+}
+
+const (
+	queryKeyLimit  = "limit"
+	queryKeyOffset = "offset"
+	queryKeyCursor = "cursor"
+
+	headerKeyLink       = "Link"
+	headerKeyTotalCount = "X-Total-Count"
+)
+
+func (p Pagination) enabled() bool {
+	return 0 < p.PageSize
+}
+
+func (p Pagination) getMaxPageSize() int {
+	if p.MaxPageSize < p.PageSize {
+		return p.PageSize
+	}
+	return p.MaxPageSize
+}
+
+func (p Pagination) pageOf(query url.Values) (Page, error) {
+	pg := Page{Limit: p.PageSize}
+	if raw := query.Get(queryKeyLimit); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return pg, ErrInvalidPagination.With().Detail(fmt.Sprintf("invalid %s: %q", queryKeyLimit, raw))
+		}
+		pg.Limit = limit
+		if maxPageSize := p.getMaxPageSize(); maxPageSize < limit {
+			pg.Limit = maxPageSize
+		}
+	}
+	var (
+		key = queryKeyOffset
+		raw = query.Get(queryKeyOffset)
+	)
+	if p.Cursor {
+		key, raw = queryKeyCursor, query.Get(queryKeyCursor)
+	}
+	if raw == "" {
+		return pg, nil
+	}
+	offset, ok := p.parsePosition(raw)
+	if !ok {
+		return pg, ErrInvalidPagination.With().Detail(fmt.Sprintf("invalid %s: %q", key, raw))
+	}
+	pg.Offset = offset
+	return pg, nil
+}
+
+func (p Pagination) parsePosition(raw string) (int, bool) {
+	if p.Cursor {
+		data, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil {
+			return 0, false
+		}
+		raw = string(data)
+	}
+	offset, err := strconv.Atoi(raw)
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	return offset, true
+}
+
+func (p Pagination) formatPosition(offset int) string {
+	raw := strconv.Itoa(offset)
+	if p.Cursor {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	return raw
+}
+
+// paginate cuts out the requested page from the index iterator,
+// and sets the pagination headers of the response.
+func paginate[T any](p Pagination, w http.ResponseWriter, r *http.Request, index iterators.Iterator[T], pg Page, total *int) (iterators.Iterator[T], error) {
+	if !p.PushDown {
+		index = iterators.Offset(index, pg.Offset)
+	}
+	vs, err := iterators.Collect(iterators.Head(index, pg.Limit+1))
+	if err != nil {
+		return nil, err
+	}
+	hasNext := pg.Limit < len(vs)
+	if hasNext {
+		vs = vs[:pg.Limit]
+	}
+
+	var links []string
+	link := func(rel string, offset int) {
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", p.pageURL(r, Page{Offset: offset, Limit: pg.Limit}), rel))
+	}
+	link("first", 0)
+	if 0 < pg.Offset {
+		prev := pg.Offset - pg.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		link("prev", prev)
+	}
+	if hasNext {
+		link("next", pg.Offset+pg.Limit)
+	}
+	if total != nil {
+		w.Header().Set(headerKeyTotalCount, strconv.Itoa(*total))
+		if !p.Cursor && 0 < *total {
+			link("last", (*total-1)/pg.Limit*pg.Limit)
+		}
+	}
+	w.Header().Set(headerKeyLink, strings.Join(links, ", "))
+	return iterators.Slice(vs), nil
+}
+
+func (p Pagination) pageURL(r *http.Request, pg Page) string {
+	path := r.URL.Path
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil && r.RequestURI != "" {
+		// RequestURI is preferred, because the URL.Path might be stripped by the handlers above
+		path = u.Path
+	}
+	query := r.URL.Query()
+	query.Del(queryKeyOffset)
+	query.Del(queryKeyCursor)
+	query.Set(queryKeyLimit, strconv.Itoa(pg.Limit))
+	if p.Cursor {
+		query.Set(queryKeyCursor, p.formatPosition(pg.Offset))
+	} else {
+		query.Set(queryKeyOffset, p.formatPosition(pg.Offset))
+	}
+	return (&url.URL{Path: path, RawQuery: query.Encode()}).String()
+}
+
+// parseLinkHeader parses the RFC 8288 Link header values into a map of target URLs by their relation type.
+func parseLinkHeader(values []string) map[string]string {
+	links := make(map[string]string)
+	for _, value := range values {
+		for value != "" {
+			start := strings.IndexByte(value, '<')
+			if start < 0 {
+				break
+			}
+			end := strings.IndexByte(value[start:], '>')
+			if end < 0 {
+				break
+			}
+			target := value[start+1 : start+end]
+			value = value[start+end+1:]
+
+			params := value
+			if next := strings.IndexByte(value, '<'); 0 <= next {
+				params, value = value[:next], value[next:]
+			} else {
+				value = ""
+			}
+			for _, param := range strings.Split(params, ";") {
+				k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(k), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(v), `", `)) {
+					if _, ok := links[strings.ToLower(rel)]; !ok {
+						links[strings.ToLower(rel)] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
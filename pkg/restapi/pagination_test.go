@@ -0,0 +1,352 @@
+package restapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/pkg/restapi"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+)
+
+func makePaginatedXResource(n int, pagination restapi.Pagination) restapi.Resource[X, XID] {
+	var xs []X
+	for i := 1; i <= n; i++ {
+		xs = append(xs, X{ID: XID(i), N: i})
+	}
+	return restapi.Resource[X, XID]{
+		Mapping: restapi.DTOMapping[X, XDTO]{},
+		Index: func(ctx context.Context, query url.Values) (iterators.Iterator[X], error) {
+			return iterators.Slice(xs), nil
+		},
+		Pagination: pagination,
+	}
+}
+
+func indexPage(tb testing.TB, h http.Handler, target string) ([]XDTO, *httptest.ResponseRecorder) {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	if rr.Code != http.StatusOK {
+		return nil, rr
+	}
+	var dtos []XDTO
+	assert.NoError(tb, json.Unmarshal(rr.Body.Bytes(), &dtos))
+	return dtos, rr
+}
+
+func linkOf(tb testing.TB, rr *httptest.ResponseRecorder, rel string) (string, bool) {
+	for _, link := range strings.Split(rr.Header().Get("Link"), ", ") {
+		target, params, ok := strings.Cut(link, ">; ")
+		if ok && params == `rel="`+rel+`"` {
+			return strings.TrimPrefix(target, "<"), true
+		}
+	}
+	return "", false
+}
+
+func TestResource_Pagination(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		n          = testcase.LetValue(s, 5)
+		pagination = testcase.Let(s, func(t *testcase.T) restapi.Pagination { return restapi.Pagination{PageSize: 2} })
+		resource   = testcase.Let(s, func(t *testcase.T) restapi.Resource[X, XID] {
+			return makePaginatedXResource(n.Get(t), pagination.Get(t))
+		})
+		router = testcase.Let(s, func(t *testcase.T) *restapi.Router {
+			return restapi.NewRouter(func(r *restapi.Router) {
+				r.Resource("/xs", resource.Get(t))
+			})
+		})
+	)
+	// spyIndex wraps the Index of the resource with an inspection of its arguments.
+	spyIndex := func(s *testcase.Spec, spy func(t *testcase.T, ctx context.Context, query url.Values)) {
+		resource.Let(s, func(t *testcase.T) restapi.Resource[X, XID] {
+			res := resource.Super(t)
+			index := res.Index
+			res.Index = func(ctx context.Context, query url.Values) (iterators.Iterator[X], error) {
+				spy(t, ctx, query)
+				return index(ctx, query)
+			}
+			return res
+		})
+	}
+
+	s.Test("offset based pages are linked together", func(t *testcase.T) {
+		dtos, rr := indexPage(t, router.Get(t), "/xs")
+		t.Must.Equal([]XDTO{{ID: 1, X: 1}, {ID: 2, X: 2}}, dtos)
+		_, ok := linkOf(t, rr, "prev")
+		t.Must.False(ok)
+		next, ok := linkOf(t, rr, "next")
+		t.Must.True(ok)
+		t.Must.Equal("/xs?limit=2&offset=2", next)
+
+		dtos, rr = indexPage(t, router.Get(t), next)
+		t.Must.Equal([]XDTO{{ID: 3, X: 3}, {ID: 4, X: 4}}, dtos)
+		prev, ok := linkOf(t, rr, "prev")
+		t.Must.True(ok)
+		t.Must.Equal("/xs?limit=2&offset=0", prev)
+		next, ok = linkOf(t, rr, "next")
+		t.Must.True(ok)
+
+		dtos, rr = indexPage(t, router.Get(t), next)
+		t.Must.Equal([]XDTO{{ID: 5, X: 5}}, dtos)
+		_, ok = linkOf(t, rr, "next")
+		t.Must.False(ok, "last page should not have a next link")
+		first, ok := linkOf(t, rr, "first")
+		t.Must.True(ok)
+		t.Must.Equal("/xs?limit=2&offset=0", first)
+	})
+
+	s.Test("the other query parameters are kept in the links", func(t *testcase.T) {
+		_, rr := indexPage(t, router.Get(t), "/xs?q=foo")
+		next, ok := linkOf(t, rr, "next")
+		t.Must.True(ok)
+		u, err := url.Parse(next)
+		t.Must.NoError(err)
+		t.Must.Equal("foo", u.Query().Get("q"))
+	})
+
+	s.Test("invalid pagination query is a bad request", func(t *testcase.T) {
+		for _, target := range []string{"/xs?limit=0", "/xs?limit=abc", "/xs?offset=-1", "/xs?offset=abc"} {
+			_, rr := indexPage(t, router.Get(t), target)
+			t.Must.Equal(http.StatusBadRequest, rr.Code, assert.Message(target))
+		}
+	})
+
+	s.Test("pagination is described in the OpenAPI document", func(t *testcase.T) {
+		op := router.Get(t).OpenAPI(restapi.OpenAPIInfo{Title: "test", Version: "1"}).Paths["/xs"]["get"]
+		var names []string
+		for _, param := range op.Parameters {
+			names = append(names, param.Name)
+		}
+		t.Must.ContainExactly([]string{"limit", "offset"}, names)
+		_, ok := op.Responses["200"].Headers["Link"]
+		t.Must.True(ok)
+	})
+
+	s.When("the Index is called", func(s *testcase.Spec) {
+		type call struct {
+			Query url.Values
+			Page  restapi.Page
+			Found bool
+		}
+		got := testcase.Let(s, func(t *testcase.T) *call { return &call{} })
+		spyIndex(s, func(t *testcase.T, ctx context.Context, query url.Values) {
+			c := got.Get(t)
+			c.Query = query
+			c.Page, c.Found = restapi.LookupPage(ctx)
+		})
+
+		s.Then("it receives the page in its context, and the query without the pagination parameters", func(t *testcase.T) {
+			dtos, _ := indexPage(t, router.Get(t), "/xs?q=foo&limit=2&offset=2")
+			t.Must.Equal([]XDTO{{ID: 3, X: 3}, {ID: 4, X: 4}}, dtos)
+			t.Must.Equal(url.Values{"q": {"foo"}}, got.Get(t).Query)
+			t.Must.True(got.Get(t).Found)
+			t.Must.Equal(restapi.Page{Offset: 2, Limit: 2}, got.Get(t).Page)
+		})
+	})
+
+	s.When("Pagination is not configured", func(s *testcase.Spec) {
+		pagination.Let(s, func(t *testcase.T) restapi.Pagination { return restapi.Pagination{} })
+
+		s.Then("every entity is returned", func(t *testcase.T) {
+			dtos, rr := indexPage(t, router.Get(t), "/xs")
+			t.Must.Equal(http.StatusOK, rr.Code)
+			t.Must.Equal(5, len(dtos))
+			t.Must.Empty(rr.Header().Get("Link"))
+		})
+
+		s.And("the request has pagination parameters", func(s *testcase.Spec) {
+			gotQuery := testcase.Let(s, func(t *testcase.T) *url.Values { return &url.Values{} })
+			spyIndex(s, func(t *testcase.T, ctx context.Context, query url.Values) {
+				*gotQuery.Get(t) = query
+				_, ok := restapi.LookupPage(ctx)
+				t.Should.False(ok)
+			})
+
+			s.Then("they are left to the Index", func(t *testcase.T) {
+				indexPage(t, router.Get(t), "/xs?limit=2")
+				t.Must.Equal("2", gotQuery.Get(t).Get("limit"))
+			})
+		})
+	})
+
+	s.When("MaxPageSize is configured", func(s *testcase.Spec) {
+		n.LetValue(s, 10)
+		pagination.Let(s, func(t *testcase.T) restapi.Pagination { return restapi.Pagination{PageSize: 2, MaxPageSize: 3} })
+
+		s.Then("the requested limit is capped with it", func(t *testcase.T) {
+			dtos, _ := indexPage(t, router.Get(t), "/xs?limit=3")
+			t.Must.Equal(3, len(dtos))
+			dtos, _ = indexPage(t, router.Get(t), "/xs?limit=100")
+			t.Must.Equal(3, len(dtos))
+		})
+	})
+
+	s.When("Count is supplied", func(s *testcase.Spec) {
+		pagination.Let(s, func(t *testcase.T) restapi.Pagination {
+			p := pagination.Super(t)
+			p.Count = func(ctx context.Context, query url.Values) (int, error) {
+				return n.Get(t), nil
+			}
+			return p
+		})
+
+		s.Then("the total count is reported", func(t *testcase.T) {
+			_, rr := indexPage(t, router.Get(t), "/xs")
+			t.Must.Equal("5", rr.Header().Get("X-Total-Count"))
+			last, ok := linkOf(t, rr, "last")
+			t.Must.True(ok)
+			t.Must.Equal("/xs?limit=2&offset=4", last)
+		})
+	})
+
+	s.When("Cursor is enabled", func(s *testcase.Spec) {
+		n.LetValue(s, 3)
+		pagination.Let(s, func(t *testcase.T) restapi.Pagination { return restapi.Pagination{PageSize: 2, Cursor: true} })
+
+		s.Then("the pages are linked with a cursor instead of an offset", func(t *testcase.T) {
+			dtos, rr := indexPage(t, router.Get(t), "/xs")
+			t.Must.Equal(2, len(dtos))
+			next, ok := linkOf(t, rr, "next")
+			t.Must.True(ok)
+			u, err := url.Parse(next)
+			t.Must.NoError(err)
+			t.Must.NotEmpty(u.Query().Get("cursor"))
+			t.Must.Empty(u.Query().Get("offset"))
+
+			dtos, rr = indexPage(t, router.Get(t), next)
+			t.Must.Equal([]XDTO{{ID: 3, X: 3}}, dtos)
+			_, ok = linkOf(t, rr, "next")
+			t.Must.False(ok)
+		})
+
+		s.Then("an invalid cursor is a bad request", func(t *testcase.T) {
+			_, rr := indexPage(t, router.Get(t), "/xs?cursor=%21invalid")
+			t.Must.Equal(http.StatusBadRequest, rr.Code)
+		})
+	})
+
+	s.When("PushDown is enabled", func(s *testcase.Spec) {
+		pagination.Let(s, func(t *testcase.T) restapi.Pagination { return restapi.Pagination{PageSize: 2, PushDown: true} })
+		resource.Let(s, func(t *testcase.T) restapi.Resource[X, XID] {
+			res := resource.Super(t)
+			index := res.Index
+			res.Index = func(ctx context.Context, query url.Values) (iterators.Iterator[X], error) {
+				pg, ok := restapi.LookupPage(ctx)
+				t.Should.True(ok)
+				all, err := index(ctx, query)
+				if err != nil {
+					return nil, err
+				}
+				return iterators.Offset(all, pg.Offset), nil
+			}
+			return res
+		})
+
+		s.Then("the Index applies the page itself", func(t *testcase.T) {
+			dtos, rr := indexPage(t, router.Get(t), "/xs?offset=2")
+			t.Must.Equal([]XDTO{{ID: 3, X: 3}, {ID: 4, X: 4}}, dtos)
+			_, ok := linkOf(t, rr, "next")
+			t.Must.True(ok)
+		})
+	})
+}
+
+func TestClient_FindAll_pagination(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		requests = testcase.Let(s, func(t *testcase.T) *int32 { return new(int32) })
+		handler  = testcase.Let(s, func(t *testcase.T) http.Handler {
+			return makePaginatedXResource(5, restapi.Pagination{PageSize: 2})
+		})
+		srv = testcase.Let(s, func(t *testcase.T) *httptest.Server {
+			requests, handler := requests.Get(t), handler.Get(t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(requests, 1)
+				handler.ServeHTTP(w, r)
+			}))
+			t.Defer(srv.Close)
+			return srv
+		})
+		client = testcase.Let(s, func(t *testcase.T) restapi.Client[X, XID] {
+			return restapi.Client[X, XID]{
+				HTTPClient: srv.Get(t).Client(),
+				BaseURL:    srv.Get(t).URL,
+				Mapping:    restapi.DTOMapping[X, XDTO]{},
+			}
+		})
+		ctx = testcase.LetValue(s, context.Background())
+	)
+
+	s.Test("next links are followed", func(t *testcase.T) {
+		xs, err := iterators.Collect(client.Get(t).FindAll(ctx.Get(t)))
+		t.Must.NoError(err)
+		t.Must.Equal([]X{{ID: 1, N: 1}, {ID: 2, N: 2}, {ID: 3, N: 3}, {ID: 4, N: 4}, {ID: 5, N: 5}}, xs)
+		t.Must.Equal(int32(3), atomic.LoadInt32(requests.Get(t)))
+	})
+
+	s.Test("next pages are requested lazily", func(t *testcase.T) {
+		x, found, err := iterators.First(client.Get(t).FindAll(ctx.Get(t)))
+		t.Must.NoError(err)
+		t.Must.True(found)
+		t.Must.Equal(X{ID: 1, N: 1}, x)
+		t.Must.Equal(int32(1), atomic.LoadInt32(requests.Get(t)))
+	})
+
+	s.When("the page is streamed slowly", func(s *testcase.Spec) {
+		release := testcase.Let(s, func(t *testcase.T) chan struct{} {
+			ch := make(chan struct{})
+			t.Defer(func() { close(ch) })
+			return ch
+		})
+		handler.Let(s, func(t *testcase.T) http.Handler {
+			release := release.Get(t)
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`[{"id":1,"xnum":1}`))
+				w.(http.Flusher).Flush()
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+				_, _ = w.Write([]byte(`,{"id":2,"xnum":2}]`))
+			})
+		})
+		ctx.Let(s, func(t *testcase.T) context.Context {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			t.Defer(cancel)
+			return ctx
+		})
+
+		s.Then("the entities of a page are decoded as they arrive", func(t *testcase.T) {
+			x, found, err := iterators.First(client.Get(t).FindAll(ctx.Get(t)))
+			t.Must.NoError(err)
+			t.Must.True(found)
+			t.Must.Equal(X{ID: 1, N: 1}, x)
+		})
+	})
+
+	s.When("the response is unexpected", func(s *testcase.Spec) {
+		client.Let(s, func(t *testcase.T) restapi.Client[X, XID] {
+			c := client.Super(t)
+			c.BaseURL = srv.Get(t).URL + "/?limit=0"
+			return c
+		})
+
+		s.Then("it is returned as an error", func(t *testcase.T) {
+			_, err := iterators.Collect(client.Get(t).FindAll(ctx.Get(t)))
+			t.Must.Error(err)
+		})
+	})
+}
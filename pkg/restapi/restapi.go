@@ -18,6 +18,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 )
 
@@ -80,6 +81,10 @@ type Resource[Entity, ID any] struct {
 	// For example, "/users/42/jobs" will end up as "/jobs".
 	SubRoutes http.Handler
 
+	// Pagination is an optional configuration to serve the Index results in pages.
+	// Without it, the Index responds with every entity.
+	Pagination Pagination
+
 	// BodyReadLimit is the max bytes that the handler is willing to read from the request body.
 	//
 	// The default value is DefaultBodyReadLimit, which is preset to 16MB.
@@ -88,6 +93,7 @@ type Resource[Entity, ID any] struct {
 
 type resource interface {
 	resource()
+	openAPI(b *openAPIBuilder, path string, params []OpenAPIParameter)
 	http.Handler
 }
 
@@ -128,6 +134,7 @@ func passthroughMappingMode[Entity any]() DTOMapping[Entity, Entity] {
 // It is implemented by DTOMapping.
 type Mapping[Entity any] interface {
 	newDTO() (dtoPtr any)
+	dtoType() reflect.Type
 	toEnt(ctx context.Context, dtoPtr any) (Entity, error)
 	toDTO(ctx context.Context, ent Entity) (DTO any, _ error)
 }
@@ -147,6 +154,8 @@ type DTOMapping[Entity, DTO any] struct {
 
 func (dto DTOMapping[Entity, DTO]) newDTO() any { return new(DTO) }
 
+func (dto DTOMapping[Entity, DTO]) dtoType() reflect.Type { return reflectkit.TypeOf[DTO]() }
+
 func (dto DTOMapping[Entity, DTO]) toEnt(ctx context.Context, dtoPtr any) (Entity, error) {
 	if dtoPtr == nil {
 		return *new(Entity), fmt.Errorf("nil dto ptr")
@@ -280,9 +289,23 @@ func (res Resource[Entity, ID]) index(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
+	var (
+		ctx   = r.Context()
+		query = r.URL.Query()
+		pg    Page
+	)
+	if res.Pagination.enabled() {
+		p, err := res.Pagination.pageOf(query)
+		if err != nil {
+			res.getErrorHandler().HandleError(w, r, err)
+			return
+		}
+		pg = p
+		ctx = contextWithPage(ctx, pg)
+		query = res.Pagination.indexQuery(query)
+	}
 
-	index, err := res.Index(ctx, r.URL.Query())
+	index, err := res.Index(ctx, query)
 	if err != nil {
 		res.getErrorHandler().HandleError(w, r, err)
 		return
@@ -299,6 +322,23 @@ func (res Resource[Entity, ID]) index(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if res.Pagination.enabled() {
+		var total *int
+		if res.Pagination.Count != nil {
+			n, err := res.Pagination.Count(ctx, query)
+			if err != nil {
+				res.getErrorHandler().HandleError(w, r, err)
+				return
+			}
+			total = &n
+		}
+		index, err = paginate(res.Pagination, w, r, index, pg, total)
+		if err != nil {
+			res.getErrorHandler().HandleError(w, r, err)
+			return
+		}
+	}
+
 	resSer, resMIMEType := res.Serialization.responseBodySerializer(r) // TODO:TEST_ME
 	resMapping := res.getMapping(resMIMEType)
 
@@ -0,0 +1,338 @@
+// Package saga supplies orchestration for multi-step business processes,
+// where a failure in a later step requires the compensation of the already completed steps.
+package saga
+
+import (
+	"context"
+	"fmt"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/ports/comproto"
+	"go.llib.dev/frameless/ports/crud"
+	"go.llib.dev/frameless/ports/pubsub"
+)
+
+// HeaderSagaID is the header that holds the ID of the saga instance.
+// The commands published during a step's Action carry this header,
+// so the replies can be correlated back to the saga with Saga.Reply.
+const HeaderSagaID = "saga-id"
+
+const (
+	ErrStepNotFound errorkit.Error = "saga step not found"
+	ErrNotAwaiting  errorkit.Error = "saga is not awaiting a reply"
+)
+
+type Status string
+
+const (
+	// StatusRunning means that the saga executes its steps.
+	StatusRunning Status = "running"
+	// StatusAwaiting means that the saga waits for the reply of its current step.
+	StatusAwaiting Status = "awaiting"
+	// StatusCompensating means that a step failed, and the saga undoes the completed steps.
+	StatusCompensating Status = "compensating"
+	// StatusCompleted means that all the steps succeeded.
+	StatusCompleted Status = "completed"
+	// StatusCompensated means that all the completed steps are compensated after a failure.
+	StatusCompensated Status = "compensated"
+)
+
+// State is the persisted state of a saga instance.
+type State[Data any] struct {
+	ID     string `ext:"id"`
+	Status Status
+	// Step is the index of the current step.
+	Step int
+	// Compensations is the persisted compensation log.
+	// It holds the names of the completed steps in their execution order,
+	// and the compensations are executed in reverse order, just like with txs.OnRollback.
+	Compensations []string
+	// Data is the business data of the saga instance.
+	Data Data
+	// Error is the message of the step failure which triggered the compensation.
+	Error string
+}
+
+// Done reports whether the saga instance reached a final status.
+func (s State[Data]) Done() bool {
+	return s.Status == StatusCompleted || s.Status == StatusCompensated
+}
+
+// Repository is the minimum expected interface for persisting the saga states.
+type Repository[Data any] interface {
+	crud.Creator[State[Data]]
+	crud.ByIDFinder[State[Data], string]
+	crud.Updater[State[Data]]
+}
+
+// Queue is the pubsub queue which drives the execution of the saga instances.
+type Queue interface {
+	pubsub.Publisher[Event]
+	pubsub.Subscriber[Event]
+}
+
+// Event signals that a saga instance has pending work.
+// Each Event handling makes one unit of progress, either executes a step or a compensation.
+type Event struct {
+	SagaID string
+}
+
+type Step[Data any] struct {
+	// Name is the identifier of the step in the persisted compensation log.
+	// It must be unique within the saga, and stable between deployments.
+	Name string
+	// Action executes the step.
+	// Changes made on the data are persisted when the Action succeeds.
+	// Messages published with the Action's context carry the HeaderSagaID header.
+	Action func(ctx context.Context, data *Data) error
+	// Compensation is an optional function that undoes the effects of a completed Action.
+	Compensation func(ctx context.Context, data Data) error
+	// AwaitReply makes the saga wait after the Action until the outcome of the step is reported with Saga.Reply.
+	// This allows steps to issue commands to other services, and react to their reply messages.
+	// The Compensation of an awaiting step is only registered when the step's reply reports a success.
+	AwaitReply bool
+}
+
+// Saga orchestrates a multi-step business process.
+// The saga state is persisted in the Repository,
+// and the execution is driven through the Queue,
+// thus a saga instance survives restarts, and continues where it left off.
+//
+// When a step fails, the Compensation of the completed steps are executed in Last-In-First-Out order.
+//
+// Saga.Run is a tasker.Task compatible function.
+type Saga[Data any] struct {
+	Steps      []Step[Data]
+	Repository Repository[Data]
+	Queue      Queue
+	// OnePhaseCommitProtocol is an optional transaction manager.
+	// When supplied, the state changes and the publishing of the next Event happen in the same transaction.
+	OnePhaseCommitProtocol comproto.OnePhaseCommitProtocol
+	// Concurrency is the maximum number of events handled in parallel by Run.
+	//
+	// Default: 1
+	Concurrency int
+}
+
+// Start creates a new saga instance with the initial data, and schedules its first step.
+func (s Saga[Data]) Start(ctx context.Context, data Data) (_ string, rErr error) {
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	ctx, err := s.beginTx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer s.finishTx(&rErr, ctx)
+	state := State[Data]{
+		Status: StatusRunning,
+		Data:   data,
+	}
+	if err := s.Repository.Create(ctx, &state); err != nil {
+		return "", err
+	}
+	if err := s.Queue.Publish(ctx, Event{SagaID: state.ID}); err != nil {
+		return "", err
+	}
+	return state.ID, nil
+}
+
+// Run handles the saga events from the Queue until the context is cancelled.
+func (s Saga[Data]) Run(ctx context.Context) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	return pubsub.Consumer[Event]{
+		Subscriber:  s.Queue,
+		Handler:     s.Handle,
+		Concurrency: s.Concurrency,
+	}.Run(ctx)
+}
+
+// Handle makes one unit of progress on the saga instance of the event.
+// It is meant to be used when the saga events are consumed manually,
+// for example to drive a saga step by step in a test.
+// Events of an awaiting or finished saga instance are ignored.
+func (s Saga[Data]) Handle(ctx context.Context, event Event) (rErr error) {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	ctx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.finishTx(&rErr, ctx)
+	state, err := s.findState(ctx, event.SagaID)
+	if err != nil {
+		return err
+	}
+	switch state.Status {
+	case StatusRunning:
+		return s.execute(ctx, state)
+	case StatusCompensating:
+		return s.compensate(ctx, state)
+	default: // awaiting a reply or already done
+		return nil
+	}
+}
+
+// Reply reports the outcome of an awaiting step.
+// When the result is nil, the saga continues with the next step,
+// otherwise it starts to compensate the completed steps.
+func (s Saga[Data]) Reply(ctx context.Context, sagaID string, result error) (rErr error) {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	ctx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.finishTx(&rErr, ctx)
+	state, err := s.findState(ctx, sagaID)
+	if err != nil {
+		return err
+	}
+	if state.Status != StatusAwaiting {
+		return errorkit.With(ErrNotAwaiting).
+			Detailf("saga %s is %s", sagaID, state.Status).
+			Unwrap()
+	}
+	if result != nil {
+		return s.fail(ctx, state, result)
+	}
+	return s.next(ctx, state)
+}
+
+func (s Saga[Data]) execute(ctx context.Context, state State[Data]) error {
+	if len(s.Steps) <= state.Step {
+		state.Status = StatusCompleted
+		return s.Repository.Update(ctx, &state)
+	}
+	step := s.Steps[state.Step]
+	data := state.Data
+	if err := step.Action(ContextWithID(ctx, state.ID), &data); err != nil {
+		return s.fail(ctx, state, err)
+	}
+	state.Data = data
+	if step.AwaitReply {
+		state.Status = StatusAwaiting
+		return s.Repository.Update(ctx, &state)
+	}
+	return s.next(ctx, state)
+}
+
+func (s Saga[Data]) next(ctx context.Context, state State[Data]) error {
+	state.Compensations = append(append([]string{}, state.Compensations...), s.Steps[state.Step].Name)
+	state.Step++
+	state.Status = StatusRunning
+	return s.update(ctx, state)
+}
+
+func (s Saga[Data]) fail(ctx context.Context, state State[Data], cause error) error {
+	state.Status = StatusCompensating
+	state.Error = cause.Error()
+	return s.update(ctx, state)
+}
+
+func (s Saga[Data]) compensate(ctx context.Context, state State[Data]) error {
+	if len(state.Compensations) == 0 {
+		state.Status = StatusCompensated
+		return s.Repository.Update(ctx, &state)
+	}
+	last := len(state.Compensations) - 1
+	step, ok := s.lookupStep(state.Compensations[last])
+	if !ok {
+		return errorkit.With(ErrStepNotFound).
+			Detailf("%q step is not defined in the saga", state.Compensations[last]).
+			Unwrap()
+	}
+	if step.Compensation != nil {
+		// a failing compensation leaves the state untouched,
+		// thus the event is redelivered and the compensation is retried.
+		if err := step.Compensation(ContextWithID(ctx, state.ID), state.Data); err != nil {
+			return err
+		}
+	}
+	state.Compensations = state.Compensations[:last]
+	return s.update(ctx, state)
+}
+
+// update persists the state, and schedules the next unit of work.
+func (s Saga[Data]) update(ctx context.Context, state State[Data]) error {
+	if err := s.Repository.Update(ctx, &state); err != nil {
+		return err
+	}
+	return s.Queue.Publish(ctx, Event{SagaID: state.ID})
+}
+
+func (s Saga[Data]) findState(ctx context.Context, id string) (State[Data], error) {
+	state, found, err := s.Repository.FindByID(ctx, id)
+	if err != nil {
+		return state, err
+	}
+	if !found {
+		return state, errorkit.With(crud.ErrNotFound).
+			Detailf("saga state not found by id: %s", id).
+			Unwrap()
+	}
+	return state, nil
+}
+
+func (s Saga[Data]) lookupStep(name string) (Step[Data], bool) {
+	for _, step := range s.Steps {
+		if step.Name == name {
+			return step, true
+		}
+	}
+	return Step[Data]{}, false
+}
+
+func (s Saga[Data]) validate() error {
+	if s.Repository == nil {
+		return fmt.Errorf("missing saga.Saga.Repository")
+	}
+	if s.Queue == nil {
+		return fmt.Errorf("missing saga.Saga.Queue")
+	}
+	var names = make(map[string]struct{}, len(s.Steps))
+	for i, step := range s.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("missing saga.Step.Name at index %d", i)
+		}
+		if step.Action == nil {
+			return fmt.Errorf("missing saga.Step.Action for %q", step.Name)
+		}
+		if _, ok := names[step.Name]; ok {
+			return fmt.Errorf("duplicate saga.Step.Name: %q", step.Name)
+		}
+		names[step.Name] = struct{}{}
+	}
+	return nil
+}
+
+func (s Saga[Data]) beginTx(ctx context.Context) (context.Context, error) {
+	if s.OnePhaseCommitProtocol == nil {
+		return ctx, nil
+	}
+	return s.OnePhaseCommitProtocol.BeginTx(ctx)
+}
+
+func (s Saga[Data]) finishTx(errp *error, ctx context.Context) {
+	if s.OnePhaseCommitProtocol == nil {
+		return
+	}
+	comproto.FinishOnePhaseCommit(errp, s.OnePhaseCommitProtocol, ctx)
+}
+
+// ContextWithID returns a context that carries the saga ID in the HeaderSagaID header.
+func ContextWithID(ctx context.Context, sagaID string) context.Context {
+	return pubsub.ContextWithHeaders(ctx, pubsub.Headers{HeaderSagaID: sagaID})
+}
+
+// LookupID returns the saga ID from the headers of the context.
+// Reply handlers driven by a pubsub.Consumer can use it to correlate the reply with its saga.
+func LookupID(ctx context.Context) (string, bool) {
+	hs, _ := pubsub.LookupHeaders(ctx)
+	id, ok := hs[HeaderSagaID]
+	return id, ok && id != ""
+}
@@ -0,0 +1,380 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/pkg/saga"
+	"go.llib.dev/frameless/pkg/tasker"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/random"
+)
+
+var _ tasker.Runnable = saga.Saga[Order]{}
+
+type Order struct {
+	ItemID        string
+	ReservationID string
+	PaymentID     string
+	ShipmentID    string
+}
+
+type OrderSaga struct {
+	Memory  *memory.Memory
+	Queue   *memory.Queue[saga.Event]
+	Saga    saga.Saga[Order]
+	Journal []string
+}
+
+func makeOrderSaga(tb testing.TB, steps ...saga.Step[Order]) *OrderSaga {
+	rnd := random.New(random.CryptoSeed{})
+	m := memory.NewMemory()
+	q := &memory.Queue[saga.Event]{Memory: m, Namespace: "saga/" + rnd.UUID()}
+	return &OrderSaga{
+		Memory: m,
+		Queue:  q,
+		Saga: saga.Saga[Order]{
+			Steps:                  steps,
+			Repository:             memory.NewRepositoryWithNamespace[saga.State[Order], string](m, rnd.UUID()),
+			Queue:                  q,
+			OnePhaseCommitProtocol: m,
+		},
+	}
+}
+
+// Drive handles the saga events one by one until the saga instance awaits a reply or finishes.
+// Each event handling publishes at most one new event, which makes the execution deterministic.
+func (sg *OrderSaga) Drive(tb testing.TB, id string) saga.State[Order] {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub := sg.Queue.Subscribe(ctx)
+	defer sub.Close()
+	for {
+		state := sg.State(tb, id)
+		if state.Done() || state.Status == saga.StatusAwaiting {
+			return state
+		}
+		assert.True(tb, sub.Next())
+		msg := sub.Value()
+		assert.NoError(tb, sg.Saga.Handle(ctx, msg.Data()))
+		assert.NoError(tb, msg.ACK())
+	}
+}
+
+func (sg *OrderSaga) State(tb testing.TB, id string) saga.State[Order] {
+	state, found, err := sg.Saga.Repository.FindByID(context.Background(), id)
+	assert.NoError(tb, err)
+	assert.True(tb, found)
+	return state
+}
+
+func (sg *OrderSaga) Step(name string, action func(ctx context.Context, order *Order) error) saga.Step[Order] {
+	return saga.Step[Order]{
+		Name: name,
+		Action: func(ctx context.Context, order *Order) error {
+			if err := action(ctx, order); err != nil {
+				return err
+			}
+			sg.Journal = append(sg.Journal, name)
+			return nil
+		},
+		Compensation: func(ctx context.Context, order Order) error {
+			sg.Journal = append(sg.Journal, "undo "+name)
+			return nil
+		},
+	}
+}
+
+func TestSaga(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		ctx       = context.Background()
+		orderSaga = testcase.Let(s, func(t *testcase.T) *OrderSaga { return makeOrderSaga(t) })
+		steps     = testcase.Var[[]saga.Step[Order]]{ID: "saga steps"}
+		subject   = testcase.Let(s, func(t *testcase.T) *OrderSaga {
+			sg := orderSaga.Get(t)
+			sg.Saga.Steps = steps.Get(t)
+			return sg
+		})
+		order = testcase.LetValue(s, Order{ItemID: "42"})
+	)
+	start := func(t *testcase.T) string {
+		id, err := subject.Get(t).Saga.Start(ctx, order.Get(t))
+		t.Must.NoError(err)
+		return id
+	}
+	succeed := func(ctx context.Context, order *Order) error { return nil }
+	fail := func(ctx context.Context, order *Order) error { return errors.New("boom") }
+
+	s.Context("when all steps succeed", func(s *testcase.Spec) {
+		steps.Let(s, func(t *testcase.T) []saga.Step[Order] {
+			sg := orderSaga.Get(t)
+			return []saga.Step[Order]{
+				sg.Step("reserve", func(ctx context.Context, order *Order) error {
+					order.ReservationID = "reservation-" + order.ItemID
+					return nil
+				}),
+				sg.Step("charge", func(ctx context.Context, order *Order) error {
+					order.PaymentID = "payment-" + order.ReservationID
+					return nil
+				}),
+				sg.Step("ship", func(ctx context.Context, order *Order) error {
+					order.ShipmentID = "shipment-" + order.PaymentID
+					return nil
+				}),
+			}
+		})
+
+		s.Test("the saga is completed", func(t *testcase.T) {
+			state := subject.Get(t).Drive(t, start(t))
+			t.Must.Equal(saga.StatusCompleted, state.Status)
+			t.Must.Equal([]string{"reserve", "charge", "ship"}, subject.Get(t).Journal)
+			t.Must.Equal([]string{"reserve", "charge", "ship"}, state.Compensations)
+			t.Must.Equal("shipment-payment-reservation-42", state.Data.ShipmentID)
+		})
+	})
+
+	s.Context("when a step fails", func(s *testcase.Spec) {
+		steps.Let(s, func(t *testcase.T) []saga.Step[Order] {
+			sg := orderSaga.Get(t)
+			return []saga.Step[Order]{
+				sg.Step("reserve", succeed),
+				sg.Step("charge", succeed),
+				sg.Step("ship", fail),
+			}
+		})
+
+		s.Test("the completed steps are compensated in reverse order", func(t *testcase.T) {
+			state := subject.Get(t).Drive(t, start(t))
+			t.Must.Equal(saga.StatusCompensated, state.Status)
+			t.Must.Equal("boom", state.Error)
+			t.Must.Empty(state.Compensations)
+			t.Must.Equal([]string{"reserve", "charge", "undo charge", "undo reserve"}, subject.Get(t).Journal)
+		})
+	})
+
+	s.Context("when a failing step changes the data", func(s *testcase.Spec) {
+		steps.Let(s, func(t *testcase.T) []saga.Step[Order] {
+			return []saga.Step[Order]{
+				orderSaga.Get(t).Step("charge", func(ctx context.Context, order *Order) error {
+					order.PaymentID = "payment"
+					return errors.New("boom")
+				}),
+			}
+		})
+
+		s.Test("the data changes are not persisted", func(t *testcase.T) {
+			state := subject.Get(t).Drive(t, start(t))
+			t.Must.Equal(saga.StatusCompensated, state.Status)
+			t.Must.Empty(state.Data.PaymentID)
+		})
+	})
+
+	s.Context("when the second step fails", func(s *testcase.Spec) {
+		steps.Let(s, func(t *testcase.T) []saga.Step[Order] {
+			sg := orderSaga.Get(t)
+			return []saga.Step[Order]{
+				sg.Step("reserve", succeed),
+				sg.Step("charge", fail),
+			}
+		})
+		// handle processes the next saga event, and ACK-s it when the handling succeeds.
+		handle := func(t *testcase.T, sub pubsub.Subscription[saga.Event]) error {
+			t.Must.True(sub.Next())
+			if err := subject.Get(t).Saga.Handle(ctx, sub.Value().Data()); err != nil {
+				return err
+			}
+			t.Must.NoError(sub.Value().ACK())
+			return nil
+		}
+		subscription := testcase.Let(s, func(t *testcase.T) pubsub.Subscription[saga.Event] {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			t.Defer(cancel)
+			sub := subject.Get(t).Queue.Subscribe(ctx)
+			t.Defer(sub.Close)
+			return sub
+		})
+
+		s.Context("and the compensation fails temporarily", func(s *testcase.Spec) {
+			compensationAttempts := testcase.LetValue(s, 0)
+			steps.Let(s, func(t *testcase.T) []saga.Step[Order] {
+				steps := steps.Super(t)
+				steps[0].Compensation = func(ctx context.Context, order Order) error {
+					compensationAttempts.Set(t, compensationAttempts.Get(t)+1)
+					if compensationAttempts.Get(t) == 1 {
+						return errors.New("temporary failure")
+					}
+					return nil
+				}
+				return steps
+			})
+
+			s.Test("the compensation is retried with the redelivery of the event", func(t *testcase.T) {
+				id := start(t)
+				sub := subscription.Get(t)
+				for i := 0; i < 2; i++ { // reserve, then charge fails
+					t.Must.NoError(handle(t, sub))
+				}
+				t.Must.Error(handle(t, sub))
+				t.Must.NoError(sub.Value().NACK())
+				t.Must.Equal([]string{"reserve"}, subject.Get(t).State(t, id).Compensations)
+
+				state := subject.Get(t).Drive(t, id)
+				t.Must.Equal(saga.StatusCompensated, state.Status)
+				t.Must.Equal(2, compensationAttempts.Get(t))
+			})
+		})
+
+		s.Test("the persisted state lets a new saga process continue after a restart", func(t *testcase.T) {
+			id := start(t)
+			t.Must.NoError(handle(t, subscription.Get(t)))
+			t.Must.NoError(subscription.Get(t).Close())
+
+			t.Log("a new saga definition with the same repository and queue, as if the process restarted")
+			sg := subject.Get(t)
+			sg.Saga = saga.Saga[Order]{
+				Steps:      sg.Saga.Steps,
+				Repository: sg.Saga.Repository,
+				Queue:      sg.Saga.Queue,
+			}
+			state := sg.Drive(t, id)
+			t.Must.Equal(saga.StatusCompensated, state.Status)
+			t.Must.Equal([]string{"reserve", "undo reserve"}, sg.Journal)
+		})
+
+		s.Test("a compensation step that is no longer defined is reported", func(t *testcase.T) {
+			id := start(t)
+			sub := subscription.Get(t)
+			for i := 0; i < 2; i++ {
+				t.Must.NoError(handle(t, sub))
+			}
+			t.Must.Equal(saga.StatusCompensating, subject.Get(t).State(t, id).Status)
+
+			subject.Get(t).Saga.Steps = subject.Get(t).Saga.Steps[1:]
+			t.Must.ErrorIs(saga.ErrStepNotFound, handle(t, sub))
+		})
+	})
+
+	s.Context("when the step names are not unique", func(s *testcase.Spec) {
+		steps.Let(s, func(t *testcase.T) []saga.Step[Order] {
+			sg := orderSaga.Get(t)
+			return []saga.Step[Order]{
+				sg.Step("reserve", succeed),
+				sg.Step("reserve", succeed),
+			}
+		})
+
+		s.Test("the saga is not started", func(t *testcase.T) {
+			_, err := subject.Get(t).Saga.Start(ctx, order.Get(t))
+			t.Must.Error(err)
+		})
+	})
+}
+
+func TestSaga_Reply(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		ctx      = context.Background()
+		subject  = testcase.Let(s, func(t *testcase.T) *OrderSaga { return makeOrderSaga(t) })
+		payments = testcase.Let(s, func(t *testcase.T) *memory.Queue[Order] {
+			return &memory.Queue[Order]{Memory: subject.Get(t).Memory, Namespace: "payments/" + t.Random.UUID()}
+		})
+		// id is the ID of a started saga, which awaits the reply of the payment service.
+		id = testcase.Let(s, func(t *testcase.T) string {
+			sg := subject.Get(t)
+			charge := sg.Step("charge", func(ctx context.Context, order *Order) error {
+				return payments.Get(t).Publish(ctx, *order) // issue a command to the payment service
+			})
+			charge.AwaitReply = true
+			sg.Saga.Steps = []saga.Step[Order]{
+				sg.Step("reserve", func(ctx context.Context, order *Order) error { return nil }),
+				charge,
+				sg.Step("ship", func(ctx context.Context, order *Order) error { return nil }),
+			}
+			id, err := sg.Saga.Start(ctx, Order{})
+			t.Must.NoError(err)
+			sg.Drive(t, id)
+			return id
+		}).EagerLoading(s)
+	)
+	// receiveCommand acts as the payment service, and returns the saga ID from the received command.
+	receiveCommand := func(t *testcase.T) string {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		sub := payments.Get(t).Subscribe(ctx)
+		defer sub.Close()
+		t.Must.True(sub.Next())
+		t.Must.NoError(sub.Value().ACK())
+		ctx = pubsub.ContextWithHeaders(ctx, sub.Value().Headers())
+		id, ok := saga.LookupID(ctx)
+		t.Must.True(ok)
+		return id
+	}
+
+	s.Test("the saga waits for the reply of an awaiting step", func(t *testcase.T) {
+		state := subject.Get(t).State(t, id.Get(t))
+		t.Must.Equal(saga.StatusAwaiting, state.Status)
+		t.Must.Equal([]string{"reserve"}, state.Compensations)
+		t.Must.Equal(id.Get(t), receiveCommand(t))
+	})
+
+	s.Test("a successful reply continues the saga", func(t *testcase.T) {
+		t.Must.NoError(subject.Get(t).Saga.Reply(ctx, receiveCommand(t), nil))
+
+		state := subject.Get(t).Drive(t, id.Get(t))
+		t.Must.Equal(saga.StatusCompleted, state.Status)
+		t.Must.Equal([]string{"reserve", "charge", "ship"}, subject.Get(t).Journal)
+	})
+
+	s.Test("a failed reply compensates the steps completed before the awaiting step", func(t *testcase.T) {
+		t.Must.NoError(subject.Get(t).Saga.Reply(ctx, receiveCommand(t), errors.New("insufficient funds")))
+
+		state := subject.Get(t).Drive(t, id.Get(t))
+		t.Must.Equal(saga.StatusCompensated, state.Status)
+		t.Must.Equal("insufficient funds", state.Error)
+		t.Must.Equal([]string{"reserve", "charge", "undo reserve"}, subject.Get(t).Journal)
+	})
+
+	s.Test("a reply to a saga which is not awaiting is rejected", func(t *testcase.T) {
+		t.Must.NoError(subject.Get(t).Saga.Reply(ctx, receiveCommand(t), nil))
+
+		t.Must.ErrorIs(saga.ErrNotAwaiting, subject.Get(t).Saga.Reply(ctx, id.Get(t), nil))
+	})
+}
+
+func TestSaga_Run(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	subject := testcase.Let(s, func(t *testcase.T) *OrderSaga {
+		sg := makeOrderSaga(t)
+		sg.Saga.Concurrency = 2
+		sg.Saga.Steps = []saga.Step[Order]{
+			{Name: "reserve", Action: func(ctx context.Context, order *Order) error { return nil }},
+			{Name: "charge", Action: func(ctx context.Context, order *Order) error { return errors.New("boom") }},
+		}
+		return sg
+	})
+
+	s.Test("the saga events are handled until the context is done", func(t *testcase.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- subject.Get(t).Saga.Run(ctx) }()
+
+		id, err := subject.Get(t).Saga.Start(ctx, Order{})
+		t.Must.NoError(err)
+
+		t.Eventually(func(it assert.It) {
+			it.Must.Equal(saga.StatusCompensated, subject.Get(t).State(it, id).Status)
+		})
+
+		cancel()
+		t.Must.NoError(<-done)
+	})
+}
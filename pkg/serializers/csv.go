@@ -0,0 +1,106 @@
+package serializers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.llib.dev/frameless/pkg/convkit"
+	"go.llib.dev/frameless/pkg/reflectkit"
+	"go.llib.dev/frameless/pkg/zerokit"
+)
+
+// CSV encodes a list of structs as CSV, where the first row is the header.
+// The columns are named by the `csv` field tag, or by the field name when the tag is missing,
+// and fields tagged with `csv:"-"` are skipped.
+// Time values are formatted with the layout from the field's `layout` tag, which defaults to time.RFC3339.
+//
+// The encoded output can be decoded with iterators.CSV.
+type CSV struct{}
+
+func (s CSV) MakeListEncoder(w io.Writer) ListEncoder {
+	return &csvListEncoder{W: csv.NewWriter(w)}
+}
+
+type csvListEncoder struct {
+	W *csv.Writer
+
+	typ    reflect.Type
+	fields []csvField
+	done   bool
+}
+
+type csvField struct {
+	Index   int
+	Name    string
+	Options convkit.Options
+}
+
+func (e *csvListEncoder) Encode(v any) error {
+	if e.done {
+		return fmt.Errorf("csv list encoder is already closed")
+	}
+	val := reflectkit.BaseValueOf(v)
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("not supported type for csv encoding: %T", v)
+	}
+	if e.typ == nil {
+		e.typ = val.Type()
+		e.fields = e.getFields(e.typ)
+		header := make([]string, 0, len(e.fields))
+		for _, field := range e.fields {
+			header = append(header, field.Name)
+		}
+		if err := e.W.Write(header); err != nil {
+			return err
+		}
+	}
+	if val.Type() != e.typ {
+		return fmt.Errorf("csv list encoder expects %s, got %s", e.typ.String(), val.Type().String())
+	}
+	record := make([]string, 0, len(e.fields))
+	for _, field := range e.fields {
+		out, err := convkit.Format(val.Field(field.Index).Interface(), field.Options)
+		if err != nil {
+			return fmt.Errorf("error while formatting %s: %w", field.Name, err)
+		}
+		record = append(record, out)
+	}
+	return e.W.Write(record)
+}
+
+func (e *csvListEncoder) getFields(typ reflect.Type) []csvField {
+	var fields []csvField
+	for i, num := 0, typ.NumField(); i < num; i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("csv"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			name = zerokit.Coalesce(tagName, name)
+		}
+		fields = append(fields, csvField{
+			Index:   i,
+			Name:    name,
+			Options: convkit.Options{TimeLayout: zerokit.Coalesce(field.Tag.Get("layout"), time.RFC3339)},
+		})
+	}
+	return fields
+}
+
+func (e *csvListEncoder) Close() error {
+	if e.done {
+		return e.W.Error()
+	}
+	e.done = true
+	e.W.Flush()
+	return e.W.Error()
+}
@@ -0,0 +1,44 @@
+package serializers_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/pkg/serializers"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/testcase/assert"
+)
+
+type CSVRecord struct {
+	ID        int       `csv:"id"`
+	Name      string    `csv:"full_name"`
+	CreatedAt time.Time `csv:"created_at" layout:"2006-01-02"`
+	Secret    string    `csv:"-"`
+}
+
+func TestCSV_MakeListEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := serializers.CSV{}.MakeListEncoder(&buf)
+	assert.NoError(t, enc.Encode(CSVRecord{ID: 1, Name: "Jane", CreatedAt: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Secret: "x"}))
+	assert.NoError(t, enc.Encode(&CSVRecord{ID: 2, Name: "Doe, John", CreatedAt: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)}))
+	assert.NoError(t, enc.Close())
+
+	assert.Equal(t, "id,full_name,created_at\n1,Jane,2023-01-02\n2,\"Doe, John\",2023-01-03\n", buf.String())
+
+	t.Log("the output can be decoded with iterators.CSV")
+	vs, err := iterators.Collect(iterators.CSV[CSVRecord](&buf))
+	assert.NoError(t, err)
+	assert.Equal(t, []CSVRecord{
+		{ID: 1, Name: "Jane", CreatedAt: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Name: "Doe, John", CreatedAt: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}, vs)
+}
+
+func TestCSV_MakeListEncoder_rejectsMixedTypes(t *testing.T) {
+	var buf bytes.Buffer
+	enc := serializers.CSV{}.MakeListEncoder(&buf)
+	assert.NoError(t, enc.Encode(CSVRecord{ID: 1}))
+	assert.Error(t, enc.Encode(struct{ ID int }{ID: 2}))
+	assert.Error(t, enc.Encode(42))
+}
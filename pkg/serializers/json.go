@@ -233,6 +233,14 @@ func (e jsonEncoder) Encode(v any) error {
 
 func (jsonEncoder) Close() error { return nil }
 
+func (s JSONStream) MakeListDecoder(r io.Reader) ListDecoder {
+	rc, ok := r.(io.ReadCloser)
+	if !ok {
+		rc = io.NopCloser(r)
+	}
+	return s.NewListDecoder(rc)
+}
+
 func (s JSONStream) NewListDecoder(w io.ReadCloser) ListDecoder {
 	return &jsonDecoder{Decoder: json.NewDecoder(w), Closer: w}
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"go.llib.dev/frameless/pkg/iokit"
 	"go.llib.dev/frameless/pkg/serializers"
+	"go.llib.dev/frameless/ports/iterators"
 	. "go.llib.dev/frameless/spechelper/testent"
 	"go.llib.dev/testcase/assert"
 	"go.llib.dev/testcase/random"
@@ -138,6 +139,25 @@ func TestJSONStream_list(t *testing.T) {
 	assert.Equal(t, exp3, got3)
 }
 
+func TestJSONStream_MakeListDecoder(t *testing.T) {
+	var _ serializers.ListSerializer = serializers.JSONStream{}
+
+	exp := rnd.Make(Foo{}).(Foo)
+	var buf bytes.Buffer
+	enc := serializers.JSONStream{}.MakeListEncoder(&buf)
+	assert.NoError(t, enc.Encode(exp))
+	assert.NoError(t, enc.Close())
+
+	dec := serializers.JSONStream{}.MakeListDecoder(&buf)
+	var got Foo
+	assert.True(t, dec.Next())
+	assert.NoError(t, dec.Decode(&got))
+	assert.False(t, dec.Next())
+	assert.NoError(t, dec.Err())
+	assert.NoError(t, dec.Close())
+	assert.Equal(t, exp, got)
+}
+
 func TestJSONSerializer_NewListDecoder(t *testing.T) {
 	t.Run("E2E", func(t *testing.T) {
 		foos := []Foo{
@@ -205,3 +225,15 @@ func TestJSONSerializer_NewListEncoder(t *testing.T) {
 		assert.Equal(t, foos, gotFoos)
 	})
 }
+
+func TestJSON_MakeListEncoder_symmetricWithJSONArray(t *testing.T) {
+	var buf bytes.Buffer
+	enc := serializers.JSON{}.MakeListEncoder(&buf)
+	assert.NoError(t, enc.Encode(Foo{ID: "1", Foo: "Jane"}))
+	assert.NoError(t, enc.Encode(Foo{ID: "2", Foo: "John"}))
+	assert.NoError(t, enc.Close())
+
+	vs, err := iterators.Collect(iterators.JSONArray[Foo](&buf))
+	assert.NoError(t, err)
+	assert.Equal(t, []Foo{{ID: "1", Foo: "Jane"}, {ID: "2", Foo: "John"}}, vs)
+}
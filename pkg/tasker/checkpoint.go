@@ -0,0 +1,75 @@
+package tasker
+
+import (
+	"context"
+
+	"go.llib.dev/frameless/pkg/contextkit"
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/ports/crud"
+	"go.llib.dev/frameless/ports/iterators"
+)
+
+// Checkpoint is the persisted progress of a Task which iterates through a resumable iterator.
+type Checkpoint struct {
+	ID    CheckpointID `ext:"id"`
+	Token iterators.Checkpoint
+}
+
+type CheckpointID string
+
+type CheckpointRepository interface {
+	crud.Creator[Checkpoint]
+	crud.Updater[Checkpoint]
+	crud.ByIDFinder[Checkpoint, CheckpointID]
+	crud.ByIDDeleter[CheckpointID]
+}
+
+// WithCheckpoint creates a Task which handles the elements of a resumable iterator,
+// and persists the iteration's checkpoint after each handled element.
+// When the Task is interrupted, like with a shutdown signal or a restart,
+// its next run continues with the element after the last handled one.
+// Once the iteration is finished, the checkpoint is deleted, so the next run starts from the beginning.
+func WithCheckpoint[T any](
+	id CheckpointID,
+	repo CheckpointRepository,
+	iterate func(ctx context.Context, checkpoint iterators.Checkpoint) iterators.Resumable[T],
+	handle func(ctx context.Context, v T) error,
+) Task {
+	return func(ctx context.Context) (rErr error) {
+		cp, found, err := repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			cp = Checkpoint{ID: id}
+			if err := repo.Create(ctx, &cp); err != nil {
+				return err
+			}
+		}
+
+		iter := iterate(ctx, cp.Token)
+		defer errorkit.Finish(&rErr, iter.Close)
+
+		for iter.Next() {
+			if err := handle(ctx, iter.Value()); err != nil {
+				return err
+			}
+			token, err := iter.Checkpoint()
+			if err != nil {
+				return err
+			}
+			cp.Token = token
+			// the element is already handled, thus its progress is persisted even during a shutdown
+			if err := repo.Update(contextkit.Detach(ctx), &cp); err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		return repo.DeleteByID(ctx, id)
+	}
+}
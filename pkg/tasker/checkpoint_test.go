@@ -0,0 +1,134 @@
+package tasker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/pkg/tasker"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/testcase"
+)
+
+func TestWithCheckpoint(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		ctx  = testcase.LetValue(s, context.Background())
+		id   = testcase.LetValue[tasker.CheckpointID](s, "export")
+		repo = testcase.Let(s, func(t *testcase.T) *memory.Repository[tasker.Checkpoint, tasker.CheckpointID] {
+			return memory.NewRepository[tasker.Checkpoint, tasker.CheckpointID](memory.NewMemory())
+		})
+		values = testcase.Let(s, func(t *testcase.T) []int {
+			return []int{1, 2, 3, 4, 5}
+		})
+		iterate = testcase.Let(s, func(t *testcase.T) func(ctx context.Context, checkpoint iterators.Checkpoint) iterators.Resumable[int] {
+			values := values.Get(t)
+			return func(ctx context.Context, checkpoint iterators.Checkpoint) iterators.Resumable[int] {
+				return iterators.PaginateFrom(ctx, checkpoint, func(ctx context.Context, offset int) ([]int, bool, error) {
+					end := offset + 2
+					if len(values) < end {
+						end = len(values)
+					}
+					return values[offset:end], end < len(values), nil
+				})
+			}
+		})
+		handled = testcase.Let(s, func(t *testcase.T) *[]int { return &[]int{} })
+		handle  = testcase.Let(s, func(t *testcase.T) func(ctx context.Context, v int) error {
+			handled := handled.Get(t)
+			return func(ctx context.Context, v int) error {
+				*handled = append(*handled, v)
+				return nil
+			}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) tasker.Task {
+			return tasker.WithCheckpoint[int](id.Get(t), repo.Get(t), iterate.Get(t), handle.Get(t))
+		})
+	)
+	findCheckpoint := func(t *testcase.T) (tasker.Checkpoint, bool) {
+		cp, found, err := repo.Get(t).FindByID(context.Background(), id.Get(t))
+		t.Must.NoError(err)
+		return cp, found
+	}
+
+	s.Then("every element is handled, and no checkpoint is left behind", func(t *testcase.T) {
+		t.Must.NoError(subject.Get(t)(ctx.Get(t)))
+		t.Must.Equal([]int{1, 2, 3, 4, 5}, *handled.Get(t))
+		_, found := findCheckpoint(t)
+		t.Must.False(found)
+	})
+
+	s.When("the handling of an element fails", func(s *testcase.Spec) {
+		expErr := testcase.Let(s, func(t *testcase.T) error { return errors.New("boom") })
+		handle.Let(s, func(t *testcase.T) func(ctx context.Context, v int) error {
+			next, expErr, failed := handle.Super(t), expErr.Get(t), false
+			return func(ctx context.Context, v int) error {
+				if v == 4 && !failed {
+					failed = true // fails on the first attempt only
+					return expErr
+				}
+				return next(ctx, v)
+			}
+		})
+
+		s.Then("the run is interrupted, and the checkpoint is persisted", func(t *testcase.T) {
+			t.Must.ErrorIs(expErr.Get(t), subject.Get(t)(ctx.Get(t)))
+			t.Must.Equal([]int{1, 2, 3}, *handled.Get(t))
+			cp, found := findCheckpoint(t)
+			t.Must.True(found)
+			t.Must.Equal(iterators.Checkpoint("3"), cp.Token)
+		})
+
+		s.Then("the next run continues where the previous one stopped, and removes the checkpoint when finished", func(t *testcase.T) {
+			t.Must.ErrorIs(expErr.Get(t), subject.Get(t)(ctx.Get(t)))
+			*handled.Get(t) = append(*handled.Get(t), 0) // mark the restart
+			t.Must.NoError(subject.Get(t)(ctx.Get(t)))
+			t.Must.Equal([]int{1, 2, 3, 0, 4, 5}, *handled.Get(t))
+			_, found := findCheckpoint(t)
+			t.Must.False(found)
+		})
+	})
+
+	s.When("the context is cancelled during the iteration", func(s *testcase.Spec) {
+		id.LetValue(s, "infinite")
+		cancellable := testcase.Let(s, func(t *testcase.T) *struct {
+			context.Context
+			Cancel func()
+		} {
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Defer(cancel)
+			return &struct {
+				context.Context
+				Cancel func()
+			}{Context: ctx, Cancel: cancel}
+		})
+		ctx.Let(s, func(t *testcase.T) context.Context {
+			return cancellable.Get(t).Context
+		})
+		iterate.Let(s, func(t *testcase.T) func(ctx context.Context, checkpoint iterators.Checkpoint) iterators.Resumable[int] {
+			return func(ctx context.Context, checkpoint iterators.Checkpoint) iterators.Resumable[int] {
+				return iterators.PaginateFrom(ctx, checkpoint, func(ctx context.Context, offset int) ([]int, bool, error) {
+					return []int{offset}, true, nil
+				})
+			}
+		})
+		handle.Let(s, func(t *testcase.T) func(ctx context.Context, v int) error {
+			cancel := cancellable.Get(t).Cancel
+			return func(ctx context.Context, v int) error {
+				if v == 9 {
+					cancel()
+				}
+				return nil
+			}
+		})
+
+		s.Then("the run stops, and the checkpoint points after the last handled element", func(t *testcase.T) {
+			t.Must.ErrorIs(context.Canceled, subject.Get(t)(ctx.Get(t)))
+			cp, found := findCheckpoint(t)
+			t.Must.True(found)
+			t.Must.Equal(iterators.Checkpoint("10"), cp.Token)
+		})
+	})
+}
@@ -38,6 +38,7 @@ func (c FileSystem) Spec(s *testcase.Spec) {
 		s.Describe(".OpenFile", c.specOpenFile)
 		s.Describe(".MkDir", c.specMkdir)
 		s.Describe(".Remove", c.specRemove)
+		s.Describe(".Rename", c.specRename)
 		s.Describe(".Stat", c.specStat)
 	})
 	s.Describe("#File", func(s *testcase.Spec) {
@@ -500,6 +501,94 @@ func (c FileSystem) specRemove(s *testcase.Spec) {
 	})
 }
 
+func (c FileSystem) specRename(s *testcase.Spec) {
+	newName := testcase.Let(s, func(t *testcase.T) string {
+		return t.Random.StringNWithCharset(6, "hijklmn")
+	})
+	// Rename is optional, as it is not part of the filesystem.FileSystem interface.
+	type renamer interface {
+		Rename(oldpath, newpath string) error
+	}
+	s.Before(func(t *testcase.T) {
+		if _, ok := c.fileSystem().Get(t).(renamer); !ok {
+			t.Skip("the file system doesn't support Rename")
+		}
+	})
+	subject := func(t *testcase.T) error {
+		err := c.fileSystem().Get(t).(renamer).Rename(c.name().Get(t), newName.Get(t))
+		if err == nil {
+			t.Defer(c.fileSystem().Get(t).Remove, newName.Get(t))
+		}
+		return err
+	}
+
+	s.When("name points to nothing", func(s *testcase.Spec) {
+		s.Before(func(t *testcase.T) {
+			_, err := c.fileSystem().Get(t).Stat(c.name().Get(t))
+			t.Must.True(os.IsNotExist(err))
+		})
+
+		s.Then("it yields error", func(t *testcase.T) {
+			err := subject(t)
+			t.Must.NotNil(err)
+			t.Must.True(os.IsNotExist(err))
+
+			var lerr *os.LinkError
+			t.Must.True(errors.As(err, &lerr), "*os.LinkError was expected")
+			t.Must.Equal("rename", lerr.Op)
+			t.Must.Contain(lerr.Old, c.name().Get(t))
+		})
+	})
+
+	s.When("name points to a file", func(s *testcase.Spec) {
+		data := testcase.Let(s, func(t *testcase.T) []byte {
+			return []byte(t.Random.String())
+		})
+		s.Before(func(t *testcase.T) {
+			c.saveFile(t, c.name().Get(t), data.Get(t))
+		})
+
+		s.Then("it will move the file to the new name", func(t *testcase.T) {
+			t.Must.Nil(subject(t))
+			_, err := c.fileSystem().Get(t).Stat(c.name().Get(t))
+			t.Must.True(os.IsNotExist(err))
+			t.Must.Equal(data.Get(t), c.readFile(t, newName.Get(t)))
+		})
+
+		s.And("the new name points to an existing file", func(s *testcase.Spec) {
+			s.Before(func(t *testcase.T) {
+				c.saveFile(t, newName.Get(t), []byte(t.Random.String()))
+			})
+
+			s.Then("it will replace the existing file", func(t *testcase.T) {
+				t.Must.Nil(subject(t))
+				t.Must.Equal(data.Get(t), c.readFile(t, newName.Get(t)))
+			})
+		})
+	})
+
+	s.When("name points to a directory", func(s *testcase.Spec) {
+		s.Before(func(t *testcase.T) {
+			c.touchDir(t, c.name().Get(t), 0700)
+			c.touchFile(t, filepath.Join(c.name().Get(t), "file.tdd"), 0700)
+		})
+
+		s.Then("it will move the directory together with its content", func(t *testcase.T) {
+			t.Must.Nil(subject(t))
+			movedFile := filepath.Join(newName.Get(t), "file.tdd")
+			t.Defer(c.fileSystem().Get(t).Remove, movedFile)
+
+			info, err := c.fileSystem().Get(t).Stat(newName.Get(t))
+			t.Must.Nil(err)
+			t.Must.True(info.IsDir())
+			_, err = c.fileSystem().Get(t).Stat(movedFile)
+			t.Must.Nil(err)
+			_, err = c.fileSystem().Get(t).Stat(c.name().Get(t))
+			t.Must.True(os.IsNotExist(err))
+		})
+	})
+}
+
 func (c FileSystem) specStat(s *testcase.Spec) {
 	subject := func(t *testcase.T) (fs.FileInfo, error) {
 		return c.fileSystem().Get(t).Stat(c.name().Get(t))
@@ -898,6 +987,16 @@ func (c FileSystem) saveFile(t *testcase.T, name string, data []byte) {
 	c.writeToFile(t, file, data)
 }
 
+func (c FileSystem) readFile(t *testcase.T, name string) []byte {
+	t.Helper()
+	file, err := filesystem.Open(c.fileSystem().Get(t), name)
+	t.Must.Nil(err)
+	defer func() { t.Should.Nil(file.Close()) }()
+	data, err := io.ReadAll(file)
+	t.Must.Nil(err)
+	return data
+}
+
 func (c FileSystem) overwrite(dst []byte, src []byte) []byte {
 	l := len(dst)
 	if l < len(src) {
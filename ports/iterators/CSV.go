@@ -0,0 +1,169 @@
+package iterators
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.llib.dev/frameless/pkg/convkit"
+	"go.llib.dev/frameless/pkg/zerokit"
+)
+
+// CSV iterates through the records of a CSV input, and maps each row into a T struct.
+// The first row is the header, and the columns are mapped to the struct fields by their `csv` tag,
+// or when the tag is missing, by their case-insensitive field name.
+// Fields tagged with `csv:"-"` and the columns without a matching field are ignored.
+// Empty cells leave the field on its zero value.
+// Time values are parsed with the layout from the field's `layout` tag, which defaults to time.RFC3339.
+//
+// Parsing failures are reported as *ParseError with the position of the invalid cell.
+// When the reader is an io.Closer, closing the iterator closes the reader.
+func CSV[T any](r io.Reader) Iterator[T] {
+	return &csvIter[T]{Reader: r}
+}
+
+type csvIter[T any] struct {
+	Reader io.Reader
+
+	csv     *csv.Reader
+	columns []csvColumn
+	closed  bool
+	err     error
+	value   T
+}
+
+func (i *csvIter[T]) Close() error {
+	if i.closed {
+		return nil
+	}
+	i.closed = true
+	return closeReader(i.Reader)
+}
+
+func (i *csvIter[T]) Err() error {
+	return i.err
+}
+
+func (i *csvIter[T]) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+	if i.csv == nil {
+		if err := i.init(); err != nil {
+			i.err = err
+			return false
+		}
+	}
+	record, err := i.csv.Read()
+	if errors.Is(err, io.EOF) {
+		return false
+	}
+	if err != nil {
+		i.err = i.toParseError(err)
+		return false
+	}
+	var (
+		v   T
+		ptr = reflect.ValueOf(&v).Elem()
+	)
+	for col, raw := range record {
+		if len(i.columns) <= col || i.columns[col].Field < 0 || raw == "" {
+			continue
+		}
+		field := ptr.Field(i.columns[col].Field)
+		out, err := convkit.ParseReflect(field.Type(), raw, i.columns[col].Options)
+		if err != nil {
+			line, column := i.csv.FieldPos(col)
+			i.err = &ParseError{
+				Line:   line,
+				Column: column,
+				Offset: i.csv.InputOffset(),
+				Err:    err,
+			}
+			return false
+		}
+		field.Set(out)
+	}
+	i.value = v
+	return true
+}
+
+func (i *csvIter[T]) init() error {
+	typ := reflect.TypeOf(*new(T))
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return fmt.Errorf("iterators.CSV expects a struct type, got %T", *new(T))
+	}
+	i.csv = csv.NewReader(i.Reader)
+	i.csv.ReuseRecord = true
+	header, err := i.csv.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return i.toParseError(err)
+	}
+	i.columns = make([]csvColumn, len(header))
+	for col, name := range header {
+		index := csvFieldIndex(typ, strings.TrimSpace(name))
+		i.columns[col] = csvColumn{Field: index}
+		if 0 <= index {
+			layout := typ.Field(index).Tag.Get("layout")
+			i.columns[col].Options = convkit.Options{TimeLayout: zerokit.Coalesce(layout, time.RFC3339)}
+		}
+	}
+	return nil
+}
+
+func (i *csvIter[T]) toParseError(err error) error {
+	var perr *csv.ParseError
+	if !errors.As(err, &perr) {
+		return err
+	}
+	return &ParseError{
+		Line:   perr.Line,
+		Column: perr.Column,
+		Offset: i.csv.InputOffset(),
+		Err:    perr.Err,
+	}
+}
+
+func (i *csvIter[T]) Value() T {
+	return i.value
+}
+
+type csvColumn struct {
+	// Field is the index of the mapped struct field, or -1 when the column is not mapped.
+	Field   int
+	Options convkit.Options
+}
+
+func csvFieldIndex(typ reflect.Type, column string) int {
+	var byName = -1
+	for index := 0; index < typ.NumField(); index++ {
+		field := typ.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("csv")
+		if ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name == column {
+				return index
+			}
+			if name != "" {
+				continue
+			}
+		}
+		if byName < 0 && strings.EqualFold(field.Name, column) {
+			byName = index
+		}
+	}
+	return byName
+}
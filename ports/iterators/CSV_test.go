@@ -0,0 +1,92 @@
+package iterators_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/pkg/iokit"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/testcase/assert"
+)
+
+type CSVRecord struct {
+	ID        int    `csv:"id"`
+	Name      string `csv:"full_name"`
+	Active    bool
+	CreatedAt time.Time `csv:"created_at"`
+	Ignored   string    `csv:"-"`
+}
+
+const csvInput = `id,full_name,active,created_at,ignored,unknown
+1,Jane Doe,true,2023-01-02T15:04:05Z,x,y
+2,"Doe, John",false,,x,y
+`
+
+func TestCSV(t *testing.T) {
+	t.Run("rows are mapped into structs by the header", func(t *testing.T) {
+		vs, err := iterators.Collect(iterators.CSV[CSVRecord](strings.NewReader(csvInput)))
+		assert.NoError(t, err)
+		assert.Equal(t, []CSVRecord{
+			{ID: 1, Name: "Jane Doe", Active: true, CreatedAt: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)},
+			{ID: 2, Name: "Doe, John", Active: false},
+		}, vs)
+	})
+
+	t.Run("the column order is defined by the header", func(t *testing.T) {
+		in := "full_name,id\nJane,1\n"
+		vs, err := iterators.Collect(iterators.CSV[CSVRecord](strings.NewReader(in)))
+		assert.NoError(t, err)
+		assert.Equal(t, []CSVRecord{{ID: 1, Name: "Jane"}}, vs)
+	})
+
+	t.Run("empty input has no rows", func(t *testing.T) {
+		vs, err := iterators.Collect(iterators.CSV[CSVRecord](strings.NewReader("")))
+		assert.NoError(t, err)
+		assert.Empty(t, vs)
+	})
+
+	t.Run("invalid cell value is reported with its position", func(t *testing.T) {
+		in := "id,full_name\n1,Jane\nfoo,John\n"
+		vs, err := iterators.Collect(iterators.CSV[CSVRecord](strings.NewReader(in)))
+		assert.Equal(t, 1, len(vs))
+		var perr *iterators.ParseError
+		assert.True(t, errors.As(err, &perr))
+		assert.Equal(t, 3, perr.Line)
+		assert.Equal(t, 1, perr.Column)
+	})
+
+	t.Run("malformed CSV is reported with its position", func(t *testing.T) {
+		in := "id,full_name\n1,\"Jane\n2,\"Jo\"hn\"\n"
+		_, err := iterators.Collect(iterators.CSV[CSVRecord](strings.NewReader(in)))
+		var perr *iterators.ParseError
+		assert.True(t, errors.As(err, &perr))
+		assert.Equal(t, 3, perr.Line)
+	})
+
+	t.Run("non struct type is rejected", func(t *testing.T) {
+		_, err := iterators.Collect(iterators.CSV[int](strings.NewReader(csvInput)))
+		assert.Error(t, err)
+	})
+
+	t.Run("closing the iterator closes the reader", func(t *testing.T) {
+		r := &iokit.StubReader{Data: []byte(csvInput)}
+		assert.NoError(t, iterators.CSV[CSVRecord](r).Close())
+		assert.True(t, r.IsClosed)
+	})
+
+	t.Run("reader error is propagated", func(t *testing.T) {
+		r := &iokit.StubReader{Data: []byte(csvInput), ReadErr: io.ErrClosedPipe}
+		_, err := iterators.Collect(iterators.CSV[CSVRecord](r))
+		assert.ErrorIs(t, io.ErrClosedPipe, err)
+	})
+}
+
+func TestCSV_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[CSVRecord](func(tb testing.TB) iterators.Iterator[CSVRecord] {
+		return iterators.CSV[CSVRecord](strings.NewReader(csvInput))
+	}).Test(t)
+}
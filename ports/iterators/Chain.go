@@ -0,0 +1,54 @@
+package iterators
+
+import "go.llib.dev/frameless/pkg/errorkit"
+
+// Chain concatenates the iterators, and iterates through their elements one iterator after the other.
+// An iterator is closed as soon as it is exhausted, and the iteration stops at the first iterator error.
+func Chain[T any](iters ...Iterator[T]) Iterator[T] {
+	return &chainIter[T]{Iterators: iters}
+}
+
+type chainIter[T any] struct {
+	Iterators []Iterator[T]
+
+	closed bool
+	index  int
+	err    error
+	value  T
+}
+
+func (i *chainIter[T]) Close() error {
+	i.closed = true
+	var errs []error
+	for ; i.index < len(i.Iterators); i.index++ {
+		errs = append(errs, i.Iterators[i.index].Close())
+	}
+	return errorkit.Merge(errs...)
+}
+
+func (i *chainIter[T]) Err() error {
+	return i.err
+}
+
+func (i *chainIter[T]) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+	for i.index < len(i.Iterators) {
+		iter := i.Iterators[i.index]
+		if iter.Next() {
+			i.value = iter.Value()
+			return true
+		}
+		i.index++
+		if err := errorkit.Merge(iter.Err(), iter.Close()); err != nil {
+			i.err = err
+			return false
+		}
+	}
+	return false
+}
+
+func (i *chainIter[T]) Value() T {
+	return i.value
+}
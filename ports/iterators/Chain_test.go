@@ -0,0 +1,94 @@
+package iterators_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestChain(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		iters = testcase.Let(s, func(t *testcase.T) []iterators.Iterator[int] {
+			return []iterators.Iterator[int]{ranges.Int(1, 2), iterators.Empty[int](), ranges.Int(3, 5)}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Chain(iters.Get(t)...)
+		})
+	)
+
+	s.Then("iterators are iterated one after the other", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 2, 3, 4, 5}, vs)
+	})
+
+	s.When("no iterator is given", func(s *testcase.Spec) {
+		iters.Let(s, func(t *testcase.T) []iterators.Iterator[int] { return nil })
+
+		s.Then("it is empty", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.NoError(err)
+			t.Must.Empty(vs)
+		})
+	})
+
+	s.When("an iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		iters.Let(s, func(t *testcase.T) []iterators.Iterator[int] {
+			return []iterators.Iterator[int]{ranges.Int(1, 2), iterators.Error[int](expErr), ranges.Int(3, 5)}
+		})
+
+		s.Then("the iteration stops at its error", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+			t.Must.Equal([]int{1, 2}, vs)
+		})
+	})
+
+	s.When("the iterators are closable", func(s *testcase.Spec) {
+		closed := testcase.Let(s, func(t *testcase.T) *[]int { return &[]int{} })
+		closeErr := testcase.LetValue[error](s, nil)
+		iters.Let(s, func(t *testcase.T) []iterators.Iterator[int] {
+			var iters []iterators.Iterator[int]
+			for n := 1; n <= 3; n++ {
+				n := n
+				stub := iterators.Stub(ranges.Int(n, n))
+				stub.StubClose = func() error {
+					*closed.Get(t) = append(*closed.Get(t), n)
+					return closeErr.Get(t)
+				}
+				iters = append(iters, stub)
+			}
+			return iters
+		})
+
+		s.Then("exhausted iterators are closed during the iteration, and the rest on Close", func(t *testcase.T) {
+			iter := subject.Get(t)
+			t.Must.True(iter.Next())
+			t.Must.True(iter.Next())
+			t.Must.Equal([]int{1}, *closed.Get(t))
+			t.Must.NoError(iter.Close())
+			t.Must.Equal([]int{1, 2, 3}, *closed.Get(t))
+		})
+
+		s.And("closing fails", func(s *testcase.Spec) {
+			closeErr.Let(s, func(t *testcase.T) error { return errors.New("boom") })
+
+			s.Then("the close error is propagated", func(t *testcase.T) {
+				t.Must.ErrorIs(closeErr.Get(t), subject.Get(t).Close())
+			})
+		})
+	})
+}
+
+func TestChain_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		return iterators.Chain(ranges.Int(1, 5), ranges.Int(6, 10))
+	}).Test(t)
+}
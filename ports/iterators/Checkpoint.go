@@ -0,0 +1,19 @@
+package iterators
+
+import "go.llib.dev/frameless/pkg/errorkit"
+
+// Checkpoint is a serializable token of an iteration's position.
+// The zero value represents the beginning of the iteration.
+type Checkpoint string
+
+// Resumable is an Iterator which can tell its position as a Checkpoint,
+// so the iteration can be continued later from the same position, even from another process.
+type Resumable[T any] interface {
+	Iterator[T]
+	// Checkpoint returns the position right after the last element returned by Value.
+	// Resuming from it will continue with the element that follows it.
+	Checkpoint() (Checkpoint, error)
+}
+
+// ErrInvalidCheckpoint is returned when a resumable iteration receives a malformed Checkpoint.
+const ErrInvalidCheckpoint errorkit.Error = "iterators: invalid checkpoint"
@@ -0,0 +1,23 @@
+package iterators
+
+// Distinct filters out the repeated elements of the iterator,
+// and only yields the first occurrence of each value.
+// The already seen values are held in memory.
+func Distinct[T comparable](iter Iterator[T]) Iterator[T] {
+	return DistinctBy(iter, func(v T) T { return v })
+}
+
+// DistinctBy filters out the elements whose key is already seen,
+// and only yields the first element for each key.
+// The already seen keys are held in memory.
+func DistinctBy[K comparable, T any](iter Iterator[T], key func(T) K) Iterator[T] {
+	var seen = make(map[K]struct{})
+	return Filter(iter, func(v T) bool {
+		k := key(v)
+		if _, ok := seen[k]; ok {
+			return false
+		}
+		seen[k] = struct{}{}
+		return true
+	})
+}
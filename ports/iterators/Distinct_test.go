@@ -0,0 +1,67 @@
+package iterators_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/testcase"
+)
+
+func TestDistinct(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Slice([]int{1, 2, 1, 3, 2, 4, 4})
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Distinct(source.Get(t))
+		})
+	)
+
+	s.Then("repeated elements are yielded only once, in the order of their first occurrence", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 2, 3, 4}, vs)
+	})
+
+	s.When("the source iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Error[int](expErr)
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+}
+
+func TestDistinctBy(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			return iterators.Slice([]string{"Foo", "bar", "FOO", "Baz", "BAR"})
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			return iterators.DistinctBy(source.Get(t), strings.ToLower)
+		})
+	)
+
+	s.Then("elements with an already seen key are skipped", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]string{"Foo", "bar", "Baz"}, vs)
+	})
+}
+
+func TestDistinct_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		return iterators.Distinct(iterators.Slice([]int{1, 1, 2, 3, 3, 3, 4}))
+	}).Test(t)
+}
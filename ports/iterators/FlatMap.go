@@ -0,0 +1,72 @@
+package iterators
+
+import "go.llib.dev/frameless/pkg/errorkit"
+
+// FlatMap transforms each element into an iterator, and flattens their elements into a single iterator.
+// This is useful when each element of a stream maps to multiple values,
+// like when the related entities of each entity are queried with a FindAll.
+// The inner iterators are closed as soon as they are exhausted.
+func FlatMap[To any, From any](iter Iterator[From], transform func(From) (Iterator[To], error)) Iterator[To] {
+	return &flatMapIter[From, To]{Iterator: iter, Transform: transform}
+}
+
+type flatMapIter[From any, To any] struct {
+	Iterator  Iterator[From]
+	Transform func(From) (Iterator[To], error)
+
+	closed bool
+	err    error
+	inner  Iterator[To]
+	value  To
+}
+
+func (i *flatMapIter[From, To]) Close() error {
+	i.closed = true
+	var errs []error
+	if i.inner != nil {
+		errs = append(errs, i.inner.Close())
+		i.inner = nil
+	}
+	errs = append(errs, i.Iterator.Close())
+	return errorkit.Merge(errs...)
+}
+
+func (i *flatMapIter[From, To]) Err() error {
+	if i.err != nil {
+		return i.err
+	}
+	return i.Iterator.Err()
+}
+
+func (i *flatMapIter[From, To]) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+	for {
+		if i.inner != nil {
+			if i.inner.Next() {
+				i.value = i.inner.Value()
+				return true
+			}
+			inner := i.inner
+			i.inner = nil
+			if err := errorkit.Merge(inner.Err(), inner.Close()); err != nil {
+				i.err = err
+				return false
+			}
+		}
+		if !i.Iterator.Next() {
+			return false
+		}
+		inner, err := i.Transform(i.Iterator.Value())
+		if err != nil {
+			i.err = err
+			return false
+		}
+		i.inner = inner
+	}
+}
+
+func (i *flatMapIter[From, To]) Value() To {
+	return i.value
+}
@@ -0,0 +1,92 @@
+package iterators_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestFlatMap(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		transform = testcase.Let(s, func(t *testcase.T) func(n int) (iterators.Iterator[int], error) {
+			return func(n int) (iterators.Iterator[int], error) {
+				return ranges.Int(1, n), nil
+			}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.FlatMap(ranges.Int(1, 3), transform.Get(t))
+		})
+	)
+
+	s.Then("the iterators of the elements are flattened", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 1, 2, 1, 2, 3}, vs)
+	})
+
+	s.When("the transform fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		transform.Let(s, func(t *testcase.T) func(n int) (iterators.Iterator[int], error) {
+			return func(n int) (iterators.Iterator[int], error) {
+				if n == 2 {
+					return nil, expErr
+				}
+				return iterators.SingleValue(n), nil
+			}
+		})
+
+		s.Then("the iteration stops with its error", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+			t.Must.Equal([]int{1}, vs)
+		})
+	})
+
+	s.When("an inner iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		transform.Let(s, func(t *testcase.T) func(n int) (iterators.Iterator[int], error) {
+			return func(n int) (iterators.Iterator[int], error) {
+				return iterators.Error[int](expErr), nil
+			}
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+
+	s.When("the inner iterators are closable", func(s *testcase.Spec) {
+		closed := testcase.LetValue(s, 0)
+		transform.Let(s, func(t *testcase.T) func(n int) (iterators.Iterator[int], error) {
+			return func(n int) (iterators.Iterator[int], error) {
+				stub := iterators.Stub(ranges.Int(1, n))
+				stub.StubClose = func() error { closed.Set(t, closed.Get(t)+1); return nil }
+				return stub, nil
+			}
+		})
+
+		s.Then("exhausted inner iterators are closed during the iteration, and the current one on Close", func(t *testcase.T) {
+			iter := subject.Get(t)
+			t.Must.True(iter.Next())
+			t.Must.True(iter.Next())
+			t.Must.Equal(1, closed.Get(t), "exhausted inner iterator is closed")
+			t.Must.NoError(iter.Close())
+			t.Must.Equal(2, closed.Get(t), "the current inner iterator is closed with the iterator")
+		})
+	})
+}
+
+func TestFlatMap_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		return iterators.FlatMap(ranges.Int(1, 5), func(n int) (iterators.Iterator[int], error) {
+			return ranges.Int(n, 5), nil
+		})
+	}).Test(t)
+}
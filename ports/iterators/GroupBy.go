@@ -0,0 +1,74 @@
+package iterators
+
+// Group is a set of consecutive elements that share the same key.
+type Group[K comparable, T any] struct {
+	Key    K
+	Values []T
+}
+
+// GroupBy groups the consecutive elements of the iterator which have the same key.
+// Similarly to the Unix "uniq" command, only adjacent elements are grouped,
+// thus the iterator should be sorted by the key to have a single group per key.
+// Only the elements of the current group are held in memory.
+func GroupBy[K comparable, T any](iter Iterator[T], key func(T) K) Iterator[Group[K, T]] {
+	return &groupByIter[K, T]{Iterator: iter, Key: key}
+}
+
+type groupByIter[K comparable, T any] struct {
+	Iterator Iterator[T]
+	Key      func(T) K
+
+	closed bool
+	done   bool
+	// pending is the first element of the next group,
+	// which was read ahead to detect the end of the current group.
+	pending    *T
+	pendingKey K
+	value      Group[K, T]
+}
+
+func (i *groupByIter[K, T]) Close() error {
+	i.closed = true
+	return i.Iterator.Close()
+}
+
+func (i *groupByIter[K, T]) Err() error {
+	return i.Iterator.Err()
+}
+
+func (i *groupByIter[K, T]) Next() bool {
+	if i.closed {
+		return false
+	}
+	if i.pending == nil {
+		if i.done || !i.Iterator.Next() {
+			i.done = true
+			return false
+		}
+		v := i.Iterator.Value()
+		i.pending, i.pendingKey = &v, i.Key(v)
+	}
+	group := Group[K, T]{Key: i.pendingKey, Values: []T{*i.pending}}
+	i.pending = nil
+	for i.Iterator.Next() {
+		v := i.Iterator.Value()
+		k := i.Key(v)
+		if k != group.Key {
+			i.pending, i.pendingKey = &v, k
+			break
+		}
+		group.Values = append(group.Values, v)
+	}
+	if i.pending == nil {
+		i.done = true
+		if i.Iterator.Err() != nil {
+			return false
+		}
+	}
+	i.value = group
+	return true
+}
+
+func (i *groupByIter[K, T]) Value() Group[K, T] {
+	return i.value
+}
@@ -0,0 +1,67 @@
+package iterators_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestGroupBy(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			return iterators.Slice([]string{"apple", "avocado", "banana", "blueberry", "cherry", "apricot"})
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[iterators.Group[byte, string]] {
+			return iterators.GroupBy(source.Get(t), func(s string) byte { return s[0] })
+		})
+	)
+
+	s.Then("consecutive elements with the same key are grouped", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]iterators.Group[byte, string]{
+			{Key: 'a', Values: []string{"apple", "avocado"}},
+			{Key: 'b', Values: []string{"banana", "blueberry"}},
+			{Key: 'c', Values: []string{"cherry"}},
+			{Key: 'a', Values: []string{"apricot"}},
+		}, vs)
+	})
+
+	s.When("the source iterator is empty", func(s *testcase.Spec) {
+		source.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			return iterators.Empty[string]()
+		})
+
+		s.Then("no group is yielded", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.NoError(err)
+			t.Must.Empty(vs)
+		})
+	})
+
+	s.When("the source iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		source.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			stub := iterators.Stub(source.Super(t))
+			stub.StubErr = func() error { return expErr }
+			return stub
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+}
+
+func TestGroupBy_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[iterators.Group[int, int]](func(tb testing.TB) iterators.Iterator[iterators.Group[int, int]] {
+		return iterators.GroupBy(ranges.Int(1, 20), func(n int) int { return n / 5 })
+	}).Test(t)
+}
@@ -0,0 +1,113 @@
+package iterators
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JSONArray iterates through the elements of a top-level JSON array, and decodes each element into T.
+// The input is decoded element by element, thus large arrays are not loaded into the memory at once.
+//
+// Decoding failures are reported as *ParseError with the position of the invalid element.
+// When the reader is an io.Closer, closing the iterator closes the reader.
+func JSONArray[T any](r io.Reader) Iterator[T] {
+	return &jsonArrayIter[T]{Reader: r}
+}
+
+type jsonArrayIter[T any] struct {
+	Reader io.Reader
+
+	pos    *positionReader
+	dec    *json.Decoder
+	done   bool
+	closed bool
+	err    error
+	value  T
+}
+
+func (i *jsonArrayIter[T]) Close() error {
+	if i.closed {
+		return nil
+	}
+	i.closed = true
+	return closeReader(i.Reader)
+}
+
+func (i *jsonArrayIter[T]) Err() error {
+	return i.err
+}
+
+func (i *jsonArrayIter[T]) Next() bool {
+	if i.closed || i.done || i.err != nil {
+		return false
+	}
+	if i.dec == nil {
+		i.pos = &positionReader{Reader: i.Reader}
+		i.dec = json.NewDecoder(i.pos)
+		if err := i.expectDelim('['); err != nil {
+			i.err = err
+			return false
+		}
+	}
+	if !i.dec.More() {
+		i.done = true
+		if err := i.expectDelim(']'); err != nil {
+			i.err = err
+		}
+		return false
+	}
+	var raw json.RawMessage
+	if err := i.dec.Decode(&raw); err != nil {
+		i.err = i.toParseError(err, i.dec.InputOffset())
+		return false
+	}
+	start := i.dec.InputOffset() - int64(len(raw))
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		var offset = start
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			offset += typeErr.Offset
+		}
+		i.err = i.toParseError(err, offset)
+		return false
+	}
+	i.pos.Position(start) // forget the already passed newlines
+	i.value = v
+	return true
+}
+
+func (i *jsonArrayIter[T]) expectDelim(delim json.Delim) error {
+	offset := i.dec.InputOffset()
+	tok, err := i.dec.Token()
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return i.toParseError(err, offset)
+	}
+	if tok != delim {
+		return i.toParseError(fmt.Errorf("expected %q, got %v", delim, tok), offset)
+	}
+	return nil
+}
+
+func (i *jsonArrayIter[T]) toParseError(err error, offset int64) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset - 1 // the offset points after the invalid character
+	}
+	line, column := i.pos.Position(offset)
+	return &ParseError{
+		Line:   line,
+		Column: column,
+		Offset: offset,
+		Err:    err,
+	}
+}
+
+func (i *jsonArrayIter[T]) Value() T {
+	return i.value
+}
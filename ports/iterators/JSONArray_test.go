@@ -0,0 +1,94 @@
+package iterators_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.llib.dev/frameless/pkg/iokit"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/testcase/assert"
+)
+
+type JSONRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+const jsonArrayInput = `[
+  {"id": 1, "name": "foo"},
+  {"id": 2, "name": "bar"},
+  {"id": 3, "name": "baz"}
+]`
+
+func TestJSONArray(t *testing.T) {
+	t.Run("elements are decoded one by one", func(t *testing.T) {
+		vs, err := iterators.Collect(iterators.JSONArray[JSONRecord](strings.NewReader(jsonArrayInput)))
+		assert.NoError(t, err)
+		assert.Equal(t, []JSONRecord{{1, "foo"}, {2, "bar"}, {3, "baz"}}, vs)
+	})
+
+	t.Run("elements are yielded before the whole input is read", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go func() { _, _ = pw.Write([]byte(`[{"id":1},`)) }()
+		iter := iterators.JSONArray[JSONRecord](pr)
+		assert.True(t, iter.Next())
+		assert.Equal(t, JSONRecord{ID: 1}, iter.Value())
+		assert.NoError(t, iter.Close())
+	})
+
+	t.Run("empty array has no elements", func(t *testing.T) {
+		vs, err := iterators.Collect(iterators.JSONArray[JSONRecord](strings.NewReader(" [ ] ")))
+		assert.NoError(t, err)
+		assert.Empty(t, vs)
+	})
+
+	t.Run("non array input is rejected", func(t *testing.T) {
+		_, err := iterators.Collect(iterators.JSONArray[JSONRecord](strings.NewReader(`{"id":1}`)))
+		var perr *iterators.ParseError
+		assert.True(t, errors.As(err, &perr))
+		assert.Equal(t, 1, perr.Line)
+		assert.Equal(t, 1, perr.Column)
+	})
+
+	t.Run("syntax error is reported with its position", func(t *testing.T) {
+		in := "[\n  {\"id\": 1},\n  {\"id\": x}\n]"
+		vs, err := iterators.Collect(iterators.JSONArray[JSONRecord](strings.NewReader(in)))
+		assert.Equal(t, 1, len(vs))
+		var perr *iterators.ParseError
+		assert.True(t, errors.As(err, &perr))
+		assert.Equal(t, 3, perr.Line)
+		assert.Equal(t, 10, perr.Column)
+	})
+
+	t.Run("type mismatch is reported with its position", func(t *testing.T) {
+		in := "[\n  {\"id\": 1},\n  {\"id\": \"two\"}\n]"
+		_, err := iterators.Collect(iterators.JSONArray[JSONRecord](strings.NewReader(in)))
+		var perr *iterators.ParseError
+		assert.True(t, errors.As(err, &perr))
+		assert.Equal(t, 3, perr.Line)
+	})
+
+	t.Run("truncated input is reported", func(t *testing.T) {
+		vs, err := iterators.Collect(iterators.JSONArray[JSONRecord](strings.NewReader(`[{"id":1}`)))
+		assert.Equal(t, []JSONRecord{{ID: 1}}, vs)
+		var perr *iterators.ParseError
+		assert.True(t, errors.As(err, &perr))
+		assert.Equal(t, 1, perr.Line)
+	})
+
+	t.Run("closing the iterator closes the reader", func(t *testing.T) {
+		r := &iokit.StubReader{Data: []byte(jsonArrayInput)}
+		assert.NoError(t, iterators.JSONArray[JSONRecord](r).Close())
+		assert.True(t, r.IsClosed)
+	})
+}
+
+func TestJSONArray_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[JSONRecord](func(tb testing.TB) iterators.Iterator[JSONRecord] {
+		return iterators.JSONArray[JSONRecord](strings.NewReader(jsonArrayInput))
+	}).Test(t)
+}
@@ -0,0 +1,120 @@
+package iterators
+
+import (
+	"container/heap"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+)
+
+// Merge combines already sorted iterators into a single sorted iterator (k-way merge).
+// The less function must be the same ordering that the iterators are sorted by.
+// Equal elements are yielded in the order of the iterators in the argument list.
+func Merge[T any](less func(a, b T) bool, iters ...Iterator[T]) Iterator[T] {
+	return &mergeIter[T]{Less: less, Iterators: iters}
+}
+
+type mergeIter[T any] struct {
+	Less      func(a, b T) bool
+	Iterators []Iterator[T]
+
+	init   bool
+	closed bool
+	err    error
+	heap   mergeHeap[T]
+	value  T
+	// last is the index of the iterator which yielded the current value,
+	// it is advanced lazily on the next Next call.
+	last int
+}
+
+func (i *mergeIter[T]) Close() error {
+	i.closed = true
+	var errs []error
+	for _, iter := range i.Iterators {
+		errs = append(errs, iter.Close())
+	}
+	return errorkit.Merge(errs...)
+}
+
+func (i *mergeIter[T]) Err() error {
+	return i.err
+}
+
+func (i *mergeIter[T]) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+	if !i.init {
+		i.init = true
+		i.heap = mergeHeap[T]{less: i.Less}
+		for index := range i.Iterators {
+			if !i.push(index) {
+				return false
+			}
+		}
+		heap.Init(&i.heap)
+	} else if 0 <= i.last {
+		if !i.push(i.last) {
+			return false
+		}
+	}
+	if i.heap.Len() == 0 {
+		i.last = -1
+		return false
+	}
+	head := heap.Pop(&i.heap).(mergeHeapItem[T])
+	i.value, i.last = head.value, head.index
+	return true
+}
+
+// push advances the iterator by its index, and puts its next element into the heap.
+func (i *mergeIter[T]) push(index int) bool {
+	iter := i.Iterators[index]
+	if iter.Next() {
+		heap.Push(&i.heap, mergeHeapItem[T]{value: iter.Value(), index: index})
+		return true
+	}
+	if err := iter.Err(); err != nil {
+		i.err = err
+		return false
+	}
+	return true
+}
+
+func (i *mergeIter[T]) Value() T {
+	return i.value
+}
+
+type mergeHeapItem[T any] struct {
+	value T
+	index int
+}
+
+type mergeHeap[T any] struct {
+	less  func(a, b T) bool
+	items []mergeHeapItem[T]
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.items) }
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.less(a.value, b.value) {
+		return true
+	}
+	if h.less(b.value, a.value) {
+		return false
+	}
+	return a.index < b.index
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap[T]) Push(x any) { h.items = append(h.items, x.(mergeHeapItem[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	last := len(h.items) - 1
+	item := h.items[last]
+	h.items = h.items[:last]
+	return item
+}
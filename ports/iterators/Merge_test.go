@@ -0,0 +1,131 @@
+package iterators_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestMerge(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	less := func(a, b int) bool { return a < b }
+	var (
+		iters = testcase.Let(s, func(t *testcase.T) []iterators.Iterator[int] {
+			return []iterators.Iterator[int]{
+				iterators.Slice([]int{1, 4, 7}),
+				iterators.Slice([]int{2, 5, 8, 9}),
+				iterators.Empty[int](),
+				iterators.Slice([]int{3, 6}),
+			}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Merge(less, iters.Get(t)...)
+		})
+	)
+
+	s.Then("sorted iterators are merged into a sorted iterator", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 2, 3, 4, 5, 6, 7, 8, 9}, vs)
+	})
+
+	s.When("the iterators have random sorted elements", func(s *testcase.Spec) {
+		values := testcase.Let(s, func(t *testcase.T) [][]int {
+			var vss [][]int
+			for i, n := 0, t.Random.IntB(1, 7); i < n; i++ {
+				var vs []int
+				for j, m := 0, t.Random.IntB(0, 20); j < m; j++ {
+					vs = append(vs, t.Random.IntB(0, 100))
+				}
+				sort.Ints(vs)
+				vss = append(vss, vs)
+			}
+			return vss
+		})
+		iters.Let(s, func(t *testcase.T) []iterators.Iterator[int] {
+			var iters []iterators.Iterator[int]
+			for _, vs := range values.Get(t) {
+				iters = append(iters, iterators.Slice(vs))
+			}
+			return iters
+		})
+
+		s.Then("every element is yielded in sorted order", func(t *testcase.T) {
+			var exp []int
+			for _, vs := range values.Get(t) {
+				exp = append(exp, vs...)
+			}
+			sort.Ints(exp)
+			got, err := iterators.Collect(subject.Get(t))
+			t.Must.NoError(err)
+			t.Must.Equal(len(exp), len(got))
+			if 0 < len(exp) {
+				t.Must.Equal(exp, got)
+			}
+		})
+	})
+
+	s.When("an iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		iters.Let(s, func(t *testcase.T) []iterators.Iterator[int] {
+			return []iterators.Iterator[int]{ranges.Int(1, 5), iterators.Error[int](expErr)}
+		})
+
+		s.Then("the merge stops with its error", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+
+	s.When("the iterator is closed", func(s *testcase.Spec) {
+		closed := testcase.LetValue(s, 0)
+		iters.Let(s, func(t *testcase.T) []iterators.Iterator[int] {
+			var iters []iterators.Iterator[int]
+			for i := 0; i < 3; i++ {
+				stub := iterators.Stub(ranges.Int(1, 3))
+				stub.StubClose = func() error { closed.Set(t, closed.Get(t)+1); return nil }
+				iters = append(iters, stub)
+			}
+			return iters
+		})
+
+		s.Then("all iterators are closed", func(t *testcase.T) {
+			t.Must.NoError(subject.Get(t).Close())
+			t.Must.Equal(3, closed.Get(t))
+		})
+	})
+}
+
+func TestMerge_stable(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	type E struct{ Key, Src int }
+	subject := testcase.Let(s, func(t *testcase.T) iterators.Iterator[E] {
+		return iterators.Merge(func(a, b E) bool { return a.Key < b.Key },
+			iterators.Slice([]E{{Key: 1, Src: 1}, {Key: 1, Src: 1}, {Key: 2, Src: 1}}),
+			iterators.Slice([]E{{Key: 1, Src: 2}, {Key: 2, Src: 2}}),
+		)
+	})
+
+	s.Then("equal elements keep the order of the iterators", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]E{{1, 1}, {1, 1}, {1, 2}, {2, 1}, {2, 2}}, vs)
+	})
+}
+
+func TestMerge_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		t := testcase.ToT(&tb)
+		return iterators.Merge(func(a, b int) bool { return a < b },
+			ranges.Int(1, t.Random.IntB(1, 10)),
+			ranges.Int(1, t.Random.IntB(1, 10)),
+		)
+	}).Test(t)
+}
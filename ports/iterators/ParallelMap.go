@@ -0,0 +1,217 @@
+package iterators
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+)
+
+// ParallelMap is the concurrent version of Map.
+// It is useful when the transformation is I/O bound, like when each element needs a remote call to enrich it.
+//
+// The source iterator is consumed sequentially in a background goroutine,
+// and the elements are transformed concurrently, with at most as many elements in-flight as the concurrency limit.
+// By default, the results are yielded in the order of their completion,
+// use ParallelMapOrdered to keep the order of the source iterator.
+//
+// The first transformation error stops the iteration:
+// the context of the in-flight transformations is cancelled, and the source iterator is closed.
+// When the context is cancelled, the iteration stops, and Err returns the context's error.
+func ParallelMap[To any, From any](
+	ctx context.Context,
+	iter Iterator[From],
+	transform func(context.Context, From) (To, error),
+	opts ...ParallelMapOption,
+) Iterator[To] {
+	var c parallelMapConfig
+	for _, opt := range opts {
+		opt.configureParallelMap(&c)
+	}
+	return &parallelMapIter[From, To]{
+		Context:   ctx,
+		Iterator:  iter,
+		Transform: transform,
+		Config:    c,
+	}
+}
+
+type ParallelMapOption interface {
+	configureParallelMap(c *parallelMapConfig)
+}
+
+type parallelMapOptionFunc func(c *parallelMapConfig)
+
+func (fn parallelMapOptionFunc) configureParallelMap(c *parallelMapConfig) { fn(c) }
+
+// ParallelMapConcurrency sets the maximum number of elements transformed in parallel.
+//
+// Default: runtime.NumCPU()
+func ParallelMapConcurrency(n int) ParallelMapOption {
+	return parallelMapOptionFunc(func(c *parallelMapConfig) {
+		c.Concurrency = n
+	})
+}
+
+// ParallelMapOrdered makes ParallelMap yield the results in the order of the source iterator.
+// A slow element holds back the already finished results behind it,
+// but the number of the buffered results is still bound by the concurrency limit.
+func ParallelMapOrdered() ParallelMapOption {
+	return parallelMapOptionFunc(func(c *parallelMapConfig) {
+		c.Ordered = true
+	})
+}
+
+type parallelMapConfig struct {
+	Concurrency int
+	Ordered     bool
+}
+
+func (c parallelMapConfig) getConcurrency() int {
+	if c.Concurrency < 1 {
+		return runtime.NumCPU()
+	}
+	return c.Concurrency
+}
+
+type parallelMapIter[From, To any] struct {
+	Context   context.Context
+	Iterator  Iterator[From]
+	Transform func(context.Context, From) (To, error)
+	Config    parallelMapConfig
+
+	init    sync.Once
+	started bool
+	cancel  func()
+	// slots limits the number of elements which are in-flight or waiting to be consumed.
+	slots   chan struct{}
+	results chan parallelMapResult[To]
+	// producerDone is closed when the source iterator is closed, and all transformation is finished.
+	producerDone chan struct{}
+	// srcErr and closeErr are written by the producer before it closes the results channel.
+	srcErr   error
+	closeErr error
+
+	shutdown sync.Once
+	closed   bool
+	done     bool
+	err      error
+	pending  map[int]parallelMapResult[To]
+	index    int
+	value    To
+}
+
+type parallelMapResult[To any] struct {
+	index int
+	value To
+	err   error
+}
+
+func (i *parallelMapIter[From, To]) start() {
+	i.init.Do(func() {
+		i.started = true
+		n := i.Config.getConcurrency()
+		ctx, cancel := context.WithCancel(i.Context)
+		i.cancel = cancel
+		i.slots = make(chan struct{}, n)
+		i.results = make(chan parallelMapResult[To], n)
+		i.producerDone = make(chan struct{})
+		i.pending = make(map[int]parallelMapResult[To])
+		go i.produce(ctx)
+	})
+}
+
+func (i *parallelMapIter[From, To]) produce(ctx context.Context) {
+	defer close(i.producerDone)
+	var (
+		wg    sync.WaitGroup
+		index int
+	)
+dispatch:
+	for {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case i.slots <- struct{}{}:
+		}
+		if !i.Iterator.Next() {
+			break dispatch
+		}
+		wg.Add(1)
+		go func(index int, v From) {
+			defer wg.Done()
+			out, err := i.Transform(ctx, v)
+			i.results <- parallelMapResult[To]{index: index, value: out, err: err}
+		}(index, i.Iterator.Value())
+		index++
+	}
+	i.srcErr = i.Iterator.Err()
+	i.closeErr = i.Iterator.Close()
+	wg.Wait()
+	close(i.results)
+}
+
+func (i *parallelMapIter[From, To]) Next() bool {
+	if i.closed || i.done || i.err != nil {
+		return false
+	}
+	i.start()
+	for {
+		if r, ok := i.pending[i.index]; ok {
+			delete(i.pending, i.index)
+			return i.yield(r)
+		}
+		r, ok := <-i.results
+		if !ok {
+			i.done = true
+			i.err = errorkit.Merge(i.srcErr, i.Context.Err())
+			return false
+		}
+		if r.err != nil {
+			i.err = r.err
+			i.stop()
+			return false
+		}
+		if !i.Config.Ordered {
+			return i.yield(r)
+		}
+		i.pending[r.index] = r
+	}
+}
+
+func (i *parallelMapIter[From, To]) yield(r parallelMapResult[To]) bool {
+	<-i.slots
+	i.index++
+	i.value = r.value
+	return true
+}
+
+// stop cancels the in-flight transformations, and waits until the source iterator is closed.
+func (i *parallelMapIter[From, To]) stop() {
+	i.shutdown.Do(func() {
+		i.cancel()
+		for range i.results { // drain to let the transformations finish
+		}
+		<-i.producerDone
+	})
+}
+
+func (i *parallelMapIter[From, To]) Close() error {
+	i.closed = true
+	i.init.Do(func() {}) // prevent starting after close
+	if !i.started {
+		i.shutdown.Do(func() { i.closeErr = i.Iterator.Close() })
+		return i.closeErr
+	}
+	i.stop()
+	return i.closeErr
+}
+
+func (i *parallelMapIter[From, To]) Err() error {
+	return i.err
+}
+
+func (i *parallelMapIter[From, To]) Value() To {
+	return i.value
+}
@@ -0,0 +1,283 @@
+package iterators_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+)
+
+func ExampleParallelMap() {
+	var iter iterators.Iterator[int] = ranges.Int(1, 100)
+
+	enriched := iterators.ParallelMap(context.Background(), iter,
+		func(ctx context.Context, n int) (string, error) {
+			return strconv.Itoa(n), nil // e.g. a remote call
+		},
+		iterators.ParallelMapConcurrency(8),
+		iterators.ParallelMapOrdered(),
+	)
+	defer enriched.Close()
+
+	for enriched.Next() {
+		_ = enriched.Value()
+	}
+	_ = enriched.Err()
+}
+
+func TestParallelMap(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		ctx = testcase.Let(s, func(t *testcase.T) context.Context {
+			return context.Background()
+		})
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return ranges.Int(1, 50)
+		})
+		transform = testcase.Let(s, func(t *testcase.T) func(ctx context.Context, n int) (int, error) {
+			return func(ctx context.Context, n int) (int, error) { return n * 2, nil }
+		})
+		concurrency = testcase.LetValue(s, 4)
+		options     = testcase.Let(s, func(t *testcase.T) []iterators.ParallelMapOption {
+			return nil
+		})
+	)
+	subject := func(t *testcase.T) iterators.Iterator[int] {
+		opts := append([]iterators.ParallelMapOption{iterators.ParallelMapConcurrency(concurrency.Get(t))}, options.Get(t)...)
+		return iterators.ParallelMap(ctx.Get(t), source.Get(t), transform.Get(t), opts...)
+	}
+	doubles := func(from, to int) []int {
+		var vs []int
+		for n := from; n <= to; n++ {
+			vs = append(vs, n*2)
+		}
+		return vs
+	}
+
+	s.Test("all elements are transformed", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject(t))
+		t.Must.NoError(err)
+		t.Must.ContainExactly(doubles(1, 50), vs)
+	})
+
+	s.When("the transformations complete in the reverse order of the source", func(s *testcase.Spec) {
+		const n = 4
+		concurrency.LetValue(s, n)
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return ranges.Int(1, n)
+		})
+		transform.Let(s, func(t *testcase.T) func(ctx context.Context, n int) (int, error) {
+			// each element waits for the completion of the next one, except the last.
+			done := make(map[int]chan struct{})
+			for i := 1; i <= n+1; i++ {
+				done[i] = make(chan struct{})
+			}
+			close(done[n+1])
+			return func(ctx context.Context, v int) (int, error) {
+				<-done[v+1]
+				close(done[v])
+				return v * 2, nil
+			}
+		})
+
+		s.Then("every element is yielded", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject(t))
+			t.Must.NoError(err)
+			t.Must.ContainExactly(doubles(1, n), vs)
+		})
+
+		s.And("ParallelMapOrdered is used", func(s *testcase.Spec) {
+			options.Let(s, func(t *testcase.T) []iterators.ParallelMapOption {
+				return []iterators.ParallelMapOption{iterators.ParallelMapOrdered()}
+			})
+
+			s.Then("the order of the source iterator is kept", func(t *testcase.T) {
+				vs, err := iterators.Collect(subject(t))
+				t.Must.NoError(err)
+				t.Must.Equal(doubles(1, n), vs)
+			})
+		})
+	})
+
+	s.When("the transformations are blocked", func(s *testcase.Spec) {
+		concurrency.LetValue(s, 3)
+		type activity struct {
+			sync.Mutex
+			Active, Peak int
+		}
+		var (
+			act     = testcase.Let(s, func(t *testcase.T) *activity { return &activity{} })
+			release = testcase.Let(s, func(t *testcase.T) chan struct{} { return make(chan struct{}) })
+		)
+		transform.Let(s, func(t *testcase.T) func(ctx context.Context, n int) (int, error) {
+			act, release := act.Get(t), release.Get(t)
+			return func(ctx context.Context, n int) (int, error) {
+				act.Lock()
+				act.Active++
+				if act.Peak < act.Active {
+					act.Peak = act.Active
+				}
+				act.Unlock()
+				<-release
+				act.Lock()
+				act.Active--
+				act.Unlock()
+				return n, nil
+			}
+		})
+
+		s.Then("the number of parallel transformations is bound by the concurrency", func(t *testcase.T) {
+			iter := subject(t)
+			defer iter.Close()
+			done := make(chan []int)
+			go func() {
+				vs, err := iterators.Collect(iter)
+				t.Should.NoError(err)
+				done <- vs
+			}()
+			t.Eventually(func(it assert.It) {
+				act.Get(t).Lock()
+				defer act.Get(t).Unlock()
+				it.Must.Equal(concurrency.Get(t), act.Get(t).Active)
+			})
+			close(release.Get(t))
+
+			t.Must.Equal(50, len(<-done))
+			t.Must.Equal(concurrency.Get(t), act.Get(t).Peak,
+				"transformations are expected to run in parallel, up to the concurrency limit")
+		})
+	})
+
+	s.When("a transformation fails", func(s *testcase.Spec) {
+		var (
+			expErr = testcase.Let(s, func(t *testcase.T) error { return errors.New(t.Random.String()) })
+			closed = testcase.Let(s, func(t *testcase.T) *int32 { return new(int32) })
+			calls  = testcase.Let(s, func(t *testcase.T) *int32 { return new(int32) })
+		)
+		concurrency.LetValue(s, 2)
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			closed := closed.Get(t)
+			src := iterators.Stub[int](ranges.Int(1, 1000))
+			src.StubClose = func() error { atomic.AddInt32(closed, 1); return nil }
+			return src
+		})
+		transform.Let(s, func(t *testcase.T) func(ctx context.Context, n int) (int, error) {
+			expErr, calls := expErr.Get(t), calls.Get(t)
+			return func(ctx context.Context, n int) (int, error) {
+				atomic.AddInt32(calls, 1)
+				if n == 5 {
+					return 0, expErr
+				}
+				return n, nil
+			}
+		})
+
+		s.Then("the first error stops the iteration and closes the source", func(t *testcase.T) {
+			_, err := iterators.Collect(subject(t))
+			t.Must.ErrorIs(expErr.Get(t), err)
+			t.Must.Equal(int32(1), atomic.LoadInt32(closed.Get(t)))
+			t.Must.True(atomic.LoadInt32(calls.Get(t)) < 1000)
+		})
+	})
+
+	s.When("the source iterator fails", func(s *testcase.Spec) {
+		expErr := testcase.Let(s, func(t *testcase.T) error { return errors.New(t.Random.String()) })
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Error[int](expErr.Get(t))
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject(t))
+			t.Must.ErrorIs(expErr.Get(t), err)
+		})
+	})
+
+	s.When("the context is cancelled during the iteration", func(s *testcase.Spec) {
+		type cancellable struct {
+			Context context.Context
+			Cancel  func()
+		}
+		c := testcase.Let(s, func(t *testcase.T) cancellable {
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Defer(cancel)
+			return cancellable{Context: ctx, Cancel: cancel}
+		})
+		ctx.Let(s, func(t *testcase.T) context.Context {
+			return c.Get(t).Context
+		})
+		concurrency.LetValue(s, 1)
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return ranges.Int(1, 1000)
+		})
+		transform.Let(s, func(t *testcase.T) func(ctx context.Context, n int) (int, error) {
+			cancel := c.Get(t).Cancel
+			return func(ctx context.Context, n int) (int, error) {
+				if n == 10 {
+					cancel()
+				}
+				return n, nil
+			}
+		})
+
+		s.Then("the iteration stops", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject(t))
+			t.Must.ErrorIs(context.Canceled, err)
+			t.Must.True(len(vs) < 1000)
+		})
+	})
+
+	s.When("the iterator is closed", func(s *testcase.Spec) {
+		var (
+			closeErr = testcase.Let(s, func(t *testcase.T) error { return errors.New(t.Random.String()) })
+			closed   = testcase.Let(s, func(t *testcase.T) *int32 { return new(int32) })
+		)
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			closeErr, closed := closeErr.Get(t), closed.Get(t)
+			src := iterators.Stub[int](ranges.Int(1, 1000))
+			src.StubClose = func() error {
+				atomic.AddInt32(closed, 1)
+				return closeErr
+			}
+			return src
+		})
+
+		s.Then("closing an unstarted iterator closes the source once", func(t *testcase.T) {
+			iter := subject(t)
+			t.Must.ErrorIs(closeErr.Get(t), iter.Close())
+			_ = iter.Close()
+			t.Must.Equal(int32(1), atomic.LoadInt32(closed.Get(t)))
+			t.Must.False(iter.Next())
+		})
+
+		s.Then("closing mid-iteration stops the background work and closes the source", func(t *testcase.T) {
+			iter := subject(t)
+			t.Must.True(iter.Next())
+			t.Must.ErrorIs(closeErr.Get(t), iter.Close())
+			t.Must.False(iter.Next())
+			t.Must.Equal(int32(1), atomic.LoadInt32(closed.Get(t)))
+		})
+	})
+}
+
+func TestParallelMap_implementsIterator(t *testing.T) {
+	transform := func(ctx context.Context, n int) (string, error) { return strconv.Itoa(n), nil }
+
+	t.Run("unordered", iteratorcontracts.Iterator[string](func(tb testing.TB) iterators.Iterator[string] {
+		return iterators.ParallelMap(context.Background(), ranges.Int(1, 20), transform, iterators.ParallelMapConcurrency(4))
+	}).Test)
+
+	t.Run("ordered", iteratorcontracts.Iterator[string](func(tb testing.TB) iterators.Iterator[string] {
+		return iterators.ParallelMap(context.Background(), ranges.Int(1, 20), transform,
+			iterators.ParallelMapConcurrency(4),
+			iterators.ParallelMapOrdered())
+	}).Test)
+}
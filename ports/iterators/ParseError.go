@@ -0,0 +1,68 @@
+package iterators
+
+import (
+	"fmt"
+	"io"
+)
+
+// ParseError is returned by the parsing iterators, such as CSV and JSONArray,
+// and reports the position of the invalid element in the input.
+type ParseError struct {
+	// Line is the 1-based line number of the invalid element.
+	Line int
+	// Column is the 1-based byte position of the invalid element in its line.
+	Column int
+	// Offset is the byte offset from the beginning of the input where the error was detected.
+	Offset int64
+	Err    error
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Err)
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// positionReader tracks the newlines of the read input,
+// to be able to translate byte offsets into line and column positions.
+type positionReader struct {
+	Reader io.Reader
+
+	read int64
+	// newlines holds the offsets of the newlines which were not yet passed by a Position query.
+	newlines  []int64
+	line      int
+	lineStart int64
+}
+
+func (r *positionReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == '\n' {
+			r.newlines = append(r.newlines, r.read+int64(i))
+		}
+	}
+	r.read += int64(n)
+	return n, err
+}
+
+// Position returns the 1-based line and column of the byte offset.
+// The offsets must be queried in increasing order, which allows forgetting the passed newlines.
+func (r *positionReader) Position(offset int64) (line, column int) {
+	for 0 < len(r.newlines) && r.newlines[0] < offset {
+		r.lineStart = r.newlines[0] + 1
+		r.line++
+		r.newlines = r.newlines[1:]
+	}
+	return r.line + 1, int(offset-r.lineStart) + 1
+}
+
+func closeReader(r io.Reader) error {
+	closer, ok := r.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return closer.Close()
+}
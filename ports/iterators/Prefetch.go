@@ -0,0 +1,102 @@
+package iterators
+
+import "sync"
+
+// Prefetch reads ahead up to n elements of the iterator in a background goroutine,
+// while the consumer is busy processing the current element.
+// A non-positive n is treated as one.
+// It is useful when the source iterator is I/O bound, like a database cursor,
+// and the processing of each element is slow, thus the source would otherwise sit idle.
+//
+// The elements and the error of the source iterator are yielded in their original order.
+// Closing the iterator stops the background goroutine, and closes the source iterator.
+// Since the source iterator is not used concurrently,
+// Close waits for the in-progress Next call of the source iterator to return before closing it.
+func Prefetch[T any](iter Iterator[T], n int) Iterator[T] {
+	return &prefetchIter[T]{Iterator: iter, Size: n}
+}
+
+type prefetchIter[T any] struct {
+	Iterator Iterator[T]
+	// Size is the maximum number of the prefetched elements.
+	// The background goroutine holds one of them, while it waits to pass it over.
+	Size int
+
+	init    sync.Once
+	started bool
+	values  chan T
+	stop    chan struct{}
+	stopped chan struct{}
+	// srcErr is written by the background goroutine before it closes the values channel.
+	srcErr error
+
+	close    sync.Once
+	closeErr error
+	closed   bool
+	done     bool
+	err      error
+	value    T
+}
+
+func (i *prefetchIter[T]) start() {
+	i.init.Do(func() {
+		i.started = true
+		size := i.Size
+		if size < 1 {
+			size = 1
+		}
+		i.values = make(chan T, size-1)
+		i.stop = make(chan struct{})
+		i.stopped = make(chan struct{})
+		go i.fetch()
+	})
+}
+
+func (i *prefetchIter[T]) fetch() {
+	defer close(i.stopped)
+	defer close(i.values)
+	for i.Iterator.Next() {
+		select {
+		case <-i.stop:
+			return
+		case i.values <- i.Iterator.Value():
+		}
+	}
+	i.srcErr = i.Iterator.Err()
+}
+
+func (i *prefetchIter[T]) Next() bool {
+	if i.closed || i.done || i.err != nil {
+		return false
+	}
+	i.start()
+	v, ok := <-i.values
+	if !ok {
+		i.done = true
+		i.err = i.srcErr
+		return false
+	}
+	i.value = v
+	return true
+}
+
+func (i *prefetchIter[T]) Close() error {
+	i.closed = true
+	i.init.Do(func() {}) // prevent starting after close
+	i.close.Do(func() {
+		if i.started {
+			close(i.stop)
+			<-i.stopped
+		}
+		i.closeErr = i.Iterator.Close()
+	})
+	return i.closeErr
+}
+
+func (i *prefetchIter[T]) Err() error {
+	return i.err
+}
+
+func (i *prefetchIter[T]) Value() T {
+	return i.value
+}
@@ -0,0 +1,83 @@
+package iterators_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+)
+
+func TestPrefetch(t *testing.T) {
+	t.Run("elements are yielded in order", func(t *testing.T) {
+		vs, err := iterators.Collect(iterators.Prefetch(ranges.Int(1, 100), 10))
+		assert.NoError(t, err)
+		assert.Equal(t, 100, len(vs))
+		for i, v := range vs {
+			assert.Equal(t, i+1, v)
+		}
+	})
+
+	t.Run("elements are fetched ahead while the consumer is busy, up to the given size", func(t *testing.T) {
+		const n = 5
+		var fetched int32
+		src := iterators.Stub(ranges.Int(1, 100))
+		src.StubNext = func() bool {
+			ok := src.Iterator.Next()
+			if ok {
+				atomic.AddInt32(&fetched, 1)
+			}
+			return ok
+		}
+		iter := iterators.Prefetch[int](src, n)
+
+		assert.True(t, iter.Next())
+		assert.Eventually(t, time.Second, func(it assert.It) {
+			it.Must.Equal(int32(1+n), atomic.LoadInt32(&fetched))
+		})
+		// Close waits for the background goroutine to stop, thus any further prefetching would be already counted.
+		assert.NoError(t, iter.Close())
+		assert.Equal(t, int32(1+n), atomic.LoadInt32(&fetched),
+			"prefetching is expected to be bound by the size")
+	})
+
+	t.Run("the error of the source is yielded after the prefetched elements", func(t *testing.T) {
+		expErr := errors.New("boom")
+		vs, err := iterators.Collect(iterators.Prefetch(iterators.Chain(ranges.Int(1, 3), iterators.Error[int](expErr)), 10))
+		assert.ErrorIs(t, expErr, err)
+		assert.Equal(t, []int{1, 2, 3}, vs)
+	})
+
+	t.Run("closing the iterator stops the prefetching and closes the source", func(t *testing.T) {
+		var closed int32
+		src := iterators.Stub(ranges.Int(1, 1000))
+		src.StubClose = func() error { atomic.AddInt32(&closed, 1); return nil }
+		iter := iterators.Prefetch[int](src, 3)
+		assert.True(t, iter.Next())
+		assert.NoError(t, iter.Close())
+		assert.NoError(t, iter.Close())
+		assert.Equal(t, int32(1), atomic.LoadInt32(&closed))
+		assert.False(t, iter.Next())
+	})
+
+	t.Run("closing an unstarted iterator closes the source", func(t *testing.T) {
+		expErr := errors.New("boom")
+		src := iterators.Stub(ranges.Int(1, 10))
+		src.StubClose = func() error { return expErr }
+		iter := iterators.Prefetch[int](src, 3)
+		assert.ErrorIs(t, expErr, iter.Close())
+		assert.False(t, iter.Next())
+	})
+}
+
+func TestPrefetch_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		t := testcase.ToT(&tb)
+		return iterators.Prefetch(ranges.Int(1, 50), t.Random.IntB(0, 10))
+	}).Test(t)
+}
@@ -0,0 +1,61 @@
+package iterators
+
+// Scan is the lazy version of Reduce, which yields every intermediate result of the accumulation.
+// This is useful for computing running totals over a stream.
+func Scan[
+	R, T any,
+	FN func(R, T) R |
+		func(R, T) (R, error),
+](iter Iterator[T], initial R, blk FN) Iterator[R] {
+	var do func(R, T) (R, error)
+	switch blk := any(blk).(type) {
+	case func(R, T) R:
+		do = func(result R, t T) (R, error) {
+			return blk(result, t), nil
+		}
+	case func(R, T) (R, error):
+		do = blk
+	}
+	return &scanIter[R, T]{Iterator: iter, Accumulate: do, value: initial}
+}
+
+type scanIter[R, T any] struct {
+	Iterator   Iterator[T]
+	Accumulate func(R, T) (R, error)
+
+	closed bool
+	err    error
+	value  R
+}
+
+func (i *scanIter[R, T]) Close() error {
+	i.closed = true
+	return i.Iterator.Close()
+}
+
+func (i *scanIter[R, T]) Err() error {
+	if i.err != nil {
+		return i.err
+	}
+	return i.Iterator.Err()
+}
+
+func (i *scanIter[R, T]) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+	if !i.Iterator.Next() {
+		return false
+	}
+	v, err := i.Accumulate(i.value, i.Iterator.Value())
+	if err != nil {
+		i.err = err
+		return false
+	}
+	i.value = v
+	return true
+}
+
+func (i *scanIter[R, T]) Value() R {
+	return i.value
+}
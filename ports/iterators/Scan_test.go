@@ -0,0 +1,55 @@
+package iterators_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestScan(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		accumulator = testcase.Let(s, func(t *testcase.T) func(sum, n int) (int, error) {
+			return func(sum, n int) (int, error) { return sum + n, nil }
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Scan(ranges.Int(1, 5), 0, accumulator.Get(t))
+		})
+	)
+
+	s.Then("every intermediate result is yielded", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 3, 6, 10, 15}, vs)
+	})
+
+	s.When("the accumulator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		accumulator.Let(s, func(t *testcase.T) func(sum, n int) (int, error) {
+			next := accumulator.Super(t)
+			return func(sum, n int) (int, error) {
+				if n == 3 {
+					return sum, expErr
+				}
+				return next(sum, n)
+			}
+		})
+
+		s.Then("the iteration stops with its error", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+			t.Must.Equal([]int{1, 3}, vs)
+		})
+	})
+}
+
+func TestScan_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[string](func(tb testing.TB) iterators.Iterator[string] {
+		return iterators.Scan(ranges.Char('a', 'e'), "", func(s string, r rune) string { return s + string(r) })
+	}).Test(t)
+}
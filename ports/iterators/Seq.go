@@ -0,0 +1,139 @@
+//go:build go1.23
+
+package iterators
+
+import (
+	"iter"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+)
+
+// ToSeq converts an Iterator into a range-over-func compatible sequence.
+// The iterator is closed when the sequence is exhausted, or the range loop is stopped.
+// Since iter.Seq can't express failures, the iterator's Err must be checked after the loop,
+// or use ToSeq2 to receive the error as part of the sequence.
+func ToSeq[T any](i Iterator[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		defer i.Close()
+		for i.Next() {
+			if !yield(i.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// ToSeq2 converts an Iterator into a range-over-func compatible sequence, which yields the error as the second value.
+// When the iterator fails, or it fails to close, the last element of the sequence holds the error with a zero value.
+// The iterator is closed when the sequence is exhausted, or the range loop is stopped.
+func ToSeq2[T any](i Iterator[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var done bool
+		defer func() {
+			if done {
+				return
+			}
+			_ = i.Close()
+		}()
+		for i.Next() {
+			if !yield(i.Value(), nil) {
+				return
+			}
+		}
+		done = true
+		if err := errorkit.Merge(i.Err(), i.Close()); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// FromSeq converts a range-over-func sequence into an Iterator.
+// Closing the Iterator stops the sequence.
+func FromSeq[T any](seq iter.Seq[T]) Iterator[T] {
+	next, stop := iter.Pull(seq)
+	return FromPull(next, stop)
+}
+
+// FromSeq2 converts a range-over-func sequence with errors into an Iterator.
+// The iteration stops at the first error, which is returned by the Iterator's Err.
+// Closing the Iterator stops the sequence.
+func FromSeq2[T any](seq iter.Seq2[T, error]) Iterator[T] {
+	next, stop := iter.Pull2(seq)
+	return FromPull2(next, stop)
+}
+
+// FromPull converts an iter.Pull style next and stop function pair into an Iterator.
+// Closing the Iterator calls the stop function.
+func FromPull[T any](next func() (T, bool), stop func()) Iterator[T] {
+	return FromPull2(func() (T, error, bool) {
+		v, ok := next()
+		return v, nil, ok
+	}, stop)
+}
+
+// FromPull2 converts an iter.Pull2 style next and stop function pair, where the second value is an error, into an Iterator.
+// The iteration stops at the first error, which is returned by the Iterator's Err.
+// Closing the Iterator calls the stop function.
+func FromPull2[T any](next func() (T, error, bool), stop func()) Iterator[T] {
+	return &pullIter[T]{NextFn: next, StopFn: stop}
+}
+
+type pullIter[T any] struct {
+	NextFn func() (T, error, bool)
+	StopFn func()
+
+	closed bool
+	err    error
+	value  T
+}
+
+func (i *pullIter[T]) Close() error {
+	if i.closed {
+		return nil
+	}
+	i.closed = true
+	if i.StopFn != nil {
+		i.StopFn()
+	}
+	return nil
+}
+
+func (i *pullIter[T]) Err() error {
+	return i.err
+}
+
+func (i *pullIter[T]) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+	v, err, ok := i.NextFn()
+	if !ok {
+		return false
+	}
+	if err != nil {
+		i.err = err
+		return false
+	}
+	i.value = v
+	return true
+}
+
+func (i *pullIter[T]) Value() T {
+	return i.value
+}
+
+// ToPull converts an Iterator into an iter.Pull style next and stop function pair.
+// The stop function closes the Iterator.
+// Since the next function can't express failures, the iterator's Err must be checked after the iteration,
+// or use ToPull2 to receive the error from the next function.
+func ToPull[T any](i Iterator[T]) (next func() (T, bool), stop func()) {
+	return iter.Pull(ToSeq(i))
+}
+
+// ToPull2 converts an Iterator into an iter.Pull2 style next and stop function pair,
+// where the next function's second value is the iteration error.
+// The stop function closes the Iterator.
+func ToPull2[T any](i Iterator[T]) (next func() (T, error, bool), stop func()) {
+	return iter.Pull2(ToSeq2(i))
+}
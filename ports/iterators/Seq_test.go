@@ -0,0 +1,255 @@
+//go:build go1.23
+
+package iterators_test
+
+import (
+	"errors"
+	"iter"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func ExampleToSeq() {
+	var it iterators.Iterator[int] = ranges.Int(1, 10)
+
+	for n := range iterators.ToSeq(it) {
+		_ = n
+	}
+	if err := it.Err(); err != nil {
+		return
+	}
+}
+
+func ExampleToSeq2() {
+	var it iterators.Iterator[int] = ranges.Int(1, 10)
+
+	for n, err := range iterators.ToSeq2(it) {
+		if err != nil {
+			return
+		}
+		_ = n
+	}
+}
+
+func TestToSeq(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		closed = testcase.LetValue(s, 0)
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			stub := iterators.Stub(ranges.Int(1, 5))
+			stub.StubClose = func() error { closed.Set(t, closed.Get(t)+1); return nil }
+			return stub
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iter.Seq[int] {
+			return iterators.ToSeq(source.Get(t))
+		})
+	)
+
+	s.Then("elements are yielded in a range loop", func(t *testcase.T) {
+		var vs []int
+		for n := range subject.Get(t) {
+			vs = append(vs, n)
+		}
+		t.Must.Equal([]int{1, 2, 3, 4, 5}, vs)
+	})
+
+	s.Then("the iterator is closed when the loop breaks", func(t *testcase.T) {
+		for n := range subject.Get(t) {
+			if n == 2 {
+				break
+			}
+		}
+		t.Must.Equal(1, closed.Get(t))
+	})
+}
+
+func TestToSeq2(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		closed   = testcase.LetValue(s, 0)
+		iterErr  = testcase.LetValue[error](s, nil)
+		closeErr = testcase.LetValue[error](s, nil)
+		source   = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			stub := iterators.Stub(ranges.Int(1, 3))
+			stub.StubErr = func() error { return iterErr.Get(t) }
+			stub.StubClose = func() error {
+				closed.Set(t, closed.Get(t)+1)
+				return closeErr.Get(t)
+			}
+			return stub
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iter.Seq2[int, error] {
+			return iterators.ToSeq2(source.Get(t))
+		})
+	)
+	collect := func(t *testcase.T) (vs []int, errs []error) {
+		for n, err := range subject.Get(t) {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			vs = append(vs, n)
+		}
+		return vs, errs
+	}
+
+	s.Then("elements are yielded without an error", func(t *testcase.T) {
+		vs, errs := collect(t)
+		t.Must.Empty(errs)
+		t.Must.Equal([]int{1, 2, 3}, vs)
+	})
+
+	s.Then("the iterator is closed once when the loop breaks", func(t *testcase.T) {
+		for range subject.Get(t) {
+			break
+		}
+		t.Must.Equal(1, closed.Get(t))
+	})
+
+	s.When("the iterator fails", func(s *testcase.Spec) {
+		iterErr.Let(s, func(t *testcase.T) error { return errors.New("boom") })
+
+		s.Then("its error is yielded last", func(t *testcase.T) {
+			vs, errs := collect(t)
+			t.Must.Equal([]int{1, 2, 3}, vs)
+			t.Must.Equal([]error{iterErr.Get(t)}, errs)
+		})
+	})
+
+	s.When("closing the iterator fails", func(s *testcase.Spec) {
+		closeErr.Let(s, func(t *testcase.T) error { return errors.New("boom") })
+
+		s.Then("the close error is yielded", func(t *testcase.T) {
+			_, errs := collect(t)
+			t.Must.Equal(1, len(errs))
+			t.Must.ErrorIs(closeErr.Get(t), errs[0])
+		})
+	})
+}
+
+func TestFromSeq(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		stopped = testcase.LetValue(s, false)
+		seq     = testcase.Let(s, func(t *testcase.T) iter.Seq[int] {
+			return func(yield func(int) bool) {
+				defer stopped.Set(t, true)
+				for n := 1; n <= 3; n++ {
+					if !yield(n) {
+						return
+					}
+				}
+			}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.FromSeq[int](seq.Get(t))
+		})
+	)
+
+	s.Then("elements are iterated", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 2, 3}, vs)
+	})
+
+	s.Then("closing the iterator stops the sequence", func(t *testcase.T) {
+		it := subject.Get(t)
+		t.Must.True(it.Next())
+		t.Must.NoError(it.Close())
+		t.Must.True(stopped.Get(t))
+		t.Must.False(it.Next())
+	})
+}
+
+func TestFromSeq2(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		expErr = testcase.Let(s, func(t *testcase.T) error { return errors.New("boom") })
+		seq    = testcase.Let(s, func(t *testcase.T) iter.Seq2[int, error] {
+			expErr := expErr.Get(t)
+			return func(yield func(int, error) bool) {
+				if !yield(1, nil) {
+					return
+				}
+				if !yield(0, expErr) {
+					return
+				}
+				yield(2, nil)
+			}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.FromSeq2(seq.Get(t))
+		})
+	)
+
+	s.Then("the iteration stops at the first yielded error", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.ErrorIs(expErr.Get(t), err)
+		t.Must.Equal([]int{1}, vs)
+	})
+}
+
+func TestToPull(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	s.Test("the elements are pulled one by one", func(t *testcase.T) {
+		next, stop := iterators.ToPull(ranges.Int(1, 3))
+		t.Defer(stop)
+		var vs []int
+		for {
+			v, ok := next()
+			if !ok {
+				break
+			}
+			vs = append(vs, v)
+		}
+		t.Must.Equal([]int{1, 2, 3}, vs)
+	})
+
+	s.Test("FromPull converts it back to an iterator", func(t *testcase.T) {
+		vs, err := iterators.Collect(iterators.FromPull(iterators.ToPull(ranges.Int(1, 5))))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 2, 3, 4, 5}, vs)
+	})
+}
+
+func TestToPull2(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	s.Test("the error of the iterator is pulled", func(t *testcase.T) {
+		expErr := errors.New("boom")
+		next, stop := iterators.ToPull2(iterators.Error[int](expErr))
+		t.Defer(stop)
+		_, err, ok := next()
+		t.Must.True(ok)
+		t.Must.ErrorIs(expErr, err)
+		_, _, ok = next()
+		t.Must.False(ok)
+	})
+
+	s.Test("FromPull2 converts it back to an iterator", func(t *testcase.T) {
+		vs, err := iterators.Collect(iterators.FromPull2(iterators.ToPull2(ranges.Int(1, 5))))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 2, 3, 4, 5}, vs)
+	})
+}
+
+func TestFromSeq_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		return iterators.FromSeq(iterators.ToSeq(ranges.Int(1, 10)))
+	}).Test(t)
+}
+
+func TestFromSeq2_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		return iterators.FromSeq2(iterators.ToSeq2(ranges.Int(1, 10)))
+	}).Test(t)
+}
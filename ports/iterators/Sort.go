@@ -0,0 +1,242 @@
+package iterators
+
+import (
+	"io"
+	"path"
+	"sort"
+	"strconv"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/pkg/serializers"
+	"go.llib.dev/frameless/ports/filesystem"
+	"go.llib.dev/testcase/random"
+)
+
+// Sort sorts the elements of an iterator which might not fit into the memory (external merge sort).
+// The elements are sorted in memory-bounded runs, which are spilled into temporary files on the file system,
+// then the sorted runs are merged back with a k-way merge.
+// When all the elements fit into a single run, nothing is written to the file system.
+//
+// The sorting is stable, and it starts with the first Next call, which consumes the whole source iterator.
+// Closing the iterator removes the temporary files.
+func Sort[T any](iter Iterator[T], less func(a, b T) bool, fsys filesystem.FileSystem, opts ...SortOption) Iterator[T] {
+	var c sortConfig
+	for _, opt := range opts {
+		opt.configureSort(&c)
+	}
+	return &sortIter[T]{
+		Iterator:   iter,
+		Less:       less,
+		FileSystem: fsys,
+		Config:     c,
+	}
+}
+
+type SortOption interface {
+	configureSort(c *sortConfig)
+}
+
+type sortOptionFunc func(c *sortConfig)
+
+func (fn sortOptionFunc) configureSort(c *sortConfig) { fn(c) }
+
+// SortRunSize sets the maximum number of elements sorted in memory at once.
+//
+// Default: 10000
+func SortRunSize(n int) SortOption {
+	return sortOptionFunc(func(c *sortConfig) {
+		c.RunSize = n
+	})
+}
+
+// SortSerializer sets the serializer which encodes the sorted runs into the temporary files.
+//
+// Default: serializers.JSONStream{}
+func SortSerializer(s serializers.ListSerializer) SortOption {
+	return sortOptionFunc(func(c *sortConfig) {
+		c.Serializer = s
+	})
+}
+
+// SortDirectory sets the directory of the temporary files on the file system.
+//
+// Default: the root of the file system
+func SortDirectory(dir string) SortOption {
+	return sortOptionFunc(func(c *sortConfig) {
+		c.Directory = dir
+	})
+}
+
+type sortConfig struct {
+	RunSize    int
+	Serializer serializers.ListSerializer
+	Directory  string
+}
+
+func (c sortConfig) getRunSize() int {
+	const defaultRunSize = 10000
+	if c.RunSize < 1 {
+		return defaultRunSize
+	}
+	return c.RunSize
+}
+
+func (c sortConfig) getSerializer() serializers.ListSerializer {
+	if c.Serializer == nil {
+		return serializers.JSONStream{}
+	}
+	return c.Serializer
+}
+
+type sortIter[T any] struct {
+	Iterator   Iterator[T]
+	Less       func(a, b T) bool
+	FileSystem filesystem.FileSystem
+	Config     sortConfig
+
+	init      bool
+	closed    bool
+	srcClosed bool
+	err       error
+	prefix    string
+	files     []string
+	merged    Iterator[T]
+	value     T
+}
+
+func (i *sortIter[T]) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+	if !i.init {
+		i.init = true
+		if err := i.sort(); err != nil {
+			i.err = err
+			return false
+		}
+	}
+	if !i.merged.Next() {
+		i.err = i.merged.Err()
+		return false
+	}
+	i.value = i.merged.Value()
+	return true
+}
+
+func (i *sortIter[T]) sort() error {
+	var (
+		size = i.Config.getRunSize()
+		run  = make([]T, 0, size)
+	)
+	for i.Iterator.Next() {
+		run = append(run, i.Iterator.Value())
+		if len(run) < size {
+			continue
+		}
+		if err := i.spill(run); err != nil {
+			return err
+		}
+		run = run[:0]
+	}
+	i.srcClosed = true
+	if err := errorkit.Merge(i.Iterator.Err(), i.Iterator.Close()); err != nil {
+		return err
+	}
+	if len(i.files) == 0 { // everything fit into the memory
+		i.sortRun(run)
+		i.merged = Slice(run)
+		return nil
+	}
+	if 0 < len(run) {
+		if err := i.spill(run); err != nil {
+			return err
+		}
+	}
+	runs := make([]Iterator[T], 0, len(i.files))
+	for _, name := range i.files {
+		run, err := i.openRun(name)
+		if err != nil {
+			for _, run := range runs {
+				err = errorkit.Merge(err, run.Close())
+			}
+			return err
+		}
+		runs = append(runs, run)
+	}
+	i.merged = Merge(i.Less, runs...)
+	return nil
+}
+
+func (i *sortIter[T]) sortRun(run []T) {
+	sort.SliceStable(run, func(a, b int) bool {
+		return i.Less(run[a], run[b])
+	})
+}
+
+func (i *sortIter[T]) spill(run []T) (rErr error) {
+	i.sortRun(run)
+	if i.prefix == "" {
+		i.prefix = "frameless-sort-" + random.New(random.CryptoSeed{}).UUID()
+	}
+	name := path.Join(i.Config.Directory, i.prefix+"-"+strconv.Itoa(len(i.files)))
+	f, err := filesystem.Create(i.FileSystem, name)
+	if err != nil {
+		return err
+	}
+	i.files = append(i.files, name)
+	defer errorkit.Finish(&rErr, f.Close)
+	enc := i.Config.getSerializer().MakeListEncoder(f)
+	for _, v := range run {
+		if err := enc.Encode(v); err != nil {
+			return errorkit.Merge(err, enc.Close())
+		}
+	}
+	return enc.Close()
+}
+
+func (i *sortIter[T]) openRun(name string) (Iterator[T], error) {
+	f, err := filesystem.Open(i.FileSystem, name)
+	if err != nil {
+		return nil, err
+	}
+	// the file is closed by the iterator, thus the decoder shouldn't close it
+	dec := i.Config.getSerializer().MakeListDecoder(struct{ io.Reader }{Reader: f})
+	return Func[T](func() (v T, ok bool, err error) {
+		if !dec.Next() {
+			return v, false, dec.Err()
+		}
+		if err := dec.Decode(&v); err != nil {
+			return v, false, err
+		}
+		return v, true, nil
+	}, OnClose(func() error {
+		return errorkit.Merge(dec.Close(), f.Close())
+	})), nil
+}
+
+func (i *sortIter[T]) Close() error {
+	if i.closed {
+		return nil
+	}
+	i.closed = true
+	var errs []error
+	if i.merged != nil {
+		errs = append(errs, i.merged.Close())
+	}
+	if !i.srcClosed {
+		errs = append(errs, i.Iterator.Close())
+	}
+	for _, name := range i.files {
+		errs = append(errs, i.FileSystem.Remove(name))
+	}
+	i.files = nil
+	return errorkit.Merge(errs...)
+}
+
+func (i *sortIter[T]) Err() error {
+	return i.err
+}
+
+func (i *sortIter[T]) Value() T {
+	return i.value
+}
@@ -0,0 +1,188 @@
+package iterators_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/pkg/serializers"
+	"go.llib.dev/frameless/ports/filesystem"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestSort(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		fsys = testcase.Let(s, func(t *testcase.T) *memory.FileSystem {
+			return &memory.FileSystem{}
+		})
+		values = testcase.Let(s, func(t *testcase.T) []int {
+			var vs []int
+			for i := 0; i < 100; i++ {
+				vs = append(vs, t.Random.IntB(-1000, 1000))
+			}
+			return vs
+		})
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Slice(values.Get(t))
+		})
+		options = testcase.Let(s, func(t *testcase.T) []iterators.SortOption {
+			return []iterators.SortOption{iterators.SortRunSize(1000)}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Sort(source.Get(t), func(a, b int) bool { return a < b }, fsys.Get(t), options.Get(t)...)
+		})
+	)
+	countFiles := func(t *testcase.T, dir string) int {
+		entries, err := filesystem.ReadDir(fsys.Get(t), dir)
+		t.Must.NoError(err)
+		return len(entries)
+	}
+	sorted := func(t *testcase.T) []int {
+		vs := append([]int{}, values.Get(t)...)
+		sort.Ints(vs)
+		return vs
+	}
+
+	s.When("the elements fit into a single run", func(s *testcase.Spec) {
+		s.Then("they are sorted in memory", func(t *testcase.T) {
+			got, err := iterators.Collect(subject.Get(t))
+			t.Must.NoError(err)
+			t.Must.Equal(sorted(t), got)
+			t.Must.Equal(0, countFiles(t, "."))
+		})
+	})
+
+	s.When("the elements don't fit into a single run", func(s *testcase.Spec) {
+		values.Let(s, func(t *testcase.T) []int {
+			var vs []int
+			for i := 0; i < 1000; i++ {
+				vs = append(vs, t.Random.IntB(-1000, 1000))
+			}
+			return vs
+		})
+		options.Let(s, func(t *testcase.T) []iterators.SortOption {
+			return []iterators.SortOption{iterators.SortRunSize(64)}
+		})
+
+		s.Then("they are sorted through runs spilled into the file system", func(t *testcase.T) {
+			iter := subject.Get(t)
+			t.Must.True(iter.Next())
+			t.Must.Equal(16, countFiles(t, "."))
+			got := []int{iter.Value()}
+			for iter.Next() {
+				got = append(got, iter.Value())
+			}
+			t.Must.NoError(iter.Err())
+			t.Must.Equal(sorted(t), got)
+		})
+
+		s.Then("the temporary files are removed on close", func(t *testcase.T) {
+			iter := subject.Get(t)
+			t.Must.True(iter.Next())
+			t.Must.NotEqual(0, countFiles(t, "."))
+			t.Must.NoError(iter.Close())
+			t.Must.Equal(0, countFiles(t, "."))
+		})
+
+		s.And("the source iterator fails", func(s *testcase.Spec) {
+			expErr := errors.New("boom")
+			source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+				return iterators.Chain(source.Super(t), iterators.Error[int](expErr))
+			})
+
+			s.Then("its error is propagated, and the spilled runs are removed", func(t *testcase.T) {
+				_, err := iterators.Collect(subject.Get(t))
+				t.Must.ErrorIs(expErr, err)
+				t.Must.Equal(0, countFiles(t, "."))
+			})
+		})
+	})
+
+	s.When("the iterator is closed before the iteration started", func(s *testcase.Spec) {
+		closed := testcase.LetValue(s, false)
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			stub := iterators.Stub(source.Super(t))
+			stub.StubClose = func() error { closed.Set(t, true); return nil }
+			return stub
+		})
+
+		s.Then("the source is closed", func(t *testcase.T) {
+			t.Must.NoError(subject.Get(t).Close())
+			t.Must.True(closed.Get(t))
+		})
+	})
+}
+
+func TestSort_stable(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	type E struct{ Key, Seq int }
+	var (
+		values = testcase.Let(s, func(t *testcase.T) []E {
+			var vs []E
+			for i := 0; i < 500; i++ {
+				vs = append(vs, E{Key: t.Random.IntB(0, 10), Seq: i})
+			}
+			return vs
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[E] {
+			return iterators.Sort(iterators.Slice(values.Get(t)), func(a, b E) bool { return a.Key < b.Key },
+				&memory.FileSystem{}, iterators.SortRunSize(32))
+		})
+	)
+
+	s.Then("equal elements keep their order across the spilled runs", func(t *testcase.T) {
+		got, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		exp := append([]E{}, values.Get(t)...)
+		sort.SliceStable(exp, func(i, j int) bool { return exp[i].Key < exp[j].Key })
+		t.Must.Equal(exp, got)
+	})
+}
+
+func TestSort_options(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	type E struct{ N int }
+	var (
+		fsys = testcase.Let(s, func(t *testcase.T) *memory.FileSystem {
+			fsys := &memory.FileSystem{}
+			t.Must.NoError(fsys.Mkdir("tmp", 0700))
+			return fsys
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[E] {
+			src := iterators.Map[E](ranges.Int(1, 100), func(n int) (E, error) { return E{N: n}, nil })
+			return iterators.Sort(src, func(a, b E) bool { return a.N > b.N }, fsys.Get(t),
+				iterators.SortRunSize(10),
+				iterators.SortDirectory("tmp"),
+				iterators.SortSerializer(serializers.JSON{}))
+		})
+	)
+
+	s.Then("the temporary files are placed into the directory with the custom serializer", func(t *testcase.T) {
+		iter := subject.Get(t)
+		t.Must.True(iter.Next())
+		t.Must.Equal(E{N: 100}, iter.Value())
+		entries, err := filesystem.ReadDir(fsys.Get(t), "tmp")
+		t.Must.NoError(err)
+		t.Must.Equal(10, len(entries))
+		t.Must.NoError(iter.Close())
+		entries, err = filesystem.ReadDir(fsys.Get(t), "tmp")
+		t.Must.NoError(err)
+		t.Must.Empty(entries)
+	})
+}
+
+func TestSort_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		t := testcase.ToT(&tb)
+		return iterators.Sort(ranges.Int(1, 50), func(a, b int) bool { return a > b }, &memory.FileSystem{},
+			iterators.SortRunSize(t.Random.IntB(1, 60)))
+	}).Test(t)
+}
@@ -0,0 +1,89 @@
+package iterators
+
+// TakeWhile yields the elements of the iterator as long as the predicate holds.
+// The iteration stops at the first element that doesn't satisfy the predicate.
+func TakeWhile[T any](iter Iterator[T], predicate func(T) bool) Iterator[T] {
+	return &takeWhileIter[T]{Iterator: iter, Predicate: predicate}
+}
+
+type takeWhileIter[T any] struct {
+	Iterator  Iterator[T]
+	Predicate func(T) bool
+
+	closed bool
+	done   bool
+	value  T
+}
+
+func (i *takeWhileIter[T]) Close() error {
+	i.closed = true
+	return i.Iterator.Close()
+}
+
+func (i *takeWhileIter[T]) Err() error {
+	return i.Iterator.Err()
+}
+
+func (i *takeWhileIter[T]) Next() bool {
+	if i.closed || i.done {
+		return false
+	}
+	if !i.Iterator.Next() {
+		return false
+	}
+	v := i.Iterator.Value()
+	if !i.Predicate(v) {
+		i.done = true
+		return false
+	}
+	i.value = v
+	return true
+}
+
+func (i *takeWhileIter[T]) Value() T {
+	return i.value
+}
+
+// DropWhile skips the elements of the iterator as long as the predicate holds,
+// then it yields every remaining element, starting with the first one that doesn't satisfy the predicate.
+func DropWhile[T any](iter Iterator[T], predicate func(T) bool) Iterator[T] {
+	return &dropWhileIter[T]{Iterator: iter, Predicate: predicate}
+}
+
+type dropWhileIter[T any] struct {
+	Iterator  Iterator[T]
+	Predicate func(T) bool
+
+	closed  bool
+	dropped bool
+	value   T
+}
+
+func (i *dropWhileIter[T]) Close() error {
+	i.closed = true
+	return i.Iterator.Close()
+}
+
+func (i *dropWhileIter[T]) Err() error {
+	return i.Iterator.Err()
+}
+
+func (i *dropWhileIter[T]) Next() bool {
+	if i.closed {
+		return false
+	}
+	for i.Iterator.Next() {
+		v := i.Iterator.Value()
+		if !i.dropped && i.Predicate(v) {
+			continue
+		}
+		i.dropped = true
+		i.value = v
+		return true
+	}
+	return false
+}
+
+func (i *dropWhileIter[T]) Value() T {
+	return i.value
+}
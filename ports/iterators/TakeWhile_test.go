@@ -0,0 +1,97 @@
+package iterators_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestTakeWhile(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Slice([]int{1, 2, 3, 10, 4, 5})
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.TakeWhile(source.Get(t), func(n int) bool { return n < 5 })
+		})
+	)
+
+	s.Then("elements are taken until the predicate fails", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{1, 2, 3}, vs)
+	})
+
+	s.When("the source iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Error[int](expErr)
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+}
+
+func TestTakeWhile_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		return iterators.TakeWhile(ranges.Int(1, 20), func(n int) bool { return n < 10 })
+	}).Test(t)
+}
+
+func TestDropWhile(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		source = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Slice([]int{1, 2, 3, 10, 4, 5})
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.DropWhile(source.Get(t), func(n int) bool { return n < 5 })
+		})
+	)
+
+	s.Then("elements are dropped until the predicate fails", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal([]int{10, 4, 5}, vs)
+	})
+
+	s.When("every element satisfies the predicate", func(s *testcase.Spec) {
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return ranges.Int(1, 4)
+		})
+
+		s.Then("nothing is yielded", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.NoError(err)
+			t.Must.Empty(vs)
+		})
+	})
+
+	s.When("the source iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		source.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Error[int](expErr)
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+}
+
+func TestDropWhile_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		return iterators.DropWhile(ranges.Int(1, 20), func(n int) bool { return n < 10 })
+	}).Test(t)
+}
@@ -0,0 +1,47 @@
+package iterators
+
+import "go.llib.dev/frameless/pkg/errorkit"
+
+// Pair holds the elements of two iterators at the same position.
+type Pair[A, B any] struct {
+	Left  A
+	Right B
+}
+
+// Zip pairs up the elements of two iterators by their position.
+// The iteration stops when either of the iterators is exhausted.
+func Zip[A, B any](a Iterator[A], b Iterator[B]) Iterator[Pair[A, B]] {
+	return &zipIter[A, B]{A: a, B: b}
+}
+
+type zipIter[A, B any] struct {
+	A Iterator[A]
+	B Iterator[B]
+
+	closed bool
+	value  Pair[A, B]
+}
+
+func (i *zipIter[A, B]) Close() error {
+	i.closed = true
+	return errorkit.Merge(i.A.Close(), i.B.Close())
+}
+
+func (i *zipIter[A, B]) Err() error {
+	return errorkit.Merge(i.A.Err(), i.B.Err())
+}
+
+func (i *zipIter[A, B]) Next() bool {
+	if i.closed {
+		return false
+	}
+	if !i.A.Next() || !i.B.Next() {
+		return false
+	}
+	i.value = Pair[A, B]{Left: i.A.Value(), Right: i.B.Value()}
+	return true
+}
+
+func (i *zipIter[A, B]) Value() Pair[A, B] {
+	return i.value
+}
@@ -0,0 +1,111 @@
+package iterators_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+)
+
+func TestZip(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		left = testcase.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return ranges.Int(1, 3)
+		})
+		right = testcase.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			return iterators.Slice([]string{"a", "b", "c"})
+		})
+		subject = testcase.Let(s, func(t *testcase.T) iterators.Iterator[iterators.Pair[int, string]] {
+			return iterators.Zip(left.Get(t), right.Get(t))
+		})
+	)
+	expected := []iterators.Pair[int, string]{
+		{Left: 1, Right: "a"},
+		{Left: 2, Right: "b"},
+		{Left: 3, Right: "c"},
+	}
+
+	s.Then("elements are paired by their position", func(t *testcase.T) {
+		vs, err := iterators.Collect(subject.Get(t))
+		t.Must.NoError(err)
+		t.Must.Equal(expected, vs)
+	})
+
+	s.When("the left iterator is longer", func(s *testcase.Spec) {
+		left.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return ranges.Int(1, 5)
+		})
+
+		s.Then("iteration stops with the shorter iterator", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.NoError(err)
+			t.Must.Equal(expected, vs)
+		})
+	})
+
+	s.When("the right iterator is longer", func(s *testcase.Spec) {
+		right.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			return iterators.Slice([]string{"a", "b", "c", "d", "e"})
+		})
+
+		s.Then("iteration stops with the shorter iterator", func(t *testcase.T) {
+			vs, err := iterators.Collect(subject.Get(t))
+			t.Must.NoError(err)
+			t.Must.Equal(expected, vs)
+		})
+	})
+
+	s.When("the left iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		left.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			return iterators.Error[int](expErr)
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+
+	s.When("the right iterator fails", func(s *testcase.Spec) {
+		expErr := errors.New("boom")
+		right.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			return iterators.Error[string](expErr)
+		})
+
+		s.Then("its error is propagated", func(t *testcase.T) {
+			_, err := iterators.Collect(subject.Get(t))
+			t.Must.ErrorIs(expErr, err)
+		})
+	})
+
+	s.When("the iterator is closed", func(s *testcase.Spec) {
+		closed := testcase.LetValue(s, 0)
+		left.Let(s, func(t *testcase.T) iterators.Iterator[int] {
+			stub := iterators.Stub(left.Super(t))
+			stub.StubClose = func() error { closed.Set(t, closed.Get(t)+1); return nil }
+			return stub
+		})
+		right.Let(s, func(t *testcase.T) iterators.Iterator[string] {
+			stub := iterators.Stub(right.Super(t))
+			stub.StubClose = func() error { closed.Set(t, closed.Get(t)+1); return nil }
+			return stub
+		})
+
+		s.Then("both iterators are closed", func(t *testcase.T) {
+			t.Must.NoError(subject.Get(t).Close())
+			t.Must.Equal(2, closed.Get(t))
+		})
+	})
+}
+
+func TestZip_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[iterators.Pair[int, rune]](func(tb testing.TB) iterators.Iterator[iterators.Pair[int, rune]] {
+		return iterators.Zip(ranges.Int(1, 10), ranges.Char('a', 'z'))
+	}).Test(t)
+}
@@ -2,6 +2,8 @@ package iterators
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 )
 
 // Paginate will create an Iterator[T] which can be used like any other iterator,
@@ -14,10 +16,31 @@ func Paginate[T any](
 	ctx context.Context,
 	more func(ctx context.Context, offset int) (values []T, hasNext bool, _ error),
 ) Iterator[T] {
-	return &paginator[T]{
+	return PaginateFrom(ctx, "", more)
+}
+
+// PaginateFrom is the resumable version of Paginate.
+// The iteration continues from the position of the Checkpoint,
+// which is retrieved from a previous iteration's Resumable.Checkpoint.
+// The checkpoint is the offset of the next element,
+// thus the more function receives it as the offset of the first page.
+func PaginateFrom[T any](
+	ctx context.Context,
+	checkpoint Checkpoint,
+	more func(ctx context.Context, offset int) (values []T, hasNext bool, _ error),
+) Resumable[T] {
+	i := &paginator[T]{
 		Context: ctx,
 		More:    more,
 	}
+	if checkpoint != "" {
+		offset, err := strconv.Atoi(string(checkpoint))
+		if err != nil || offset < 0 {
+			i.err = fmt.Errorf("%w: %q", ErrInvalidCheckpoint, checkpoint)
+		}
+		i.Offset = offset
+	}
+	return i
 }
 
 type paginator[T any] struct {
@@ -66,6 +89,11 @@ func (i *paginator[T]) Close() error { i.done = true; return nil }
 func (i *paginator[T]) Err() error   { return i.err }
 func (i *paginator[T]) Value() T     { return i.value }
 
+func (i *paginator[T]) Checkpoint() (Checkpoint, error) {
+	position := i.Offset - (len(i.buffer) - i.index)
+	return Checkpoint(strconv.Itoa(position)), nil
+}
+
 func (i *paginator[T]) more() ([]T, error) {
 	if i.noMore {
 		return nil, nil
@@ -161,3 +161,63 @@ func TestPaginate(t *testing.T) {
 		})
 	})
 }
+
+func TestPaginateFrom(t *testing.T) {
+	ctx := context.Background()
+	var values []int
+	for n := 0; n < 25; n++ {
+		values = append(values, n)
+	}
+	var offsets []int
+	more := func(ctx context.Context, offset int) ([]int, bool, error) {
+		offsets = append(offsets, offset)
+		const limit = 10
+		end := offset + limit
+		if len(values) < end {
+			end = len(values)
+		}
+		return values[offset:end], end < len(values), nil
+	}
+
+	t.Run("the checkpoint resumes the iteration after the last consumed element", func(t *testing.T) {
+		offsets = nil
+		iter := iterators.PaginateFrom[int](ctx, "", more)
+		for i := 0; i < 13; i++ {
+			assert.True(t, iter.Next())
+		}
+		assert.Equal(t, 12, iter.Value())
+		checkpoint, err := iter.Checkpoint()
+		assert.NoError(t, err)
+		assert.NoError(t, iter.Close())
+
+		offsets = nil
+		vs, err := iterators.Collect[int](iterators.PaginateFrom(ctx, checkpoint, more))
+		assert.NoError(t, err)
+		assert.Equal(t, values[13:], vs)
+		assert.Equal(t, []int{13, 23}, offsets)
+	})
+
+	t.Run("the checkpoint of an unstarted iteration is its beginning", func(t *testing.T) {
+		checkpoint, err := iterators.PaginateFrom[int](ctx, "", more).Checkpoint()
+		assert.NoError(t, err)
+		vs, err := iterators.Collect[int](iterators.PaginateFrom(ctx, checkpoint, more))
+		assert.NoError(t, err)
+		assert.Equal(t, values, vs)
+	})
+
+	t.Run("the checkpoint of a finished iteration yields nothing", func(t *testing.T) {
+		iter := iterators.PaginateFrom[int](ctx, "", more)
+		for iter.Next() {
+		}
+		checkpoint, err := iter.Checkpoint()
+		assert.NoError(t, err)
+		vs, err := iterators.Collect[int](iterators.PaginateFrom(ctx, checkpoint, more))
+		assert.NoError(t, err)
+		assert.Empty(t, vs)
+	})
+
+	t.Run("malformed checkpoint is an error", func(t *testing.T) {
+		_, err := iterators.Collect[int](iterators.PaginateFrom(ctx, "not an offset", more))
+		assert.ErrorIs(t, iterators.ErrInvalidCheckpoint, err)
+	})
+}
@@ -0,0 +1,34 @@
+package ranges
+
+import (
+	"math"
+
+	"go.llib.dev/frameless/ports/iterators"
+)
+
+// Float returns an iterator with the numbers from begin to end, with the given step between them.
+// A negative step makes a descending range, where begin is expected to be greater than end.
+//
+// Each element is computed from begin and its index, so the floating point errors don't accumulate,
+// and the end is included when the steps reach it within a small tolerance.
+func Float(begin, end, step float64) iterators.Iterator[float64] {
+	if step == 0 {
+		return iterators.Error[float64](ErrZeroStep)
+	}
+	tolerance := math.Abs(step) * 1e-9
+	return &stepRange[float64]{
+		At: func(n int) float64 { return begin + float64(n)*step },
+		InRange: func(v float64) bool {
+			if 0 < step {
+				return v <= end+tolerance
+			}
+			return end-tolerance <= v
+		},
+	}
+}
+
+// FloatFrom returns an infinite iterator with the numbers starting from begin, with the given step between them.
+// Use it together with iterators.Limit or iterators.TakeWhile to bound the iteration.
+func FloatFrom(begin, step float64) iterators.Iterator[float64] {
+	return &stepRange[float64]{At: func(n int) float64 { return begin + float64(n)*step }}
+}
@@ -0,0 +1,45 @@
+package ranges_test
+
+import (
+	"testing"
+
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+)
+
+func TestFloat(t *testing.T) {
+	t.Run("ascending with an inexact step includes the end", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.Float(0, 0.3, 0.1))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, len(vs))
+		assert.Equal(t, 0.0, vs[0])
+		assert.True(t, 0.3-1e-9 < vs[3] && vs[3] < 0.3+1e-9)
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.Float(1, 0, -0.25))
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{1, 0.75, 0.5, 0.25, 0}, vs)
+	})
+
+	t.Run("zero step is an error", func(t *testing.T) {
+		_, err := iterators.Collect(ranges.Float(0, 1, 0))
+		assert.ErrorIs(t, ranges.ErrZeroStep, err)
+	})
+}
+
+func TestFloat_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[float64](func(tb testing.TB) iterators.Iterator[float64] {
+		t := testcase.ToT(&tb)
+		return ranges.Float(t.Random.Float64(), float64(t.Random.IntB(2, 5)), 0.5)
+	}).Test(t)
+}
+
+func TestFloatFrom(t *testing.T) {
+	vs, err := iterators.Collect(iterators.Limit(ranges.FloatFrom(1, 0.5), 3))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 1.5, 2}, vs)
+}
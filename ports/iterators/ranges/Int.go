@@ -1,6 +1,10 @@
 package ranges
 
-import "go.llib.dev/frameless/ports/iterators"
+import (
+	"math"
+
+	"go.llib.dev/frameless/ports/iterators"
+)
 
 func Int(begin, end int) iterators.Iterator[int] {
 	return &intRange{Begin: begin, End: end}
@@ -35,3 +39,43 @@ func (ir *intRange) Next() bool {
 func (ir *intRange) Value() int {
 	return ir.Begin + ir.nextIndex - 1
 }
+
+// IntStep returns an iterator with the numbers from begin to end, with the given step between them.
+// A negative step makes a descending range, where begin is expected to be greater than end.
+// The end is included when it is reached by the steps.
+func IntStep(begin, end, step int) iterators.Iterator[int] {
+	if step == 0 {
+		return iterators.Error[int](ErrZeroStep)
+	}
+	return &stepRange[int]{
+		At: func(n int) int { return begin + n*step },
+		InRange: func(v int) bool {
+			if 0 < step {
+				return v <= end
+			}
+			return end <= v
+		},
+		HasNext: func(v int) bool {
+			// the distance to the end is compared as unsigned, since it might not fit into an int.
+			if 0 < step {
+				return uint(step) <= uint(end)-uint(v)
+			}
+			return uint(-step) <= uint(v)-uint(end)
+		},
+	}
+}
+
+// IntFrom returns an infinite iterator with the numbers starting from begin, with the given step between them.
+// Use it together with iterators.Limit or iterators.TakeWhile to bound the iteration.
+// The iteration ends before the numbers would overflow the int type.
+func IntFrom(begin, step int) iterators.Iterator[int] {
+	return &stepRange[int]{
+		At: func(n int) int { return begin + n*step },
+		HasNext: func(v int) bool {
+			if 0 < step {
+				return v <= math.MaxInt-step
+			}
+			return math.MinInt-step <= v
+		},
+	}
+}
@@ -2,6 +2,7 @@ package ranges_test
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"go.llib.dev/frameless/ports/iterators"
@@ -79,3 +80,69 @@ func TestInt_implementsIterator(t *testing.T) {
 		return ranges.Int(min, max)
 	}).Test(t)
 }
+
+func TestIntStep(t *testing.T) {
+	t.Run("ascending", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.IntStep(0, 10, 3))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 3, 6, 9}, vs)
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.IntStep(10, 0, -5))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{10, 5, 0}, vs)
+	})
+
+	t.Run("empty when the step goes away from the end", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.IntStep(0, 10, -1))
+		assert.NoError(t, err)
+		assert.Empty(t, vs)
+	})
+
+	t.Run("zero step is an error", func(t *testing.T) {
+		_, err := iterators.Collect(ranges.IntStep(0, 10, 0))
+		assert.ErrorIs(t, ranges.ErrZeroStep, err)
+	})
+
+	t.Run("ends at the boundaries of the int type without overflowing", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.IntStep(math.MaxInt-4, math.MaxInt, 2))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{math.MaxInt - 4, math.MaxInt - 2, math.MaxInt}, vs)
+
+		vs, err = iterators.Collect(ranges.IntStep(math.MaxInt-5, math.MaxInt, 2))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{math.MaxInt - 5, math.MaxInt - 3, math.MaxInt - 1}, vs)
+
+		vs, err = iterators.Collect(ranges.IntStep(math.MinInt+4, math.MinInt, -3))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{math.MinInt + 4, math.MinInt + 1}, vs)
+
+		vs, err = iterators.Collect(ranges.IntStep(math.MinInt, math.MaxInt, math.MaxInt))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{math.MinInt, -1, math.MaxInt - 1}, vs)
+	})
+}
+
+func TestIntStep_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[int](func(tb testing.TB) iterators.Iterator[int] {
+		t := testcase.ToT(&tb)
+		return ranges.IntStep(t.Random.IntB(-10, 0), t.Random.IntB(1, 10), t.Random.IntB(1, 3))
+	}).Test(t)
+}
+
+func TestIntFrom(t *testing.T) {
+	vs, err := iterators.Collect(iterators.Limit(ranges.IntFrom(5, -2), 4))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5, 3, 1, -1}, vs)
+
+	t.Run("ends before overflowing the int type", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.IntFrom(math.MaxInt-3, 2))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{math.MaxInt - 3, math.MaxInt - 1}, vs)
+
+		vs, err = iterators.Collect(ranges.IntFrom(math.MinInt+1, -1))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{math.MinInt + 1, math.MinInt}, vs)
+	})
+}
@@ -0,0 +1,59 @@
+package ranges
+
+import (
+	"time"
+
+	"go.llib.dev/frameless/pkg/tasker/schedule"
+	"go.llib.dev/frameless/ports/iterators"
+)
+
+// Time returns an iterator with the points in time from begin to end, with the given step between them.
+// A negative step makes a descending range, where begin is expected to be after end.
+// The end is included when it is reached by the steps.
+func Time(begin, end time.Time, step time.Duration) iterators.Iterator[time.Time] {
+	if step == 0 {
+		return iterators.Error[time.Time](ErrZeroStep)
+	}
+	return &stepRange[time.Time]{
+		At: func(n int) time.Time { return begin.Add(time.Duration(n) * step) },
+		InRange: func(v time.Time) bool {
+			if 0 < step {
+				return !v.After(end)
+			}
+			return !v.Before(end)
+		},
+	}
+}
+
+// TimeFrom returns an infinite iterator with the points in time starting from begin, with the given step between them.
+// Use it together with iterators.Limit or iterators.TakeWhile to bound the iteration.
+func TimeFrom(begin time.Time, step time.Duration) iterators.Iterator[time.Time] {
+	return &stepRange[time.Time]{At: func(n int) time.Time { return begin.Add(time.Duration(n) * step) }}
+}
+
+// Monthly returns an iterator with the monthly occurrences of the schedule between begin and end, both inclusive.
+// The occurrences follow the semantics of schedule.Monthly:
+// they are on the given day of the month at the given hour and minute, in the schedule's location.
+// A day which is out of the month's range is normalised the way time.Date does, e.g. February 30 becomes March 2.
+//
+// A zero end makes the iterator infinite.
+func Monthly(begin, end time.Time, m schedule.Monthly) iterators.Iterator[time.Time] {
+	loc := m.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	begin = begin.In(loc)
+	offset := 0
+	if time.Date(begin.Year(), begin.Month(), m.Day, m.Hour, m.Minute, 0, 0, loc).Before(begin) {
+		offset = 1
+	}
+	r := &stepRange[time.Time]{
+		At: func(n int) time.Time {
+			return time.Date(begin.Year(), begin.Month()+time.Month(offset+n), m.Day, m.Hour, m.Minute, 0, 0, loc)
+		},
+	}
+	if !end.IsZero() {
+		r.InRange = func(v time.Time) bool { return !v.After(end) }
+	}
+	return r
+}
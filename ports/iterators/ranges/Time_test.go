@@ -0,0 +1,93 @@
+package ranges_test
+
+import (
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/pkg/tasker/schedule"
+	"go.llib.dev/frameless/ports/iterators"
+	"go.llib.dev/frameless/ports/iterators/iteratorcontracts"
+	"go.llib.dev/frameless/ports/iterators/ranges"
+	"go.llib.dev/testcase/assert"
+)
+
+func TestTime(t *testing.T) {
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("ascending", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.Time(begin, begin.Add(time.Hour), 30*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{begin, begin.Add(30 * time.Minute), begin.Add(time.Hour)}, vs)
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.Time(begin, begin.Add(-time.Hour), -45*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{begin, begin.Add(-45 * time.Minute)}, vs)
+	})
+
+	t.Run("zero step is an error", func(t *testing.T) {
+		_, err := iterators.Collect(ranges.Time(begin, begin.Add(time.Hour), 0))
+		assert.ErrorIs(t, ranges.ErrZeroStep, err)
+	})
+}
+
+func TestTime_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[time.Time](func(tb testing.TB) iterators.Iterator[time.Time] {
+		begin := time.Now()
+		return ranges.Time(begin, begin.Add(time.Hour), time.Minute)
+	}).Test(t)
+}
+
+func TestTimeFrom(t *testing.T) {
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	vs, err := iterators.Collect(iterators.Limit(ranges.TimeFrom(begin, 24*time.Hour), 2))
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{begin, begin.AddDate(0, 0, 1)}, vs)
+}
+
+func TestMonthly(t *testing.T) {
+	date := func(y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("occurrences between begin and end are yielded", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.Monthly(date(2024, 1, 10, 0), date(2024, 4, 15, 0),
+			schedule.Monthly{Day: 15, Hour: 6, Location: time.UTC}))
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{
+			date(2024, 1, 15, 6),
+			date(2024, 2, 15, 6),
+			date(2024, 3, 15, 6),
+		}, vs)
+	})
+
+	t.Run("when the occurrence of the first month is before begin, it starts with the next month", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.Monthly(date(2024, 11, 20, 0), date(2025, 2, 1, 0),
+			schedule.Monthly{Day: 1, Location: time.UTC}))
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{date(2024, 12, 1, 0), date(2025, 1, 1, 0), date(2025, 2, 1, 0)}, vs)
+	})
+
+	t.Run("days don't drift after a short month", func(t *testing.T) {
+		vs, err := iterators.Collect(ranges.Monthly(date(2023, 1, 1, 0), date(2023, 4, 1, 0),
+			schedule.Monthly{Day: 30, Location: time.UTC}))
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{date(2023, 1, 30, 0), date(2023, 3, 2, 0), date(2023, 3, 30, 0)}, vs)
+	})
+
+	t.Run("zero end makes it infinite", func(t *testing.T) {
+		vs, err := iterators.Collect(iterators.Limit(ranges.Monthly(date(2024, 1, 1, 0), time.Time{},
+			schedule.Monthly{Day: 1, Location: time.UTC}), 13))
+		assert.NoError(t, err)
+		assert.Equal(t, 13, len(vs))
+		assert.Equal(t, date(2025, 1, 1, 0), vs[12])
+	})
+}
+
+func TestMonthly_implementsIterator(t *testing.T) {
+	iteratorcontracts.Iterator[time.Time](func(tb testing.TB) iterators.Iterator[time.Time] {
+		begin := time.Now()
+		return ranges.Monthly(begin, begin.AddDate(1, 0, 0), schedule.Monthly{Day: 1})
+	}).Test(t)
+}
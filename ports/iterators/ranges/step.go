@@ -0,0 +1,54 @@
+package ranges
+
+import "go.llib.dev/frameless/pkg/errorkit"
+
+// ErrZeroStep is returned by the stepping ranges when their step is zero,
+// since such range would never reach its end.
+const ErrZeroStep errorkit.Error = "ranges: step must not be zero"
+
+// stepRange yields the nth element of a sequence,
+// until the element is out of the range.
+// Computing the elements from their index, instead of accumulating the steps,
+// prevents drifting when the steps are not exact, like with floats or calendar months.
+type stepRange[T any] struct {
+	At      func(n int) T
+	InRange func(v T) bool
+	// HasNext is an optional check, which tells if the element after v is still in the range,
+	// before it is computed, thus the iteration can stop without overflowing.
+	HasNext func(v T) bool
+
+	index  int
+	value  T
+	done   bool
+	closed bool
+}
+
+func (r *stepRange[T]) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *stepRange[T]) Err() error {
+	return nil
+}
+
+func (r *stepRange[T]) Next() bool {
+	if r.closed || r.done {
+		return false
+	}
+	v := r.At(r.index)
+	if r.InRange != nil && !r.InRange(v) {
+		r.done = true
+		return false
+	}
+	r.index++
+	r.value = v
+	if r.HasNext != nil && !r.HasNext(v) {
+		r.done = true
+	}
+	return true
+}
+
+func (r *stepRange[T]) Value() T {
+	return r.value
+}
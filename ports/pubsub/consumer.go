@@ -0,0 +1,173 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.llib.dev/frameless/pkg/contextkit"
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/frameless/pkg/retry"
+	"go.llib.dev/frameless/pkg/zerokit"
+	"go.llib.dev/frameless/ports/meta"
+)
+
+// Consumer drives the subscriptions of a Subscriber and handles the received messages with the Handler.
+// A message is ACK-ed when the Handler succeeds, and NACK-ed when the Handler returns with an error.
+// After a failed handling, the Consumer backs off according to the RetryStrategy.
+//
+// The Handler's context carries the Headers of the message,
+// thus messages published with the Handler's context inherit them,
+// and the headers are also added to the logging details of the context.
+//
+// Consumer.Run is a tasker.Task compatible function.
+// On context cancellation, Consumer stops receiving new messages,
+// but lets the in-flight messages finish their handling before Run returns.
+type Consumer[Data any] struct {
+	Subscriber Subscriber[Data]
+	Handler    func(ctx context.Context, data Data) error
+	// Concurrency is the maximum number of messages handled in parallel.
+	// Each concurrent worker uses its own Subscription.
+	//
+	// Default: 1
+	Concurrency int
+	// HandlerTimeout is the time limit for handling a single message.
+	// When the timeout is reached, the context of the Handler is cancelled.
+	//
+	// Default: no timeout
+	HandlerTimeout time.Duration
+	// RetryStrategy decides how long the Consumer backs off after a failed message handling,
+	// and how many consecutive failures it takes to give up on a message.
+	// When the RetryStrategy gives up, the failure is logged, the message stays NACK-ed,
+	// and the Consumer continues with a fresh retry budget,
+	// thus a poison message can't stop the Consumer.
+	//
+	// Default: retry.ExponentialBackoff{}
+	RetryStrategy retry.Strategy[retry.FailureCount]
+	// StopOnFailure makes Run return with the last handling error when the RetryStrategy gives up.
+	StopOnFailure bool
+	// MetaAccessor is an optional meta.MetaAccessor.
+	// When supplied, the Headers of the messages are set as meta values in the Handler's context.
+	MetaAccessor meta.MetaAccessor
+}
+
+func (c Consumer[Data]) Run(ctx context.Context) error {
+	if c.Subscriber == nil {
+		return fmt.Errorf("missing pubsub.Consumer.Subscriber")
+	}
+	if c.Handler == nil {
+		return fmt.Errorf("missing pubsub.Consumer.Handler")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		m    sync.Mutex
+		errs []error
+	)
+	for i, n := 0, c.getConcurrency(); i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.work(ctx); err != nil {
+				m.Lock()
+				errs = append(errs, err)
+				m.Unlock()
+				cancel() // if one worker fails, all will shut down
+			}
+		}()
+	}
+	wg.Wait()
+	return errorkit.Merge(errs...)
+}
+
+func (c Consumer[Data]) work(ctx context.Context) (rErr error) {
+	sub := c.Subscriber.Subscribe(ctx)
+	defer errorkit.Finish(&rErr, sub.Close)
+
+	var failureCount retry.FailureCount
+	for sub.Next() {
+		err := c.handle(ctx, sub.Value())
+		if err == nil {
+			failureCount = 0
+			continue
+		}
+		logger.Warn(ctx, "pubsub.Consumer failed to handle a message", logger.ErrField(err))
+		failureCount++
+		if c.getRetryStrategy().ShouldTry(ctx, failureCount) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if c.StopOnFailure {
+			return err
+		}
+		logger.Error(ctx, "pubsub.Consumer gave up retrying after consecutive failures",
+			logger.ErrField(err), logger.Field("failure_count", failureCount))
+		failureCount = 0
+	}
+	if err := sub.Err(); err != nil && !errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return nil
+}
+
+func (c Consumer[Data]) handle(ctx context.Context, msg Message[Data]) error {
+	// in-flight messages are drained during shutdown,
+	// thus their handling is detached from the cancellation of the Consumer.
+	ctx = contextkit.Detach(ctx)
+	if 0 < c.HandlerTimeout {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, c.HandlerTimeout)
+		defer cancel()
+	}
+	ctx, err := c.contextWithHeaders(ctx, msg.Headers())
+	if err != nil {
+		return errorkit.Merge(err, msg.NACK())
+	}
+	if err := c.Handler(ctx, msg.Data()); err != nil {
+		return errorkit.Merge(err, msg.NACK())
+	}
+	return msg.ACK()
+}
+
+func (c Consumer[Data]) contextWithHeaders(ctx context.Context, hs Headers) (context.Context, error) {
+	if len(hs) == 0 {
+		return ctx, nil
+	}
+	ctx = ContextWithHeaders(ctx, hs)
+	fields := make(logger.Fields, len(hs))
+	for k, v := range hs {
+		fields[k] = v
+	}
+	ctx = logger.ContextWith(ctx, fields)
+	if c.MetaAccessor == nil {
+		return ctx, nil
+	}
+	for k, v := range hs {
+		var err error
+		ctx, err = c.MetaAccessor.SetMeta(ctx, k, v)
+		if err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+func (c Consumer[Data]) getConcurrency() int {
+	const defaultConcurrency = 1
+	return zerokit.Coalesce(c.Concurrency, defaultConcurrency)
+}
+
+func (c Consumer[Data]) getRetryStrategy() retry.Strategy[retry.FailureCount] {
+	if c.RetryStrategy == nil {
+		return retry.ExponentialBackoff{}
+	}
+	return c.RetryStrategy
+}
@@ -0,0 +1,381 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/pkg/logger"
+	"go.llib.dev/frameless/pkg/tasker"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/random"
+)
+
+var _ tasker.Runnable = pubsub.Consumer[string]{}
+
+type retryStrategyFunc func(ctx context.Context, failureCount int) bool
+
+func (fn retryStrategyFunc) ShouldTry(ctx context.Context, failureCount int) bool {
+	return fn(ctx, failureCount)
+}
+
+func runConsumer[Data any](tb testing.TB, c pubsub.Consumer[Data]) (cancel func(), done <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.Run(ctx) }()
+	tb.Cleanup(cancel)
+	return cancel, errCh
+}
+
+func TestConsumer_Run(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		queue = testcase.Let(s, func(t *testcase.T) *memory.Queue[string] {
+			return &memory.Queue[string]{Memory: memory.NewMemory(), Namespace: t.Random.UUID()}
+		})
+		handler  = testcase.Var[func(ctx context.Context, data string) error]{ID: "Consumer.Handler"}
+		consumer = testcase.Let(s, func(t *testcase.T) pubsub.Consumer[string] {
+			return pubsub.Consumer[string]{
+				Subscriber: queue.Get(t),
+				Handler:    handler.Get(t),
+			}
+		})
+	)
+	act := func(t *testcase.T) (cancel func(), done <-chan error) {
+		return runConsumer[string](t, consumer.Get(t))
+	}
+	publish := func(t *testcase.T, ctx context.Context, data ...string) {
+		t.Must.NoError(queue.Get(t).Publish(ctx, data...))
+	}
+
+	s.Context("when the handler succeeds", func(s *testcase.Spec) {
+		type handled struct {
+			sync.Mutex
+			Data []string
+		}
+		got := testcase.Let(s, func(t *testcase.T) *handled {
+			return &handled{}
+		})
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, data string) error {
+			got := got.Get(t)
+			return func(ctx context.Context, data string) error {
+				got.Lock()
+				defer got.Unlock()
+				got.Data = append(got.Data, data)
+				return nil
+			}
+		})
+		handledData := func(t *testcase.T) []string {
+			got := got.Get(t)
+			got.Lock()
+			defer got.Unlock()
+			return append([]string{}, got.Data...)
+		}
+
+		s.Test("messages are handled and ACK-ed", func(t *testcase.T) {
+			cancel, done := act(t)
+
+			exp := random.Slice(3, t.Random.UUID)
+			publish(t, context.Background(), exp...)
+
+			t.Eventually(func(it assert.It) {
+				it.Must.ContainExactly(exp, handledData(t))
+			})
+
+			cancel()
+			t.Must.NoError(<-done)
+			t.Must.Equal(len(exp), len(handledData(t)), "ACK-ed messages should not be redelivered")
+		})
+	})
+
+	s.Context("when the handler fails once", func(s *testcase.Spec) {
+		var (
+			attempts = testcase.Let(s, func(t *testcase.T) *int32 {
+				return new(int32)
+			})
+			failureCounts = testcase.Let(s, func(t *testcase.T) chan int {
+				return make(chan int, 1)
+			})
+		)
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, data string) error {
+			attempts := attempts.Get(t)
+			return func(ctx context.Context, data string) error {
+				if atomic.AddInt32(attempts, 1) == 1 {
+					return errors.New("boom")
+				}
+				return nil
+			}
+		})
+		consumer.Let(s, func(t *testcase.T) pubsub.Consumer[string] {
+			c := consumer.Super(t)
+			failureCounts := failureCounts.Get(t)
+			c.RetryStrategy = retryStrategyFunc(func(ctx context.Context, failureCount int) bool {
+				failureCounts <- failureCount
+				return true
+			})
+			return c
+		})
+
+		s.Test("the message is NACK-ed, and it is retried after a back off", func(t *testcase.T) {
+			cancel, done := act(t)
+			publish(t, context.Background(), t.Random.UUID())
+
+			t.Eventually(func(it assert.It) {
+				it.Must.Equal(int32(2), atomic.LoadInt32(attempts.Get(t)))
+			})
+			t.Must.Equal(1, <-failureCounts.Get(t))
+
+			cancel()
+			t.Must.NoError(<-done)
+		})
+	})
+
+	s.Context("when the handler keeps failing beyond the retry budget", func(s *testcase.Spec) {
+		const budget = 2
+		var (
+			expErr   = errors.New("boom")
+			attempts = testcase.Let(s, func(t *testcase.T) *int32 {
+				return new(int32)
+			})
+		)
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, data string) error {
+			attempts := attempts.Get(t)
+			return func(ctx context.Context, data string) error {
+				if atomic.AddInt32(attempts, 1) <= budget*3 {
+					return expErr
+				}
+				return nil
+			}
+		})
+		consumer.Let(s, func(t *testcase.T) pubsub.Consumer[string] {
+			c := consumer.Super(t)
+			c.RetryStrategy = retryStrategyFunc(func(ctx context.Context, failureCount int) bool {
+				return failureCount < budget
+			})
+			return c
+		})
+
+		s.Test("the failure is logged, and the consumer keeps consuming with a fresh retry budget", func(t *testcase.T) {
+			out := logger.Stub(t)
+			cancel, done := act(t)
+			publish(t, context.Background(), t.Random.UUID())
+
+			t.Eventually(func(it assert.It) {
+				it.Must.Equal(int32(budget*3+1), atomic.LoadInt32(attempts.Get(t)))
+			})
+			t.Must.Contain(out.String(), "gave up retrying")
+
+			cancel()
+			t.Must.NoError(<-done)
+
+			ctx, cancelSub := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancelSub()
+			sub := queue.Get(t).Subscribe(ctx)
+			defer sub.Close()
+			t.Must.False(sub.Next(), "the eventually handled message should have been ACK-ed")
+		})
+
+		s.Context("and StopOnFailure is set", func(s *testcase.Spec) {
+			consumer.Let(s, func(t *testcase.T) pubsub.Consumer[string] {
+				c := consumer.Super(t)
+				c.StopOnFailure = true
+				return c
+			})
+
+			s.Test("the handling error is returned", func(t *testcase.T) {
+				_, done := act(t)
+				publish(t, context.Background(), t.Random.UUID())
+
+				t.Must.Within(5*time.Second, func(ctx context.Context) {
+					t.Must.ErrorIs(expErr, <-done)
+				})
+				t.Must.Equal(int32(budget), atomic.LoadInt32(attempts.Get(t)))
+			})
+		})
+	})
+
+	s.Context("when Concurrency is set", func(s *testcase.Spec) {
+		const concurrency = 3
+		var (
+			inFlight    = testcase.Let(s, func(t *testcase.T) *int32 { return new(int32) })
+			maxInFlight = testcase.Let(s, func(t *testcase.T) *int32 { return new(int32) })
+			release     = testcase.Let(s, func(t *testcase.T) chan struct{} {
+				return make(chan struct{})
+			})
+		)
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, data string) error {
+			inFlight, maxInFlight, release := inFlight.Get(t), maxInFlight.Get(t), release.Get(t)
+			return func(ctx context.Context, data string) error {
+				n := atomic.AddInt32(inFlight, 1)
+				defer atomic.AddInt32(inFlight, -1)
+				for {
+					peak := atomic.LoadInt32(maxInFlight)
+					if n <= peak || atomic.CompareAndSwapInt32(maxInFlight, peak, n) {
+						break
+					}
+				}
+				<-release
+				return nil
+			}
+		})
+		consumer.Let(s, func(t *testcase.T) pubsub.Consumer[string] {
+			c := consumer.Super(t)
+			c.Concurrency = concurrency
+			return c
+		})
+
+		s.Test("messages are handled concurrently up to the configured Concurrency", func(t *testcase.T) {
+			cancel, done := act(t)
+			publish(t, context.Background(), random.Slice(concurrency*2, t.Random.UUID)...)
+
+			t.Eventually(func(it assert.It) {
+				it.Must.Equal(int32(concurrency), atomic.LoadInt32(inFlight.Get(t)))
+			})
+			close(release.Get(t))
+			cancel()
+			t.Must.NoError(<-done)
+			t.Must.Equal(int32(concurrency), atomic.LoadInt32(maxInFlight.Get(t)))
+		})
+	})
+
+	s.Context("when HandlerTimeout is set", func(s *testcase.Spec) {
+		deadlines := testcase.Let(s, func(t *testcase.T) chan time.Time {
+			return make(chan time.Time, 1)
+		})
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, data string) error {
+			deadlines := deadlines.Get(t)
+			return func(ctx context.Context, data string) error {
+				deadline, ok := ctx.Deadline()
+				t.Should.True(ok)
+				deadlines <- deadline
+				return nil
+			}
+		})
+		consumer.Let(s, func(t *testcase.T) pubsub.Consumer[string] {
+			c := consumer.Super(t)
+			c.HandlerTimeout = time.Minute
+			return c
+		})
+
+		s.Test("handler context has the HandlerTimeout as deadline", func(t *testcase.T) {
+			cancel, done := act(t)
+			publish(t, context.Background(), t.Random.UUID())
+
+			deadline := <-deadlines.Get(t)
+			t.Must.True(time.Now().Add(time.Minute).Add(time.Second).After(deadline))
+			t.Must.True(time.Now().Add(time.Minute).Add(-1 * time.Second).Before(deadline))
+
+			cancel()
+			t.Must.NoError(<-done)
+		})
+	})
+
+	s.Context("when a message is in-flight", func(s *testcase.Spec) {
+		var (
+			started = testcase.Let(s, func(t *testcase.T) chan struct{} {
+				return make(chan struct{})
+			})
+			release = testcase.Let(s, func(t *testcase.T) chan struct{} {
+				return make(chan struct{})
+			})
+			finished = testcase.Let(s, func(t *testcase.T) *int32 {
+				return new(int32)
+			})
+		)
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, data string) error {
+			started, release, finished := started.Get(t), release.Get(t), finished.Get(t)
+			return func(ctx context.Context, data string) error {
+				close(started)
+				<-release
+				t.Should.NoError(ctx.Err())
+				atomic.AddInt32(finished, 1)
+				return nil
+			}
+		})
+
+		s.Test("on cancellation, in-flight messages are drained before Run returns", func(t *testcase.T) {
+			cancel, done := act(t)
+			publish(t, context.Background(), t.Random.UUID())
+			<-started.Get(t)
+			cancel()
+
+			t.Must.NotWithin(100*time.Millisecond, func(ctx context.Context) {
+				select {
+				case <-done:
+				case <-ctx.Done():
+				}
+			})
+
+			close(release.Get(t))
+			t.Must.NoError(<-done)
+			t.Must.Equal(int32(1), atomic.LoadInt32(finished.Get(t)))
+
+			ctx, cancelSub := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancelSub()
+			sub := queue.Get(t).Subscribe(ctx)
+			defer sub.Close()
+			t.Must.False(sub.Next(), "drained message should have been ACK-ed")
+		})
+	})
+
+	s.Context("when the message has headers", func(s *testcase.Spec) {
+		type got struct {
+			Headers   pubsub.Headers
+			Meta      string
+			MetaFound bool
+			MetaErr   error
+		}
+		var (
+			eventLog = testcase.Let(s, func(t *testcase.T) *memory.EventLog {
+				return memory.NewEventLog()
+			})
+			gots = testcase.Let(s, func(t *testcase.T) chan got {
+				return make(chan got, 1)
+			})
+			headers = testcase.Let(s, func(t *testcase.T) pubsub.Headers {
+				return pubsub.Headers{"tenant": t.Random.UUID()}
+			})
+		)
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, data string) error {
+			eventLog, gots := eventLog.Get(t), gots.Get(t)
+			return func(ctx context.Context, data string) error {
+				var g got
+				g.Headers, _ = pubsub.LookupHeaders(ctx)
+				g.MetaFound, g.MetaErr = eventLog.LookupMeta(ctx, "tenant", &g.Meta)
+				logger.Info(ctx, "handling")
+				gots <- g
+				return nil
+			}
+		})
+		consumer.Let(s, func(t *testcase.T) pubsub.Consumer[string] {
+			c := consumer.Super(t)
+			c.MetaAccessor = eventLog.Get(t)
+			return c
+		})
+
+		s.Test("handler context carries the message headers", func(t *testcase.T) {
+			buf := logger.Stub(t)
+			cancel, done := act(t)
+			expected := headers.Get(t)
+			publish(t, pubsub.ContextWithHeaders(context.Background(), expected), t.Random.UUID())
+
+			g := <-gots.Get(t)
+			t.Must.Equal(expected["tenant"], g.Headers["tenant"])
+			t.Must.NotEmpty(g.Headers[pubsub.HeaderMessageID])
+			t.Must.NoError(g.MetaErr)
+			t.Must.True(g.MetaFound)
+			t.Must.Equal(expected["tenant"], g.Meta)
+			t.Must.Contain(buf.String(), expected["tenant"])
+
+			cancel()
+			t.Must.NoError(<-done)
+		})
+	})
+}
@@ -0,0 +1,95 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.llib.dev/frameless/pkg/errorkit"
+	"go.llib.dev/frameless/pkg/zerokit"
+	"go.llib.dev/frameless/ports/comproto"
+)
+
+// HeaderMessageID is the header that holds the unique identifier of a message.
+// The queues set it on every published message, even when a single Publish call carries multiple values,
+// and the redeliveries of a message keep the same ID.
+const HeaderMessageID = "message-id"
+
+// DeduplicationRepository records the IDs of the already processed messages for a limited time.
+type DeduplicationRepository interface {
+	// MarkProcessed marks the message ID as processed for the duration of the TTL.
+	// It reports false, when the message ID is already marked as processed, and the mark is not yet expired.
+	MarkProcessed(ctx context.Context, messageID string, ttl time.Duration) (bool, error)
+	// UnmarkProcessed removes the processed mark of the message ID.
+	UnmarkProcessed(ctx context.Context, messageID string) error
+}
+
+// Deduplicator makes message handlers idempotent when the messages are delivered at least once.
+// Duplicate deliveries of an already processed message are acknowledged without calling the handler again.
+type Deduplicator[Data any] struct {
+	Repository DeduplicationRepository
+	// MessageID returns the unique identifier of a message.
+	//
+	// Default: the HeaderMessageID header from the handler's context.
+	MessageID func(ctx context.Context, data Data) (string, error)
+	// TTL is how long a processed message ID is remembered.
+	//
+	// Default: 24 hours
+	TTL time.Duration
+	// OnePhaseCommitProtocol is an optional transaction manager.
+	// When supplied, the processed mark is recorded in the same transaction as the handler's own writes,
+	// so they are committed or rolled back together.
+	OnePhaseCommitProtocol comproto.OnePhaseCommitProtocol
+}
+
+// Handler wraps the next handler with deduplication.
+// The returned handler is compatible with Consumer.Handler.
+func (d Deduplicator[Data]) Handler(next func(ctx context.Context, data Data) error) func(ctx context.Context, data Data) error {
+	return func(ctx context.Context, data Data) (rErr error) {
+		if d.Repository == nil {
+			return fmt.Errorf("missing pubsub.Deduplicator.Repository")
+		}
+		messageID, err := d.messageID(ctx, data)
+		if err != nil {
+			return err
+		}
+		if d.OnePhaseCommitProtocol != nil {
+			ctx, err = d.OnePhaseCommitProtocol.BeginTx(ctx)
+			if err != nil {
+				return err
+			}
+			defer comproto.FinishOnePhaseCommit(&rErr, d.OnePhaseCommitProtocol, ctx)
+		}
+		ok, err := d.Repository.MarkProcessed(ctx, messageID, d.getTTL())
+		if err != nil {
+			return err
+		}
+		if !ok { // duplicate delivery
+			return nil
+		}
+		if err := next(ctx, data); err != nil {
+			if d.OnePhaseCommitProtocol == nil {
+				err = errorkit.Merge(err, d.Repository.UnmarkProcessed(ctx, messageID))
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+func (d Deduplicator[Data]) messageID(ctx context.Context, data Data) (string, error) {
+	if d.MessageID != nil {
+		return d.MessageID(ctx, data)
+	}
+	hs, _ := LookupHeaders(ctx)
+	messageID, ok := hs[HeaderMessageID]
+	if !ok || messageID == "" {
+		return "", fmt.Errorf("missing %s header", HeaderMessageID)
+	}
+	return messageID, nil
+}
+
+func (d Deduplicator[Data]) getTTL() time.Duration {
+	const defaultTTL = 24 * time.Hour
+	return zerokit.Coalesce(d.TTL, defaultTTL)
+}
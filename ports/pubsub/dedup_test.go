@@ -0,0 +1,164 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/assert"
+	"go.llib.dev/testcase/random"
+)
+
+func TestDeduplicator_Handler(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	type Msg struct {
+		ID   string
+		Data string
+	}
+	type spyHandler struct {
+		sync.Mutex
+		Handled []Msg
+		// Err is returned by the handler until it is cleared.
+		Err error
+	}
+
+	var (
+		m = testcase.Let(s, func(t *testcase.T) *memory.Memory {
+			return memory.NewMemory()
+		})
+		spy = testcase.Let(s, func(t *testcase.T) *spyHandler {
+			return &spyHandler{}
+		})
+		next = testcase.Let(s, func(t *testcase.T) func(ctx context.Context, msg Msg) error {
+			spy := spy.Get(t)
+			return func(ctx context.Context, msg Msg) error {
+				spy.Lock()
+				defer spy.Unlock()
+				spy.Handled = append(spy.Handled, msg)
+				return spy.Err
+			}
+		})
+		deduplicator = testcase.Let(s, func(t *testcase.T) pubsub.Deduplicator[Msg] {
+			return pubsub.Deduplicator[Msg]{
+				Repository: &memory.DeduplicationRepository{Memory: m.Get(t)},
+				MessageID:  func(ctx context.Context, msg Msg) (string, error) { return msg.ID, nil },
+			}
+		})
+		subject = testcase.Let(s, func(t *testcase.T) func(ctx context.Context, msg Msg) error {
+			return deduplicator.Get(t).Handler(next.Get(t))
+		})
+		ctx = testcase.LetValue(s, context.Background())
+	)
+	makeMsg := func(t *testcase.T) Msg {
+		return Msg{ID: t.Random.UUID(), Data: t.Random.String()}
+	}
+	handled := func(t *testcase.T) []Msg {
+		spy := spy.Get(t)
+		spy.Lock()
+		defer spy.Unlock()
+		return append([]Msg{}, spy.Handled...)
+	}
+
+	s.Test("duplicate deliveries are not handled again", func(t *testcase.T) {
+		h := subject.Get(t)
+		msg1, msg2 := makeMsg(t), makeMsg(t)
+		t.Must.NoError(h(ctx.Get(t), msg1))
+		t.Must.NoError(h(ctx.Get(t), msg1))
+		t.Must.NoError(h(ctx.Get(t), msg2))
+		t.Must.NoError(h(ctx.Get(t), msg1))
+		t.Must.Equal([]Msg{msg1, msg2}, handled(t))
+	})
+
+	s.When("the handling fails", func(s *testcase.Spec) {
+		expErr := testcase.Let(s, func(t *testcase.T) error {
+			return errors.New(t.Random.String())
+		})
+		s.Before(func(t *testcase.T) {
+			spy.Get(t).Err = expErr.Get(t)
+		})
+
+		s.Then("the message can be retried", func(t *testcase.T) {
+			h, msg := subject.Get(t), makeMsg(t)
+			t.Must.ErrorIs(expErr.Get(t), h(ctx.Get(t), msg))
+
+			spy.Get(t).Err = nil
+			t.Must.NoError(h(ctx.Get(t), msg))
+			t.Must.NoError(h(ctx.Get(t), msg))
+			t.Must.Equal([]Msg{msg, msg}, handled(t))
+		})
+
+		s.And("the OnePhaseCommitProtocol is supplied", func(s *testcase.Spec) {
+			repository := testcase.Let(s, func(t *testcase.T) *memory.Repository[Msg, string] {
+				return memory.NewRepository[Msg, string](m.Get(t))
+			})
+			deduplicator.Let(s, func(t *testcase.T) pubsub.Deduplicator[Msg] {
+				d := deduplicator.Super(t)
+				d.OnePhaseCommitProtocol = m.Get(t)
+				return d
+			})
+			next.Let(s, func(t *testcase.T) func(ctx context.Context, msg Msg) error {
+				next, repository := next.Super(t), repository.Get(t)
+				return func(ctx context.Context, msg Msg) error {
+					if err := repository.Create(ctx, &msg); err != nil {
+						return err
+					}
+					return next(ctx, msg)
+				}
+			})
+
+			s.Then("the handler's writes and the processed mark share the transaction", func(t *testcase.T) {
+				h, msg := subject.Get(t), makeMsg(t)
+				t.Must.ErrorIs(expErr.Get(t), h(ctx.Get(t), msg))
+				_, found, err := repository.Get(t).FindByID(ctx.Get(t), msg.ID)
+				t.Must.NoError(err)
+				t.Must.False(found, "handler writes should be rolled back")
+
+				spy.Get(t).Err = nil
+				t.Must.NoError(h(ctx.Get(t), msg))
+				t.Must.NoError(h(ctx.Get(t), msg))
+				_, found, err = repository.Get(t).FindByID(ctx.Get(t), msg.ID)
+				t.Must.NoError(err)
+				t.Must.True(found)
+				t.Must.Equal(2, len(handled(t)))
+			})
+		})
+	})
+
+	s.When("MessageID is not supplied", func(s *testcase.Spec) {
+		deduplicator.Let(s, func(t *testcase.T) pubsub.Deduplicator[Msg] {
+			d := deduplicator.Super(t)
+			d.MessageID = nil
+			return d
+		})
+
+		s.Then("the message ID is taken from the message-id header", func(t *testcase.T) {
+			h, msg := subject.Get(t), makeMsg(t)
+			t.Must.Error(h(ctx.Get(t), msg), "message without the header should yield an error")
+
+			msgCTX := pubsub.ContextWithHeaders(ctx.Get(t), pubsub.Headers{pubsub.HeaderMessageID: t.Random.UUID()})
+			t.Must.NoError(h(msgCTX, msg))
+			t.Must.NoError(h(msgCTX, msg))
+			t.Must.Equal([]Msg{msg}, handled(t))
+		})
+
+		s.Then("every message of a multi-value publish is handled exactly once by a Consumer", func(t *testcase.T) {
+			q := &memory.Queue[Msg]{Memory: m.Get(t), Namespace: t.Random.UUID()}
+			runConsumer(t, pubsub.Consumer[Msg]{
+				Subscriber: q,
+				Handler:    subject.Get(t),
+			})
+
+			exp := random.Slice(3, func() Msg { return makeMsg(t) })
+			t.Must.NoError(q.Publish(ctx.Get(t), exp...))
+
+			t.Eventually(func(it assert.It) {
+				it.Must.ContainExactly(exp, handled(t))
+			})
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"context"
+)
+
+// Headers are optional metadata that travel along with the published messages,
+// such as correlation IDs, causation IDs or tenant information.
+type Headers map[string]string
+
+// Clone returns an independent copy of the Headers.
+func (hs Headers) Clone() Headers {
+	if hs == nil {
+		return nil
+	}
+	out := make(Headers, len(hs))
+	for k, v := range hs {
+		out[k] = v
+	}
+	return out
+}
+
+type ctxKeyHeaders struct{}
+
+// ContextWithHeaders returns a context that carries the Headers.
+// Publishers attach the headers found in the context to the messages they publish.
+// When the context already has Headers, the new Headers are merged into them, and the new values take precedence.
+func ContextWithHeaders(ctx context.Context, hs Headers) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(hs) == 0 {
+		return ctx
+	}
+	merged, _ := LookupHeaders(ctx)
+	merged = merged.Clone()
+	if merged == nil {
+		merged = make(Headers, len(hs))
+	}
+	for k, v := range hs {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, ctxKeyHeaders{}, merged)
+}
+
+// LookupHeaders returns the Headers carried by the context.
+func LookupHeaders(ctx context.Context) (Headers, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	hs, ok := ctx.Value(ctxKeyHeaders{}).(Headers)
+	return hs.Clone(), ok
+}
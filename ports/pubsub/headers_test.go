@@ -0,0 +1,35 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase/assert"
+)
+
+func TestContextWithHeaders(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := pubsub.LookupHeaders(ctx)
+	assert.False(t, ok)
+
+	ctx = pubsub.ContextWithHeaders(ctx, pubsub.Headers{"foo": "1", "bar": "2"})
+	hs, ok := pubsub.LookupHeaders(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, pubsub.Headers{"foo": "1", "bar": "2"}, hs)
+
+	t.Log("the returned headers are independent copies")
+	hs["foo"] = "42"
+	hs, _ = pubsub.LookupHeaders(ctx)
+	assert.Equal(t, pubsub.Headers{"foo": "1", "bar": "2"}, hs)
+
+	t.Log("new headers are merged into the existing ones")
+	sub := pubsub.ContextWithHeaders(ctx, pubsub.Headers{"bar": "3", "baz": "4"})
+	hs, _ = pubsub.LookupHeaders(sub)
+	assert.Equal(t, pubsub.Headers{"foo": "1", "bar": "3", "baz": "4"}, hs)
+
+	t.Log("the parent context is not affected")
+	hs, _ = pubsub.LookupHeaders(ctx)
+	assert.Equal(t, pubsub.Headers{"foo": "1", "bar": "2"}, hs)
+}
@@ -0,0 +1,25 @@
+package pubsub
+
+import "context"
+
+type ctxKeyPriority struct{}
+
+// ContextWithPriority returns a context that carries the priority for the messages published with it.
+// Messages with a higher priority are delivered before the ones with a lower priority,
+// and messages with the same priority keep the ordering of the queue.
+// Messages published without a priority have the priority of zero.
+func ContextWithPriority(ctx context.Context, priority int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, ctxKeyPriority{}, priority)
+}
+
+// LookupPriority returns the message priority carried by the context.
+func LookupPriority(ctx context.Context) (int, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	priority, ok := ctx.Value(ctxKeyPriority{}).(int)
+	return priority, ok
+}
@@ -0,0 +1,26 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase/assert"
+)
+
+func TestContextWithPriority(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := pubsub.LookupPriority(ctx)
+	assert.False(t, ok)
+
+	ctx = pubsub.ContextWithPriority(ctx, 42)
+	priority, ok := pubsub.LookupPriority(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 42, priority)
+
+	t.Log("the latest priority overrides the previous one")
+	sub := pubsub.ContextWithPriority(ctx, -1)
+	priority, _ = pubsub.LookupPriority(sub)
+	assert.Equal(t, -1, priority)
+}
@@ -21,4 +21,12 @@ type Message[Data any] interface {
 	ACK() error
 	NACK() error
 	Data() Data
+	// Headers returns the Headers attached to the message during publishing.
+	Headers() Headers
+}
+
+// TopicPublisher publishes messages along with a routing key.
+// The messages are delivered to every subscriber whose binding pattern matches the routing key.
+type TopicPublisher[Data any] interface {
+	Publish(ctx context.Context, routingKey string, vs ...Data) error
 }
@@ -0,0 +1,176 @@
+package pubsubcontracts
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/ports/comproto"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase"
+	"go.llib.dev/testcase/clock/timecop"
+)
+
+// Deduplication defines the behaviour of a pubsub.DeduplicationRepository,
+// which remembers the processed message IDs until their TTL expires.
+type Deduplication func(testing.TB) DeduplicationSubject
+
+type DeduplicationSubject struct {
+	Repository pubsub.DeduplicationRepository
+	// CommitManager is an optional transaction manager for the Repository.
+	// When supplied, the contract verifies that the processed marks respect the transactions.
+	CommitManager comproto.OnePhaseCommitProtocol
+	MakeContext   func() context.Context
+}
+
+func (c Deduplication) Spec(s *testcase.Spec) {
+	subject := testcase.Let(s, func(t *testcase.T) DeduplicationSubject { return c(t) })
+
+	var (
+		ctx = testcase.Let(s, func(t *testcase.T) context.Context {
+			return subject.Get(t).MakeContext()
+		})
+		messageID = testcase.Let(s, func(t *testcase.T) string {
+			return t.Random.UUID()
+		})
+		ttl = testcase.LetValue(s, time.Hour)
+	)
+	markProcessed := func(t *testcase.T) bool {
+		ok, err := subject.Get(t).Repository.MarkProcessed(ctx.Get(t), messageID.Get(t), ttl.Get(t))
+		t.Must.NoError(err)
+		return ok
+	}
+
+	s.Describe(".MarkProcessed", func(s *testcase.Spec) {
+		s.Then("the first mark of a message ID is accepted", func(t *testcase.T) {
+			t.Must.True(markProcessed(t))
+		})
+
+		s.When("the message ID is already marked as processed", func(s *testcase.Spec) {
+			s.Before(func(t *testcase.T) {
+				t.Must.True(markProcessed(t))
+			})
+
+			s.Then("the message is reported as a duplicate", func(t *testcase.T) {
+				t.Must.False(markProcessed(t))
+			})
+
+			s.Then("other message IDs are not affected", func(t *testcase.T) {
+				ok, err := subject.Get(t).Repository.MarkProcessed(ctx.Get(t), t.Random.UUID(), ttl.Get(t))
+				t.Must.NoError(err)
+				t.Must.True(ok)
+			})
+
+			s.And("the TTL of the mark is expired", func(s *testcase.Spec) {
+				s.Before(func(t *testcase.T) {
+					timecop.Travel(t, ttl.Get(t)+time.Second)
+				})
+
+				s.Then("the message ID can be marked again", func(t *testcase.T) {
+					t.Must.True(markProcessed(t))
+				})
+			})
+
+			s.And("the mark is removed", func(s *testcase.Spec) {
+				s.Before(func(t *testcase.T) {
+					t.Must.NoError(subject.Get(t).Repository.UnmarkProcessed(ctx.Get(t), messageID.Get(t)))
+				})
+
+				s.Then("the message ID can be marked again", func(t *testcase.T) {
+					t.Must.True(markProcessed(t))
+				})
+			})
+		})
+
+		s.When("context has an error", func(s *testcase.Spec) {
+			ctx.Let(s, func(t *testcase.T) context.Context {
+				ctx, cancel := context.WithCancel(subject.Get(t).MakeContext())
+				cancel()
+				return ctx
+			})
+
+			s.Then("it returns the error of the context", func(t *testcase.T) {
+				_, err := subject.Get(t).Repository.MarkProcessed(ctx.Get(t), messageID.Get(t), ttl.Get(t))
+				t.Must.ErrorIs(ctx.Get(t).Err(), err)
+			})
+		})
+
+		s.When("the mark is made within a transaction", func(s *testcase.Spec) {
+			s.Before(func(t *testcase.T) {
+				if subject.Get(t).CommitManager == nil {
+					t.Skip("CommitManager is not supplied")
+				}
+			})
+
+			tx := testcase.Let(s, func(t *testcase.T) context.Context {
+				tx, err := subject.Get(t).CommitManager.BeginTx(subject.Get(t).MakeContext())
+				t.Must.NoError(err)
+				return tx
+			})
+
+			s.Before(func(t *testcase.T) {
+				ok, err := subject.Get(t).Repository.MarkProcessed(tx.Get(t), messageID.Get(t), ttl.Get(t))
+				t.Must.NoError(err)
+				t.Must.True(ok)
+			})
+
+			s.Then("rolling back the transaction removes the mark", func(t *testcase.T) {
+				t.Must.NoError(subject.Get(t).CommitManager.RollbackTx(tx.Get(t)))
+				t.Must.True(markProcessed(t))
+			})
+
+			s.Then("committing the transaction persists the mark", func(t *testcase.T) {
+				t.Must.NoError(subject.Get(t).CommitManager.CommitTx(tx.Get(t)))
+				t.Must.False(markProcessed(t))
+			})
+		})
+
+		s.When("concurrent transactions mark the same message ID", func(s *testcase.Spec) {
+			s.Before(func(t *testcase.T) {
+				if subject.Get(t).CommitManager == nil {
+					t.Skip("CommitManager is not supplied")
+				}
+			})
+
+			s.Then("only one of them is accepted", func(t *testcase.T) {
+				var (
+					subject   = subject.Get(t)
+					messageID = messageID.Get(t)
+					ttl       = ttl.Get(t)
+					n         = t.Random.IntBetween(2, 8)
+					accepted  int32
+					entered   sync.WaitGroup
+					wg        sync.WaitGroup
+				)
+				entered.Add(n)
+				for i := 0; i < n; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						tx, err := subject.CommitManager.BeginTx(subject.MakeContext())
+						t.Should.NoError(err)
+						entered.Done()
+						ok, err := subject.Repository.MarkProcessed(tx, messageID, ttl)
+						t.Should.NoError(err)
+						if ok {
+							atomic.AddInt32(&accepted, 1)
+							// the mark is kept uncommitted until every transaction is about to mark,
+							// an implementation might block them until the commit.
+							entered.Wait()
+						}
+						t.Should.NoError(subject.CommitManager.CommitTx(tx))
+					}()
+				}
+				wg.Wait()
+				t.Must.Equal(int32(1), atomic.LoadInt32(&accepted))
+				t.Must.False(markProcessed(t))
+			})
+		})
+	})
+}
+
+func (c Deduplication) Test(t *testing.T) { c.Spec(testcase.NewSpec(t)) }
+
+func (c Deduplication) Benchmark(b *testing.B) { c.Spec(testcase.NewSpec(b)) }
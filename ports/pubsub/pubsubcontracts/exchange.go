@@ -96,3 +96,201 @@ func (c FanOut[Data]) Spec(s *testcase.Spec) {
 		})
 	})
 }
+
+// Topic defines an exchange behaviour where messages are published with a routing key,
+// and they are delivered to the queues which binding pattern matches the routing key.
+//
+// A binding pattern is made of dot separated words, where "*" substitutes exactly one word,
+// and "#" substitutes zero or more words.
+type Topic[Data any] func(testing.TB) TopicSubject[Data]
+
+type TopicSubject[Data any] struct {
+	// Exchange is the publisher that suppose to publish to the queues made with MakeQueue,
+	// when the routing key of the published messages matches the queue's binding pattern.
+	Exchange pubsub.TopicPublisher[Data]
+	// MakeQueue creates a queue and binds it to the Exchange with the given binding pattern.
+	// Queues made with MakeQueue suppose to be cleaned up after the test.
+	// For the cleanup purpose, use the testing.TB received as part of Topic.
+	MakeQueue func(pattern string) pubsub.Subscriber[Data]
+	// Bind is an optional function, which binds a queue made with MakeQueue with an additional binding pattern.
+	// When supplied, the contract verifies that a queue with multiple matching binding patterns receives the message only once.
+	Bind func(queue pubsub.Subscriber[Data], pattern string)
+
+	MakeContext func() context.Context
+	MakeData    func() Data
+}
+
+func (c Topic[Data]) Test(t *testing.T) { c.Spec(testcase.NewSpec(t)) }
+
+func (c Topic[Data]) Benchmark(b *testing.B) { c.Spec(testcase.NewSpec(b)) }
+
+func (c Topic[Data]) subject() testcase.Var[TopicSubject[Data]] {
+	return testcase.Var[TopicSubject[Data]]{
+		ID:   "TopicSubject[Data]",
+		Init: func(t *testcase.T) TopicSubject[Data] { return c(t) },
+	}
+}
+
+func (c Topic[Data]) Spec(s *testcase.Spec) {
+	const routingKey = "frameless.pubsubcontracts.topic"
+
+	b := base[Data](func(tb testing.TB) baseSubject[Data] {
+		sub := c.subject().Get(testcase.ToT(&tb))
+		return baseSubject[Data]{
+			PubSub: PubSub[Data]{
+				Publisher:  topicPublisher[Data]{Exchange: sub.Exchange, RoutingKey: routingKey},
+				Subscriber: sub.MakeQueue(routingKey),
+			},
+			MakeContext: sub.MakeContext,
+			MakeData:    sub.MakeData,
+		}
+	})
+	b.Spec(s)
+
+	s.Context("exchange strategy is topic", func(s *testcase.Spec) {
+		publish := func(t *testcase.T, routingKey string, vs ...Data) {
+			t.Must.NoError(c.subject().Get(t).Exchange.Publish(c.subject().Get(t).MakeContext(), routingKey, vs...))
+		}
+
+		subscribe := func(t *testcase.T, pattern string) *pubsubtest.AsyncResults[Data] {
+			return pubsubtest.Subscribe(t, c.subject().Get(t).MakeQueue(pattern), c.subject().Get(t).MakeContext())
+		}
+
+		s.Test("a queue bound with an exact routing key only receives the messages published with that routing key", func(t *testcase.T) {
+			res := subscribe(t, "orders.eu.created")
+
+			var (
+				unexpected = c.subject().Get(t).MakeData()
+				expected   = c.subject().Get(t).MakeData()
+			)
+			publish(t, "orders.eu.deleted", unexpected)
+			publish(t, "orders.eu.created", expected)
+
+			res.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{expected}, got)
+			})
+		})
+
+		s.Test("the star wildcard in the binding pattern substitutes exactly one word", func(t *testcase.T) {
+			res := subscribe(t, "orders.*.created")
+
+			var (
+				val1 = c.subject().Get(t).MakeData()
+				val2 = c.subject().Get(t).MakeData()
+				val3 = c.subject().Get(t).MakeData()
+				val4 = c.subject().Get(t).MakeData()
+			)
+			publish(t, "orders.created", val1)
+			publish(t, "orders.eu.west.created", val2)
+			publish(t, "orders.eu.created", val3)
+			publish(t, "orders.us.created", val4)
+
+			res.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{val3, val4}, got)
+			})
+		})
+
+		s.Test("the hash wildcard in the binding pattern substitutes zero or more words", func(t *testcase.T) {
+			res := subscribe(t, "orders.#")
+
+			var (
+				val1 = c.subject().Get(t).MakeData()
+				val2 = c.subject().Get(t).MakeData()
+				val3 = c.subject().Get(t).MakeData()
+				val4 = c.subject().Get(t).MakeData()
+			)
+			publish(t, "invoices.eu.created", val1)
+			publish(t, "orders", val2)
+			publish(t, "orders.eu", val3)
+			publish(t, "orders.eu.west.created", val4)
+
+			res.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{val2, val3, val4}, got)
+			})
+		})
+
+		s.Test("a queue bound with the hash wildcard receives every message", func(t *testcase.T) {
+			res := subscribe(t, "#")
+
+			var (
+				val1 = c.subject().Get(t).MakeData()
+				val2 = c.subject().Get(t).MakeData()
+			)
+			publish(t, "orders.eu.created", val1)
+			publish(t, "invoices", val2)
+
+			res.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{val1, val2}, got)
+			})
+		})
+
+		s.Test("every queue with a matching binding pattern receives the message", func(t *testcase.T) {
+			var (
+				res1 = subscribe(t, "orders.*.created")
+				res2 = subscribe(t, "orders.#")
+				res3 = subscribe(t, "invoices.#")
+			)
+
+			var (
+				order   = c.subject().Get(t).MakeData()
+				invoice = c.subject().Get(t).MakeData()
+			)
+			publish(t, "orders.eu.created", order)
+			publish(t, "invoices.eu.created", invoice)
+
+			res1.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{order}, got)
+			})
+			res2.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{order}, got)
+			})
+			res3.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{invoice}, got)
+			})
+		})
+
+		s.Test("a queue with multiple matching binding patterns receives the message only once", func(t *testcase.T) {
+			if c.subject().Get(t).Bind == nil {
+				t.Skip("TopicSubject.Bind is not supplied")
+			}
+			queue := c.subject().Get(t).MakeQueue("orders.*.created")
+			c.subject().Get(t).Bind(queue, "orders.#")
+			res := pubsubtest.Subscribe(t, queue, c.subject().Get(t).MakeContext())
+
+			var (
+				val1 = c.subject().Get(t).MakeData()
+				val2 = c.subject().Get(t).MakeData()
+			)
+			publish(t, "orders.eu.created", val1)
+			publish(t, "orders.eu.deleted", val2)
+
+			res.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{val1, val2}, got)
+			})
+		})
+
+		s.Test("publishing a message that no binding pattern matches is not an error", func(t *testcase.T) {
+			res := subscribe(t, "orders.#")
+
+			var (
+				unexpected = c.subject().Get(t).MakeData()
+				expected   = c.subject().Get(t).MakeData()
+			)
+			publish(t, "invoices.eu.created", unexpected)
+			publish(t, "orders.eu.created", expected)
+
+			res.Eventually(t, func(tb testing.TB, got []Data) {
+				assert.Must(tb).ContainExactly([]Data{expected}, got)
+			})
+		})
+	})
+}
+
+type topicPublisher[Data any] struct {
+	Exchange   pubsub.TopicPublisher[Data]
+	RoutingKey string
+}
+
+func (p topicPublisher[Data]) Publish(ctx context.Context, vs ...Data) error {
+	return p.Exchange.Publish(ctx, p.RoutingKey, vs...)
+}
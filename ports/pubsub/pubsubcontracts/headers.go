@@ -0,0 +1,109 @@
+package pubsubcontracts
+
+import (
+	"context"
+	"testing"
+
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/frameless/ports/pubsub/pubsubtest"
+	"go.llib.dev/testcase"
+)
+
+// Headers defines a publisher behaviour where the pubsub.Headers in the publishing context
+// are attached to the published messages, and they are accessible on the received pubsub.Message.
+type Headers[Data any] func(testing.TB) HeadersSubject[Data]
+
+type HeadersSubject[Data any] struct {
+	PubSub      PubSub[Data]
+	MakeContext func() context.Context
+	MakeData    func() Data
+}
+
+func (c Headers[Data]) Spec(s *testcase.Spec) {
+	subject := testcase.Let(s, func(t *testcase.T) HeadersSubject[Data] { return c(t) })
+
+	b := base[Data](func(tb testing.TB) baseSubject[Data] {
+		sub := subject.Get(testcase.ToT(&tb))
+		return baseSubject[Data]{
+			PubSub:      sub.PubSub,
+			MakeContext: sub.MakeContext,
+			MakeData:    sub.MakeData,
+		}
+	})
+	b.Spec(s)
+
+	s.Context("headers", func(s *testcase.Spec) {
+		b.TryCleanup(s)
+
+		receive := func(t *testcase.T, ctx context.Context, v Data) pubsub.Headers {
+			ps := subject.Get(t).PubSub
+			sub := ps.Subscribe(subject.Get(t).MakeContext())
+			defer sub.Close()
+
+			t.Must.NoError(ps.Publish(ctx, v))
+			pubsubtest.Waiter.Wait()
+
+			t.Must.Within(pubsubtest.Waiter.Timeout, func(context.Context) {
+				t.Must.True(sub.Next())
+			})
+			msg := sub.Value()
+			t.Must.Equal(v, msg.Data())
+			t.Must.NoError(msg.ACK())
+			return msg.Headers()
+		}
+
+		s.Test("headers from the publishing context are attached to the message", func(t *testcase.T) {
+			expected := pubsub.Headers{
+				"correlation-id": t.Random.UUID(),
+				"tenant":         t.Random.StringNWithCharset(8, "abcdefghijklmnopqrstuvwxyz"),
+			}
+			ctx := pubsub.ContextWithHeaders(subject.Get(t).MakeContext(), expected)
+
+			got := receive(t, ctx, subject.Get(t).MakeData())
+			for k, v := range expected {
+				t.Must.Equal(v, got[k])
+			}
+		})
+
+		s.Test("message published without headers has only its message ID", func(t *testcase.T) {
+			got := receive(t, subject.Get(t).MakeContext(), subject.Get(t).MakeData())
+			t.Must.NotEmpty(got[pubsub.HeaderMessageID])
+			delete(got, pubsub.HeaderMessageID)
+			t.Must.Empty(got)
+		})
+
+		s.Test("every message of a multi-value publish has its own message ID, and delivered exactly once", func(t *testcase.T) {
+			var (
+				ps  = subject.Get(t).PubSub
+				sub = ps.Subscribe(subject.Get(t).MakeContext())
+				vs  = []Data{subject.Get(t).MakeData(), subject.Get(t).MakeData(), subject.Get(t).MakeData()}
+			)
+			defer sub.Close()
+			ctx := pubsub.ContextWithHeaders(subject.Get(t).MakeContext(), pubsub.Headers{"correlation-id": t.Random.UUID()})
+			t.Must.NoError(ps.Publish(ctx, vs...))
+			pubsubtest.Waiter.Wait()
+
+			var (
+				got = make([]Data, 0, len(vs))
+				ids = make(map[string]struct{})
+			)
+			for range vs {
+				t.Must.Within(pubsubtest.Waiter.Timeout, func(context.Context) {
+					t.Must.True(sub.Next())
+				})
+				msg := sub.Value()
+				got = append(got, msg.Data())
+				id := msg.Headers()[pubsub.HeaderMessageID]
+				t.Must.NotEmpty(id)
+				ids[id] = struct{}{}
+				t.Must.NoError(msg.ACK())
+			}
+			t.Must.ContainExactly(vs, got)
+			t.Must.Equal(len(vs), len(ids), "message IDs are expected to be unique")
+		})
+	})
+}
+
+func (c Headers[Data]) Test(t *testing.T) { c.Spec(testcase.NewSpec(t)) }
+
+func (c Headers[Data]) Benchmark(b *testing.B) { c.Spec(testcase.NewSpec(b)) }
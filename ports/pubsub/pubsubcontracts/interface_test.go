@@ -8,9 +8,13 @@ import (
 var (
 	_ testcase.OpenSuite = pubsubcontracts.FIFO[any](nil)
 	_ testcase.OpenSuite = pubsubcontracts.LIFO[any](nil)
+	_ testcase.OpenSuite = pubsubcontracts.Priority[any](nil)
 	_ testcase.OpenSuite = pubsubcontracts.Buffered[any](nil)
 	_ testcase.OpenSuite = pubsubcontracts.Volatile[any](nil)
 	_ testcase.OpenSuite = pubsubcontracts.Queue[any](nil)
 	_ testcase.OpenSuite = pubsubcontracts.FanOut[any](nil)
+	_ testcase.OpenSuite = pubsubcontracts.Topic[any](nil)
 	_ testcase.OpenSuite = pubsubcontracts.Blocking[any](nil)
+	_ testcase.OpenSuite = pubsubcontracts.Headers[any](nil)
+	_ testcase.OpenSuite = pubsubcontracts.Deduplication(nil)
 )
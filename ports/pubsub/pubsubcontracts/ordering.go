@@ -2,6 +2,7 @@ package pubsubcontracts
 
 import (
 	"context"
+	"go.llib.dev/frameless/ports/pubsub"
 	"go.llib.dev/frameless/ports/pubsub/pubsubtest"
 	"testing"
 
@@ -27,7 +28,7 @@ func (c Ordering[Data]) Spec(s *testcase.Spec) {
 	subject := testcase.Let(s, func(t *testcase.T) OrderingSubject[Data] { return c(t) })
 
 	b := base[Data](func(tb testing.TB) baseSubject[Data] {
-		sub := c(tb)
+		sub := subject.Get(testcase.ToT(&tb))
 		return baseSubject[Data]{
 			PubSub:      sub.PubSub,
 			MakeContext: sub.MakeContext,
@@ -96,7 +97,7 @@ func (c FIFO[Data]) Spec(s *testcase.Spec) {
 	subject := testcase.Let(s, func(t *testcase.T) FIFOSubject[Data] { return c(t) })
 
 	b := base[Data](func(tb testing.TB) baseSubject[Data] {
-		sub := c(tb)
+		sub := subject.Get(testcase.ToT(&tb))
 		return baseSubject[Data]{
 			PubSub:      sub.PubSub,
 			MakeContext: sub.MakeContext,
@@ -202,3 +203,110 @@ func (c LIFO[Data]) Spec(s *testcase.Spec) {
 func (c LIFO[Data]) Test(t *testing.T) { c.Spec(testcase.NewSpec(t)) }
 
 func (c LIFO[Data]) Benchmark(b *testing.B) { c.Spec(testcase.NewSpec(b)) }
+
+// Priority defines a publisher behaviour where messages published with a higher priority
+// are received before the messages with a lower priority.
+// Messages with the same priority are received in their publishing order (FIFO).
+//
+// The priority of the published messages is set with pubsub.ContextWithPriority.
+type Priority[Data any] func(testing.TB) PrioritySubject[Data]
+
+type PrioritySubject[Data any] struct {
+	PubSub      PubSub[Data]
+	MakeContext func() context.Context
+	MakeData    func() Data
+}
+
+func (c Priority[Data]) Spec(s *testcase.Spec) {
+	subject := testcase.Let(s, func(t *testcase.T) PrioritySubject[Data] { return c(t) })
+
+	b := base[Data](func(tb testing.TB) baseSubject[Data] {
+		sub := subject.Get(testcase.ToT(&tb))
+		return baseSubject[Data]{
+			PubSub:      sub.PubSub,
+			MakeContext: sub.MakeContext,
+			MakeData:    sub.MakeData,
+		}
+	})
+	b.Spec(s)
+
+	s.Context("ordering is based on priority", func(s *testcase.Spec) {
+		b.TryCleanup(s)
+
+		// publish publishes the messages one by one with the given context to make the test deterministic.
+		publish := func(t *testcase.T, ctx context.Context, vs ...Data) {
+			for _, v := range vs {
+				t.Must.NoError(subject.Get(t).PubSub.Publish(ctx, v))
+				pubsubtest.Waiter.Wait()
+			}
+		}
+		withPriority := func(t *testcase.T, priority int) context.Context {
+			return pubsub.ContextWithPriority(subject.Get(t).MakeContext(), priority)
+		}
+		// receive subscribes, and collects the n received data in order.
+		receive := func(t *testcase.T, n int) []Data {
+			sub := subject.Get(t).PubSub.Subscribe(subject.Get(t).MakeContext())
+			defer sub.Close()
+
+			var got []Data
+			for i := 0; i < n; i++ {
+				t.Must.Within(pubsubtest.Waiter.Timeout, func(context.Context) {
+					t.Must.True(sub.Next())
+				})
+				msg := sub.Value()
+				got = append(got, msg.Data())
+				t.Must.NoError(msg.ACK())
+			}
+			return got
+		}
+
+		s.Test("messages with higher priority are received first", func(t *testcase.T) {
+			var (
+				low    = subject.Get(t).MakeData()
+				high   = subject.Get(t).MakeData()
+				medium = subject.Get(t).MakeData()
+			)
+
+			publish(t, withPriority(t, 1), low)
+			publish(t, withPriority(t, 10), high)
+			publish(t, withPriority(t, 5), medium)
+
+			t.Must.Equal([]Data{high, medium, low}, receive(t, 3))
+		})
+
+		s.Test("messages with the same priority are received in their publishing order", func(t *testcase.T) {
+			var (
+				val1   = subject.Get(t).MakeData()
+				val2   = subject.Get(t).MakeData()
+				val3   = subject.Get(t).MakeData()
+				urgent = subject.Get(t).MakeData()
+			)
+
+			publish(t, withPriority(t, 1), val1, val2)
+			publish(t, withPriority(t, 42), urgent)
+			publish(t, withPriority(t, 1), val3)
+
+			t.Must.Equal([]Data{urgent, val1, val2, val3}, receive(t, 4))
+		})
+
+		s.Test("messages published without priority have the priority of zero", func(t *testcase.T) {
+			var (
+				none1    = subject.Get(t).MakeData()
+				none2    = subject.Get(t).MakeData()
+				positive = subject.Get(t).MakeData()
+				negative = subject.Get(t).MakeData()
+			)
+
+			publish(t, subject.Get(t).MakeContext(), none1)
+			publish(t, withPriority(t, -1), negative)
+			publish(t, subject.Get(t).MakeContext(), none2)
+			publish(t, withPriority(t, 1), positive)
+
+			t.Must.Equal([]Data{positive, none1, none2, negative}, receive(t, 4))
+		})
+	})
+}
+
+func (c Priority[Data]) Test(t *testing.T) { c.Spec(testcase.NewSpec(t)) }
+
+func (c Priority[Data]) Benchmark(b *testing.B) { c.Spec(testcase.NewSpec(b)) }
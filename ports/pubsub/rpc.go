@@ -0,0 +1,179 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.llib.dev/testcase/random"
+)
+
+const (
+	// HeaderCorrelationID is the header that correlates a reply with its request.
+	HeaderCorrelationID = "correlation-id"
+	// HeaderReplyTo is the header that holds the address where the reply to a request is expected.
+	HeaderReplyTo = "reply-to"
+	// HeaderReplyError is the header that holds the error message when the request handling failed.
+	HeaderReplyError = "reply-error"
+)
+
+// ReplyError is returned by Requester.Request when the Responder failed to handle the request.
+type ReplyError struct {
+	Message string
+}
+
+func (err ReplyError) Error() string {
+	return err.Message
+}
+
+// Requester publishes requests and waits for their replies,
+// which makes it possible to use pubsub queues for synchronous-looking commands.
+//
+// Requester.Run must be running to receive the replies.
+// Requester.Run is a tasker.Task compatible function.
+type Requester[Request, Reply any] struct {
+	// Publisher is where the requests are published.
+	Publisher Publisher[Request]
+	// Replies is the subscriber of the reply queue.
+	Replies Subscriber[Reply]
+	// ReplyTo is the address of the reply queue,
+	// which the Responder uses to publish the replies.
+	ReplyTo string
+
+	m       sync.Mutex
+	pending map[string]chan requesterResult[Reply]
+}
+
+type requesterResult[Reply any] struct {
+	Reply Reply
+	Err   error
+}
+
+// Request publishes the request with a correlation ID and the reply-to address,
+// then waits until the matching reply arrives or the context is done.
+func (r *Requester[Request, Reply]) Request(ctx context.Context, req Request) (Reply, error) {
+	if r.ReplyTo == "" {
+		return *new(Reply), fmt.Errorf("missing pubsub.Requester.ReplyTo")
+	}
+
+	correlationID := random.New(random.CryptoSeed{}).UUID()
+	results := r.register(correlationID)
+	defer r.unregister(correlationID)
+
+	ctx = ContextWithHeaders(ctx, Headers{
+		HeaderCorrelationID: correlationID,
+		HeaderReplyTo:       r.ReplyTo,
+	})
+	if err := r.Publisher.Publish(ctx, req); err != nil {
+		return *new(Reply), err
+	}
+
+	select {
+	case <-ctx.Done():
+		return *new(Reply), ctx.Err()
+	case res := <-results:
+		return res.Reply, res.Err
+	}
+}
+
+// Run receives the replies and dispatches them to the waiting Request calls.
+// Replies that no Request waits for anymore, for example due to a timeout, are acknowledged and dropped.
+func (r *Requester[Request, Reply]) Run(ctx context.Context) error {
+	return Consumer[Reply]{
+		Subscriber: r.Replies,
+		Handler:    r.dispatch,
+	}.Run(ctx)
+}
+
+func (r *Requester[Request, Reply]) dispatch(ctx context.Context, reply Reply) error {
+	hs, _ := LookupHeaders(ctx)
+	r.m.Lock()
+	results, ok := r.pending[hs[HeaderCorrelationID]]
+	r.m.Unlock()
+	if !ok {
+		return nil
+	}
+	var res = requesterResult[Reply]{Reply: reply}
+	if msg, ok := hs[HeaderReplyError]; ok {
+		res = requesterResult[Reply]{Err: ReplyError{Message: msg}}
+	}
+	select {
+	case results <- res:
+	default: // already replied
+	}
+	return nil
+}
+
+func (r *Requester[Request, Reply]) register(correlationID string) <-chan requesterResult[Reply] {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[string]chan requesterResult[Reply])
+	}
+	ch := make(chan requesterResult[Reply], 1)
+	r.pending[correlationID] = ch
+	return ch
+}
+
+func (r *Requester[Request, Reply]) unregister(correlationID string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.pending, correlationID)
+}
+
+// Responder handles the requests published by a Requester, and publishes the replies to the request's reply-to address.
+// When the Handler returns with an error, the error message is replied back, and the Requester returns it as a ReplyError.
+// Requests without a reply-to address are handled without replying to them.
+// The Handler receives the request headers without the reply-to address and the correlation ID,
+// thus the messages it publishes aren't mistaken for replies.
+//
+// Responder.Run is a tasker.Task compatible function.
+type Responder[Request, Reply any] struct {
+	// Requests is the subscriber of the request queue.
+	Requests Subscriber[Request]
+	// Handler handles a request and returns with its reply.
+	Handler func(ctx context.Context, req Request) (Reply, error)
+	// Replies returns the Publisher of the reply queue which is identified by the reply-to address.
+	Replies func(replyTo string) Publisher[Reply]
+	// Concurrency is the maximum number of requests handled in parallel.
+	//
+	// Default: 1
+	Concurrency int
+}
+
+func (r Responder[Request, Reply]) Run(ctx context.Context) error {
+	if r.Replies == nil {
+		return fmt.Errorf("missing pubsub.Responder.Replies")
+	}
+	return Consumer[Request]{
+		Subscriber:  r.Requests,
+		Handler:     r.handle,
+		Concurrency: r.Concurrency,
+	}.Run(ctx)
+}
+
+func (r Responder[Request, Reply]) handle(ctx context.Context, req Request) error {
+	hs, _ := LookupHeaders(ctx)
+	reply, err := r.Handler(r.handlerContext(ctx, hs), req)
+	replyTo, ok := hs[HeaderReplyTo]
+	if !ok {
+		return err
+	}
+	delete(hs, HeaderReplyTo)
+	if err != nil {
+		hs[HeaderReplyError] = err.Error()
+	}
+	// the reply inherits the request headers, except the reply-to address
+	ctx = context.WithValue(ctx, ctxKeyHeaders{}, hs)
+	return r.Replies(replyTo).Publish(ctx, reply)
+}
+
+// handlerContext removes the RPC headers from the context of the Handler.
+// They only belong to the current request,
+// and a message published by the Handler with them would be replied to the Requester as well.
+func (r Responder[Request, Reply]) handlerContext(ctx context.Context, hs Headers) context.Context {
+	hs = hs.Clone()
+	delete(hs, HeaderReplyTo)
+	delete(hs, HeaderCorrelationID)
+	return context.WithValue(ctx, ctxKeyHeaders{}, hs)
+}
@@ -0,0 +1,158 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.llib.dev/frameless/adapters/memory"
+	"go.llib.dev/frameless/pkg/tasker"
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase"
+)
+
+var (
+	_ tasker.Runnable = &pubsub.Requester[string, string]{}
+	_ tasker.Runnable = pubsub.Responder[string, string]{}
+)
+
+func TestRequester_Request(t *testing.T) {
+	s := testcase.NewSpec(t)
+
+	var (
+		handler   = testcase.Var[func(ctx context.Context, req string) (string, error)]{ID: "Responder.Handler"}
+		requester = testcase.Let(s, func(t *testcase.T) *pubsub.Requester[string, string] {
+			var (
+				m        = memory.NewMemory()
+				requests = &memory.Queue[string]{Memory: m, Namespace: "requests/" + t.Random.UUID()}
+				replyTo  = "replies/" + t.Random.UUID()
+			)
+			requester := &pubsub.Requester[string, string]{
+				Publisher: requests,
+				Replies:   &memory.Queue[string]{Memory: m, Namespace: replyTo},
+				ReplyTo:   replyTo,
+			}
+			responder := pubsub.Responder[string, string]{
+				Requests: requests,
+				Handler:  handler.Get(t),
+				Replies: func(replyTo string) pubsub.Publisher[string] {
+					return &memory.Queue[string]{Memory: m, Namespace: replyTo}
+				},
+				Concurrency: 4,
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				t.Should.NoError(requester.Run(ctx))
+			}()
+			go func() {
+				defer wg.Done()
+				t.Should.NoError(responder.Run(ctx))
+			}()
+			t.Defer(wg.Wait)
+			t.Defer(cancel)
+			return requester
+		})
+		timeout = testcase.LetValue(s, 5*time.Second)
+		ctx     = testcase.Let(s, func(t *testcase.T) context.Context {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout.Get(t))
+			t.Defer(cancel)
+			return ctx
+		})
+	)
+	act := func(t *testcase.T, req string) (string, error) {
+		return requester.Get(t).Request(ctx.Get(t), req)
+	}
+
+	s.Context("when the handler replies", func(s *testcase.Spec) {
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, req string) (string, error) {
+			return func(ctx context.Context, req string) (string, error) {
+				return "reply:" + req, nil
+			}
+		})
+
+		s.Test("the reply of the request is returned", func(t *testcase.T) {
+			reply, err := act(t, "hello")
+			t.Must.NoError(err)
+			t.Must.Equal("reply:hello", reply)
+		})
+
+		s.Test("concurrent requests receive their own reply", func(t *testcase.T) {
+			requester.Get(t) // start the requester and the responder before the concurrent requests
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(req string) {
+					defer wg.Done()
+					reply, err := act(t, req)
+					t.Should.NoError(err)
+					t.Should.Equal("reply:"+req, reply)
+				}(fmt.Sprintf("req-%d", i))
+			}
+			wg.Wait()
+		})
+	})
+
+	s.Context("when the handler fails", func(s *testcase.Spec) {
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, req string) (string, error) {
+			return func(ctx context.Context, req string) (string, error) {
+				return "", errors.New("boom")
+			}
+		})
+
+		s.Test("the error of the handler is returned as a ReplyError", func(t *testcase.T) {
+			_, err := act(t, "hello")
+			var replyErr pubsub.ReplyError
+			t.Must.True(errors.As(err, &replyErr))
+			t.Must.Equal("boom", replyErr.Message)
+		})
+	})
+
+	s.Context("when the request has headers", func(s *testcase.Spec) {
+		tenant := testcase.Let(s, func(t *testcase.T) string {
+			return t.Random.StringNWithCharset(6, "0123456789")
+		})
+		ctx.Let(s, func(t *testcase.T) context.Context {
+			return pubsub.ContextWithHeaders(ctx.Super(t), pubsub.Headers{"tenant": tenant.Get(t)})
+		})
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, req string) (string, error) {
+			return func(ctx context.Context, req string) (string, error) {
+				hs, _ := pubsub.LookupHeaders(ctx)
+				return hs[pubsub.HeaderReplyTo] + hs[pubsub.HeaderCorrelationID] + hs["tenant"], nil
+			}
+		})
+
+		s.Test("they are available for the handler, without the RPC headers of the request", func(t *testcase.T) {
+			reply, err := act(t, "hello")
+			t.Must.NoError(err)
+			t.Must.Equal(tenant.Get(t), reply)
+		})
+	})
+
+	s.Context("when the reply doesn't arrive in time", func(s *testcase.Spec) {
+		timeout.LetValue(s, 50*time.Millisecond)
+		release := testcase.Let(s, func(t *testcase.T) chan struct{} {
+			return make(chan struct{})
+		})
+		handler.Let(s, func(t *testcase.T) func(ctx context.Context, req string) (string, error) {
+			release := release.Get(t)
+			return func(ctx context.Context, req string) (string, error) {
+				<-release
+				return req, nil
+			}
+		})
+
+		s.Test("the context error is returned", func(t *testcase.T) {
+			requester.Get(t)
+			// the in-flight request is drained on shutdown, thus it must be released before the responder stops
+			t.Defer(func() { close(release.Get(t)) })
+			_, err := act(t, "hello")
+			t.Must.ErrorIs(context.DeadlineExceeded, err)
+		})
+	})
+}
@@ -0,0 +1,30 @@
+package pubsub
+
+import "strings"
+
+// MatchRoutingKey reports whether a routing key matches a topic exchange binding pattern.
+//
+// The routing key and the pattern are made of words delimited by dots, like "orders.eu.created".
+// In the pattern, "*" substitutes exactly one word, and "#" substitutes zero or more words.
+func MatchRoutingKey(pattern, routingKey string) bool {
+	return matchRoutingKeyWords(strings.Split(pattern, "."), strings.Split(routingKey, "."))
+}
+
+func matchRoutingKeyWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if matchRoutingKeyWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return 0 < len(key) && matchRoutingKeyWords(pattern[1:], key[1:])
+	default:
+		return 0 < len(key) && pattern[0] == key[0] && matchRoutingKeyWords(pattern[1:], key[1:])
+	}
+}
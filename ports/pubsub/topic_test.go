@@ -0,0 +1,40 @@
+package pubsub_test
+
+import (
+	"testing"
+
+	"go.llib.dev/frameless/ports/pubsub"
+	"go.llib.dev/testcase/assert"
+)
+
+func TestMatchRoutingKey(t *testing.T) {
+	type TC struct {
+		Pattern    string
+		RoutingKey string
+		Match      bool
+	}
+	for _, tc := range []TC{
+		{Pattern: "orders.created", RoutingKey: "orders.created", Match: true},
+		{Pattern: "orders.created", RoutingKey: "orders.deleted", Match: false},
+		{Pattern: "orders.created", RoutingKey: "orders.created.eu", Match: false},
+		{Pattern: "orders.*.created", RoutingKey: "orders.eu.created", Match: true},
+		{Pattern: "orders.*.created", RoutingKey: "orders.created", Match: false},
+		{Pattern: "orders.*.created", RoutingKey: "orders.eu.west.created", Match: false},
+		{Pattern: "orders.*", RoutingKey: "orders.eu.created", Match: false},
+		{Pattern: "#", RoutingKey: "orders.eu.created", Match: true},
+		{Pattern: "#", RoutingKey: "orders", Match: true},
+		{Pattern: "orders.#", RoutingKey: "orders", Match: true},
+		{Pattern: "orders.#", RoutingKey: "orders.eu.west.created", Match: true},
+		{Pattern: "orders.#", RoutingKey: "invoices.eu", Match: false},
+		{Pattern: "orders.#.created", RoutingKey: "orders.eu.west.created", Match: true},
+		{Pattern: "orders.#.created", RoutingKey: "orders.created", Match: true},
+		{Pattern: "orders.#.created", RoutingKey: "orders.eu.deleted", Match: false},
+		{Pattern: "*.#", RoutingKey: "orders", Match: true},
+		{Pattern: "*.*", RoutingKey: "orders", Match: false},
+	} {
+		tc := tc
+		t.Run(tc.Pattern+" <> "+tc.RoutingKey, func(t *testing.T) {
+			assert.Equal(t, tc.Match, pubsub.MatchRoutingKey(tc.Pattern, tc.RoutingKey))
+		})
+	}
+}